@@ -0,0 +1,620 @@
+package vroom
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/veandco/go-sdl2/sdl"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kinds of inputs actions can be bound to
+const (
+	INPUTKEY           = iota // Code is a sdl.Keycode
+	INPUTMOUSEBUTTON          // Code is sdl.BUTTON_LEFT, sdl.BUTTON_RIGHT...
+	INPUTGAMEPADBUTTON        // Code is a sdl.GameControllerButton
+	INPUTGAMEPADAXIS          // Code is a sdl.GameControllerAxis
+)
+
+// How far gamepad axes have to be pushed to count as pressed or to be picked up while listening
+const AXISPRESSTHRESHOLD = 0.5
+
+// One physical input. Axis bindings with a Sign of 1 or -1 only use that half of the axis,
+// with 0 the whole axis is used, which only makes a difference for analog axes
+type InputBinding struct {
+	Kind int
+	Code int
+	Sign int
+}
+
+func KeyBinding(key sdl.Keycode) InputBinding {
+	return InputBinding{Kind: INPUTKEY, Code: int(key)}
+}
+
+func MouseButtonBinding(button int) InputBinding {
+	return InputBinding{Kind: INPUTMOUSEBUTTON, Code: button}
+}
+
+func GamepadButtonBinding(button sdl.GameControllerButton) InputBinding {
+	return InputBinding{Kind: INPUTGAMEPADBUTTON, Code: int(button)}
+}
+
+func GamepadAxisBinding(axis sdl.GameControllerAxis, sign int) InputBinding {
+	return InputBinding{Kind: INPUTGAMEPADAXIS, Code: int(axis), Sign: sign}
+}
+
+// The binding as it's written in binding files, "key:Space", "mouse:1", "button:a", "axis:leftx+"
+// Also fine for showing in menus
+func (b InputBinding) String() string {
+	switch b.Kind {
+	case INPUTKEY:
+		return "key:" + sdl.GetKeyName(sdl.Keycode(b.Code))
+	case INPUTMOUSEBUTTON:
+		return "mouse:" + strconv.Itoa(b.Code)
+	case INPUTGAMEPADBUTTON:
+		return "button:" + sdl.GameControllerGetStringForButton(sdl.GameControllerButton(b.Code))
+	case INPUTGAMEPADAXIS:
+		sign := ""
+		if b.Sign > 0 {
+			sign = "+"
+		} else if b.Sign < 0 {
+			sign = "-"
+		}
+		return "axis:" + sdl.GameControllerGetStringForAxis(sdl.GameControllerAxis(b.Code)) + sign
+	}
+	return "unknown"
+}
+
+// Parses a binding written by String
+func ParseInputBinding(s string) (InputBinding, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return InputBinding{}, fmt.Errorf("Invalid binding %q", s)
+	}
+	name := parts[1]
+
+	switch parts[0] {
+	case "key":
+		key := sdl.GetKeyFromName(name)
+		if key == sdl.K_UNKNOWN {
+			return InputBinding{}, fmt.Errorf("Unknown key %q", name)
+		}
+		return KeyBinding(key), nil
+	case "mouse":
+		button, err := strconv.Atoi(name)
+		if err != nil {
+			return InputBinding{}, fmt.Errorf("Invalid mouse button %q", name)
+		}
+		return MouseButtonBinding(button), nil
+	case "button":
+		button := sdl.GameControllerGetButtonFromString(name)
+		if button == sdl.CONTROLLER_BUTTON_INVALID {
+			return InputBinding{}, fmt.Errorf("Unknown gamepad button %q", name)
+		}
+		return GamepadButtonBinding(button), nil
+	case "axis":
+		sign := 0
+		if strings.HasSuffix(name, "+") {
+			sign = 1
+		} else if strings.HasSuffix(name, "-") {
+			sign = -1
+		}
+		name = strings.TrimRight(name, "+-")
+		axis := sdl.GameControllerGetAxisFromString(name)
+		if axis == sdl.CONTROLLER_AXIS_INVALID {
+			return InputBinding{}, fmt.Errorf("Unknown gamepad axis %q", name)
+		}
+		return GamepadAxisBinding(axis, sign), nil
+	}
+	return InputBinding{}, fmt.Errorf("Unknown binding kind %q", parts[0])
+}
+
+// Named button like input, pressed while any of its bindings is
+type Action struct {
+	Name     string
+	Bindings []InputBinding
+
+	down     bool
+	pressed  bool // Went down this frame
+	released bool // Went up this frame
+}
+
+// Named axis between -1 and 1, bindings in Negative pull it towards -1 and the ones in Positive
+// towards 1. Keys and buttons give the full value, analog axes how far they're pushed
+// Whole axes (Sign 0) already go both ways and are used as they are in either list
+// The strongest input wins
+type InputAxis struct {
+	Name     string
+	Negative []InputBinding
+	Positive []InputBinding
+}
+
+// Maps keys, mouse buttons and gamepad inputs to named actions and axes so gameplay code
+// doesn't have to know about the physical inputs, which can then be rebound and saved
+// Keys used by the UI (typing, navigation) don't reach the actions
+type ActionMap struct {
+	DeadZone      float64 // Analog values closer to 0 than this are 0, the rest is rescaled
+	GamepadPlayer int     // Only the gamepad of this player is used, NOPLAYER for any gamepad, see SetGamepadPlayer
+
+	actions map[string]*Action
+	axes    map[string]*InputAxis
+
+	// Raw state of the bound inputs
+	down map[InputBinding]bool // Keys and mouse buttons
+	pads map[sdl.JoystickID]*padInput
+
+	listener func(InputBinding)
+}
+
+func NewActionMap() *ActionMap {
+	return &ActionMap{
+		DeadZone:      GAMEPADDEADZONE,
+		GamepadPlayer: NOPLAYER,
+		actions:       make(map[string]*Action),
+		axes:          make(map[string]*InputAxis),
+		down:          make(map[InputBinding]bool),
+		pads:          make(map[sdl.JoystickID]*padInput),
+	}
+}
+
+// Adds an action or replaces the bindings of an existing one
+func (am *ActionMap) AddAction(name string, bindings ...InputBinding) *Action {
+	action, ok := am.actions[name]
+	if !ok {
+		action = &Action{Name: name}
+		am.actions[name] = action
+	}
+	action.Bindings = bindings
+	am.refresh(action)
+	return action
+}
+
+// Adds an axis or replaces the bindings of an existing one
+func (am *ActionMap) AddAxis(name string, negative, positive []InputBinding) *InputAxis {
+	axis := &InputAxis{Name: name, Negative: negative, Positive: positive}
+	am.axes[name] = axis
+	return axis
+}
+
+func (am *ActionMap) Action(name string) *Action {
+	return am.actions[name]
+}
+
+func (am *ActionMap) InputAxis(name string) *InputAxis {
+	return am.axes[name]
+}
+
+// Names of the actions, sorted
+func (am *ActionMap) Actions() []string {
+	names := make([]string, 0, len(am.actions))
+	for name := range am.actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Names of the axes, sorted
+func (am *ActionMap) Axes() []string {
+	names := make([]string, 0, len(am.axes))
+	for name := range am.axes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Adds a binding to an action, creating the action if there's none
+func (am *ActionMap) Bind(name string, binding InputBinding) {
+	action, ok := am.actions[name]
+	if !ok {
+		am.AddAction(name, binding)
+		return
+	}
+	for _, v := range action.Bindings {
+		if v == binding {
+			return
+		}
+	}
+	action.Bindings = append(action.Bindings, binding)
+	am.refresh(action)
+}
+
+// Replaces the binding at index of the action, or adds it if index is past the end
+// Rebinding menus use this with the binding from ListenForInput
+func (am *ActionMap) Rebind(name string, index int, binding InputBinding) {
+	action, ok := am.actions[name]
+	if !ok {
+		am.AddAction(name, binding)
+		return
+	}
+	if index < 0 || index >= len(action.Bindings) {
+		action.Bindings = append(action.Bindings, binding)
+	} else {
+		action.Bindings[index] = binding
+	}
+	am.refresh(action)
+}
+
+// Removes a binding from every action and axis that uses it, so it can be given to another one
+func (am *ActionMap) Unbind(binding InputBinding) {
+	for _, action := range am.actions {
+		action.Bindings = removeBinding(action.Bindings, binding)
+		am.refresh(action)
+	}
+	for _, axis := range am.axes {
+		axis.Negative = removeBinding(axis.Negative, binding)
+		axis.Positive = removeBinding(axis.Positive, binding)
+	}
+}
+
+func removeBinding(bindings []InputBinding, binding InputBinding) []InputBinding {
+	out := bindings[:0]
+	for _, v := range bindings {
+		if v != binding {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// True while any of the bindings of the action is held
+func (am *ActionMap) Pressed(name string) bool {
+	action, ok := am.actions[name]
+	return ok && action.down
+}
+
+// True in the frame the action went down
+func (am *ActionMap) JustPressed(name string) bool {
+	action, ok := am.actions[name]
+	return ok && action.pressed
+}
+
+// True in the frame the action went up
+func (am *ActionMap) JustReleased(name string) bool {
+	action, ok := am.actions[name]
+	return ok && action.released
+}
+
+// Value of the axis between -1 and 1, 0 for unknown axes
+func (am *ActionMap) Axis(name string) float64 {
+	axis, ok := am.axes[name]
+	if !ok {
+		return 0
+	}
+	value := 0.0
+	for _, binding := range axis.Negative {
+		v := am.value(binding)
+		if binding.Kind != INPUTGAMEPADAXIS || binding.Sign != 0 {
+			v = -v
+		}
+		if math.Abs(v) > math.Abs(value) {
+			value = v
+		}
+	}
+	for _, binding := range axis.Positive {
+		if v := am.value(binding); math.Abs(v) > math.Abs(value) {
+			value = v
+		}
+	}
+	return value
+}
+
+// Axis value of the binding with the dead zone applied, 0 or 1 for keys and buttons
+func (am *ActionMap) value(binding InputBinding) float64 {
+	if binding.Kind != INPUTGAMEPADAXIS {
+		if am.down[binding] {
+			return 1
+		}
+		return 0
+	}
+
+	raw := am.axisValue(binding)
+	if math.Abs(raw) <= am.DeadZone {
+		return 0
+	}
+	scaled := (math.Abs(raw) - am.DeadZone) / (1 - am.DeadZone)
+	return math.Copysign(math.Min(1, scaled), raw)
+}
+
+// Whether the binding counts as held, axes when they're pushed past AXISPRESSTHRESHOLD
+func (am *ActionMap) active(binding InputBinding) bool {
+	switch binding.Kind {
+	case INPUTGAMEPADBUTTON:
+		for _, pad := range am.pads {
+			if am.acceptsGamepad(pad.pad) && pad.buttons[binding.Code] {
+				return true
+			}
+		}
+		return false
+	case INPUTGAMEPADAXIS:
+		return math.Abs(am.axisValue(binding)) >= AXISPRESSTHRESHOLD
+	}
+	return am.down[binding]
+}
+
+// Raw value of the axis binding, with only its half if it has a Sign
+// The gamepad pushing it the furthest wins
+func (am *ActionMap) axisValue(binding InputBinding) float64 {
+	value := 0.0
+	for _, pad := range am.pads {
+		if !am.acceptsGamepad(pad.pad) {
+			continue
+		}
+		v := pad.axes[binding.Code]
+		if binding.Sign != 0 {
+			v = math.Max(0, v*float64(binding.Sign))
+		}
+		if math.Abs(v) > math.Abs(value) {
+			value = v
+		}
+	}
+	return value
+}
+
+// Updates the held state of the action, setting pressed or released when it changes
+func (am *ActionMap) refresh(action *Action) {
+	down := false
+	for _, binding := range action.Bindings {
+		if am.active(binding) {
+			down = true
+			break
+		}
+	}
+	if down == action.down {
+		return
+	}
+	action.down = down
+	if down {
+		action.pressed = true
+	} else {
+		action.released = true
+	}
+}
+
+// Calls fn with the next key, mouse button or gamepad button pressed or axis pushed, instead of
+// it doing anything else. For rebinding menus, the binding can be given to Rebind
+func (am *ActionMap) ListenForInput(fn func(binding InputBinding)) {
+	am.listener = fn
+}
+
+func (am *ActionMap) StopListening() {
+	am.listener = nil
+}
+
+func (am *ActionMap) Listening() bool {
+	return am.listener != nil
+}
+
+// Passes the binding to the listener if there is one, returns true if it took it
+func (am *ActionMap) listen(binding InputBinding) bool {
+	if am.listener == nil {
+		return false
+	}
+	listener := am.listener
+	am.listener = nil
+	listener(binding)
+	return true
+}
+
+// State of one gamepad, so a gamepad letting go of a button or resting its stick doesn't
+// undo what another one is doing
+type padInput struct {
+	pad     *Gamepad
+	buttons [sdl.CONTROLLER_BUTTON_MAX]bool
+	axes    [sdl.CONTROLLER_AXIS_MAX]float64 // -1 to 1
+}
+
+func (am *ActionMap) acceptsGamepad(pad *Gamepad) bool {
+	return pad != nil && (am.GamepadPlayer == NOPLAYER || pad.Player == am.GamepadPlayer)
+}
+
+// Only uses the gamepad of player from now on, NOPLAYER for any gamepad
+func (am *ActionMap) SetGamepadPlayer(player int) {
+	am.GamepadPlayer = player
+	for _, action := range am.actions {
+		am.refresh(action)
+	}
+}
+
+func (am *ActionMap) padInput(pad *Gamepad) *padInput {
+	input, ok := am.pads[pad.ID]
+	if !ok {
+		input = &padInput{pad: pad}
+		am.pads[pad.ID] = input
+	}
+	return input
+}
+
+// Lets go of what's held on the gamepad, before it's removed
+func (am *ActionMap) removeGamepad(pad *Gamepad) {
+	if pad == nil {
+		return
+	}
+	if _, ok := am.pads[pad.ID]; !ok {
+		return
+	}
+	delete(am.pads, pad.ID)
+	for _, action := range am.actions {
+		am.refresh(action)
+	}
+}
+
+// Called at the start of every frame before the events, pressed and released only last one frame
+func (am *ActionMap) beginFrame() {
+	for _, action := range am.actions {
+		action.pressed = false
+		action.released = false
+	}
+}
+
+// A key or mouse button went down or up, returns true if it was taken by the listener
+func (am *ActionMap) buttonEvent(binding InputBinding, down bool) bool {
+	if down && !am.down[binding] && am.listen(binding) {
+		return true
+	}
+	if am.down[binding] == down {
+		return false // Key repeat
+	}
+	am.down[binding] = down
+	am.refreshBinding(binding)
+	return false
+}
+
+// A button on the gamepad went down or up, returns true if it was taken by the listener
+func (am *ActionMap) gamepadButtonEvent(pad *Gamepad, button sdl.GameControllerButton, down bool) bool {
+	if !validButton(button) {
+		return false
+	}
+	input := am.padInput(pad)
+	if input.buttons[button] == down {
+		return false
+	}
+	if down && am.acceptsGamepad(pad) && am.listen(GamepadButtonBinding(button)) {
+		return true
+	}
+	input.buttons[button] = down
+	am.refreshBinding(GamepadButtonBinding(button))
+	return false
+}
+
+// An axis of the gamepad moved, value between -1 and 1
+func (am *ActionMap) gamepadAxisEvent(pad *Gamepad, axis sdl.GameControllerAxis, value float64) bool {
+	if !validAxis(axis) {
+		return false
+	}
+	input := am.padInput(pad)
+	old := input.axes[axis]
+	input.axes[axis] = value
+	if am.listener != nil && am.acceptsGamepad(pad) &&
+		math.Abs(value) >= AXISPRESSTHRESHOLD && math.Abs(old) < AXISPRESSTHRESHOLD {
+		sign := 1
+		if value < 0 {
+			sign = -1
+		}
+		return am.listen(GamepadAxisBinding(axis, sign))
+	}
+	for _, sign := range []int{-1, 0, 1} {
+		am.refreshBinding(GamepadAxisBinding(axis, sign))
+	}
+	return false
+}
+
+func (am *ActionMap) refreshBinding(binding InputBinding) {
+	for _, action := range am.actions {
+		for _, v := range action.Bindings {
+			if v == binding {
+				am.refresh(action)
+				break
+			}
+		}
+	}
+}
+
+// Lets go of everything, for when the window loses focus and the key ups won't arrive
+func (am *ActionMap) Reset() {
+	am.down = make(map[InputBinding]bool)
+	am.pads = make(map[sdl.JoystickID]*padInput)
+	for _, action := range am.actions {
+		am.refresh(action)
+	}
+}
+
+type axisBindingsJSON struct {
+	Negative []string `json:"negative"`
+	Positive []string `json:"positive"`
+}
+
+type bindingsJSON struct {
+	Actions map[string][]string         `json:"actions"`
+	Axes    map[string]axisBindingsJSON `json:"axes"`
+}
+
+func parseBindings(names []string) ([]InputBinding, error) {
+	bindings := make([]InputBinding, 0, len(names))
+	for _, name := range names {
+		binding, err := ParseInputBinding(name)
+		if err != nil {
+			return nil, err
+		}
+		bindings = append(bindings, binding)
+	}
+	return bindings, nil
+}
+
+func bindingStrings(bindings []InputBinding) []string {
+	names := make([]string, 0, len(bindings))
+	for _, binding := range bindings {
+		names = append(names, binding.String())
+	}
+	return names
+}
+
+// Loads bindings saved with SaveBindings, or written by hand:
+// {"actions": {"jump": ["key:Space", "button:a"]}, "axes": {"move_x": {"negative": ["key:A", "axis:leftx-"], "positive": ["key:D", "axis:leftx+"]}}}
+// Actions and axes in the file replace the ones with the same name, others are kept so
+// defaults can be set up in code first. Nothing is changed if the file has an error
+func (am *ActionMap) LoadBindings(path string) error {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var decoded bindingsJSON
+	err = json.Unmarshal(raw, &decoded)
+	if err != nil {
+		return err
+	}
+
+	actions := make(map[string][]InputBinding)
+	for name, names := range decoded.Actions {
+		bindings, err := parseBindings(names)
+		if err != nil {
+			return fmt.Errorf("Action %q: %s", name, err)
+		}
+		actions[name] = bindings
+	}
+	axes := make(map[string][2][]InputBinding)
+	for name, axis := range decoded.Axes {
+		negative, err := parseBindings(axis.Negative)
+		if err != nil {
+			return fmt.Errorf("Axis %q: %s", name, err)
+		}
+		positive, err := parseBindings(axis.Positive)
+		if err != nil {
+			return fmt.Errorf("Axis %q: %s", name, err)
+		}
+		axes[name] = [2][]InputBinding{negative, positive}
+	}
+
+	for name, bindings := range actions {
+		am.AddAction(name, bindings...)
+	}
+	for name, axis := range axes {
+		am.AddAxis(name, axis[0], axis[1])
+	}
+	return nil
+}
+
+// Saves all the actions and axes so LoadBindings can restore them
+func (am *ActionMap) SaveBindings(path string) error {
+	encoded := bindingsJSON{
+		Actions: make(map[string][]string),
+		Axes:    make(map[string]axisBindingsJSON),
+	}
+	for name, action := range am.actions {
+		encoded.Actions[name] = bindingStrings(action.Bindings)
+	}
+	for name, axis := range am.axes {
+		encoded.Axes[name] = axisBindingsJSON{bindingStrings(axis.Negative), bindingStrings(axis.Positive)}
+	}
+
+	raw, err := json.MarshalIndent(encoded, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, raw, 0644)
+}
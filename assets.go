@@ -66,12 +66,16 @@ func (e *Engine) GetFont(name string) *ttf.Font {
 }
 
 func (e *Engine) CreateTextTexture(font string, text string, color sdl.Color) *sdl.Texture {
-	f := e.GetFont(font)
-	if f == nil {
+	return e.CreateOutlinedTextTexture(font, "", text, color, sdl.Color{})
+}
+
+// Draws the text with font on top of the text drawn with outline, outline can be empty
+func (e *Engine) CreateOutlinedTextTexture(font, outline string, text string, color sdl.Color, colorOutline sdl.Color) *sdl.Texture {
+	surface := e.renderTextSurface(font, outline, text, color, colorOutline)
+	if surface == nil {
 		return nil
 	}
 
-	surface := f.RenderUTF8_Blended(text, color)
 	texture, err := e.renderer.CreateTextureFromSurface(surface)
 	surface.Free()
 	if err != nil {
@@ -80,29 +84,30 @@ func (e *Engine) CreateTextTexture(font string, text string, color sdl.Color) *s
 	return texture
 }
 
-func (e *Engine) CreateOutlinedTextTexture(font, outline string, text string, color sdl.Color, colorOutline sdl.Color) *sdl.Texture {
+func (e *Engine) renderTextSurface(font, outline string, text string, color sdl.Color, colorOutline sdl.Color) *sdl.Surface {
 	f := e.GetFont(font)
 	if f == nil {
 		return nil
 	}
 
+	surface := f.RenderUTF8_Blended(text, color)
+	if surface == nil || outline == "" {
+		return surface
+	}
+
 	f2 := e.GetFont(outline)
-	if f == nil {
-		return nil
+	if f2 == nil {
+		return surface
 	}
-	surface := f.RenderUTF8_Blended(text, color)
 	surface2 := f2.RenderUTF8_Blended(text, colorOutline)
+	if surface2 == nil {
+		return surface
+	}
 
 	surface.SetBlendMode(sdl.BLENDMODE_BLEND)
 	surface.Blit(nil, surface2, &sdl.Rect{int32(f2.GetOutline()), int32(f2.GetOutline()), surface.W, surface.H})
 	surface.Free()
-
-	texture, err := e.renderer.CreateTextureFromSurface(surface2)
-	surface2.Free()
-	if err != nil {
-		return nil
-	}
-	return texture
+	return surface2
 }
 
 func (e *Engine) GetTexture(name string) *sdl.Texture {
@@ -1,76 +1,81 @@
 package vroom
 
-// Button Entity
-type Button struct {
-	BaseComponent
-
-	Width, Height int
+import (
+	"github.com/veandco/go-sdl2/sdl"
+)
 
-	ClickSound string
-	HoverSound string
+// Clickable button showing Text, drawn with the style unless the state sprites are set
+type Button struct {
+	Widget
 
-	HoverSprite *Sprite
-	IdleSprite  *Sprite
-	ClickSprite *Sprite
+	// Drawn depending on the state instead of the style, usually a Sprite or NineSliceSprite
+	HoverSprite DrawAble
+	IdleSprite  DrawAble
+	ClickSprite DrawAble
 
-	IsHover     bool
-	IsMouseDown bool
+	// Optional label showing the text of the button instead of Text
+	// The localized text set with Key or SetKey before Init is passed on to it
+	Label *Label
 
 	OnClick func()
 }
 
-func (b *Button) Init() {
-	b.ClickSprite.SetEnabled(false)
-	b.HoverSprite.SetEnabled(false)
+func NewButton(text string, onClick func()) *Button {
+	return &Button{
+		Widget:  Widget{Text: text, Layer: UILAYER},
+		OnClick: onClick,
+	}
 }
 
-func (b *Button) MouseEnter() {
-	b.IsHover = true
-	b.ClickSprite.SetEnabled(false)
-	b.IdleSprite.SetEnabled(false)
-	b.HoverSprite.SetEnabled(true)
-	if b.HoverSound != "" {
-		b.Parent.GetEngine().PlaySound(b.HoverSound)
+func (b *Button) Init() {
+	b.initWidget(b)
+	b.stateChanged(b.State())
+	if b.Label != nil && b.Key != "" {
+		// A label that isn't added yet localizes itself in its Init
+		b.Label.LocalizedText = b.LocalizedText
+		if b.Label.Parent != nil {
+			b.Label.Relocalize()
+		}
 	}
 }
 
-func (b *Button) MouseLeave() {
-	b.IsHover = false
-	b.IsMouseDown = false
-	b.ClickSprite.SetEnabled(false)
-	b.HoverSprite.SetEnabled(false)
-	b.IdleSprite.SetEnabled(true)
+func (b *Button) hasSprites() bool {
+	return b.IdleSprite != nil && b.HoverSprite != nil && b.ClickSprite != nil
 }
 
-func (b *Button) MouseDown(x, y, button int) {
-	b.IsMouseDown = true
-	b.HoverSprite.SetEnabled(false)
-	b.IdleSprite.SetEnabled(false)
-	b.ClickSprite.SetEnabled(true)
+func (b *Button) stateChanged(state int) {
+	if !b.hasSprites() {
+		return
+	}
+	b.IdleSprite.SetEnabled(state == WIDGETIDLE || state == WIDGETDISABLED)
+	b.HoverSprite.SetEnabled(state == WIDGETHOVER || state == WIDGETFOCUSED)
+	b.ClickSprite.SetEnabled(state == WIDGETPRESSED)
 }
 
-func (b *Button) MouseUp(x, y, button int) {
-	if b.IsMouseDown {
-		if b.OnClick != nil {
-			if b.ClickSound != "" {
-				b.Parent.GetEngine().PlaySound(b.ClickSound)
-			}
-			b.OnClick()
-		}
-	}
-	b.IsMouseDown = false
-	b.ClickSprite.SetEnabled(false)
-	if b.IsHover {
-		b.HoverSprite.SetEnabled(true)
-		b.IdleSprite.SetEnabled(false)
-	} else {
-		b.HoverSprite.SetEnabled(false)
-		b.IdleSprite.SetEnabled(true)
+func (b *Button) click(x, y int) {
+	if b.OnClick != nil {
+		b.OnClick()
 	}
 }
 
-func (b *Button) MouseMove(x, y int) {
+func (b *Button) Size() (int, int) {
+	if b.Label != nil {
+		style := b.style()
+		return b.Label.Width + style.Padding.Horizontal(), b.Label.Height + style.Padding.Vertical()
+	}
+	return b.Widget.Size()
+}
 
+func (b *Button) Draw(renderer *sdl.Renderer) {
+	if b.hasSprites() {
+		return
+	}
+	state := b.State()
+	rect := b.Rect()
+	b.drawFrame(renderer, rect, state)
+	if b.Label == nil {
+		b.drawText(renderer, b.Text, b.contentRect(), ALIGNCENTER, b.stateStyle(state).TextColor)
+	}
 }
 
 func (b *Button) Name() string {
@@ -0,0 +1,50 @@
+package vroom
+
+import (
+	"fmt"
+	"github.com/veandco/go-sdl2/sdl"
+	"strconv"
+	"strings"
+)
+
+var namedColors = map[string]sdl.Color{
+	"white":  {255, 255, 255, 255},
+	"black":  {0, 0, 0, 255},
+	"gray":   {128, 128, 128, 255},
+	"red":    {255, 0, 0, 255},
+	"green":  {0, 255, 0, 255},
+	"blue":   {0, 0, 255, 255},
+	"yellow": {255, 255, 0, 255},
+	"orange": {255, 165, 0, 255},
+	"purple": {128, 0, 128, 255},
+	"cyan":   {0, 255, 255, 255},
+	"pink":   {255, 192, 203, 255},
+}
+
+// Parses colors in the form #rgb, #rrggbb, #rrggbbaa or a name like red
+func ParseColor(s string) (sdl.Color, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if named, ok := namedColors[s]; ok {
+		return named, nil
+	}
+
+	if !strings.HasPrefix(s, "#") {
+		return sdl.Color{}, fmt.Errorf("Unknown color %q", s)
+	}
+	hex := s[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return sdl.Color{}, fmt.Errorf("Invalid color %q", s)
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return sdl.Color{}, fmt.Errorf("Invalid color %q", s)
+	}
+	return sdl.Color{uint8(value >> 24), uint8(value >> 16), uint8(value >> 8), uint8(value)}, nil
+}
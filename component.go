@@ -84,80 +84,6 @@ func (bc *BaseComponent) Destroy() {
 
 }
 
-// Core components
-type Transform struct {
-	BaseComponent
-	Position box2dlite.Vec2
-	Angle    float64
-	Scale    float32
-}
-
-func NewTransform(x, y, angle float64) *Transform {
-	return &Transform{
-		Position: box2dlite.Vec2{x, y},
-		Angle:    angle,
-	}
-}
-
-func (t *Transform) Name() string {
-	return "Transform"
-}
-
-func (t *Transform) CalcPos() box2dlite.Vec2 {
-	physComp := t.GetComponent("PhysBodyComp")
-	if physComp != nil {
-		casted := physComp.(*PhysBodyComp)
-		if casted.Body != nil {
-			pos := casted.Body.Position
-			screenPos := pos.Mul(t.GetParent().GetEngine().PhysicsScale)
-			return screenPos
-		}
-	}
-
-	parentEntity := t.GetParent().GetParent()
-	if parentEntity != nil {
-		transformComp := parentEntity.GetComponent("Transform")
-		if transformComp != nil {
-			casted := transformComp.(*Transform)
-			copy := casted.CalcPos()
-			copy.Add(t.Position)
-			return copy
-		}
-	}
-
-	return t.Position
-}
-
-func (t *Transform) GetScreenPos() box2dlite.Vec2 {
-	realPos := t.CalcPos()
-	realPos.Sub(t.Parent.GetEngine().Camera)
-	return realPos
-}
-
-func (t *Transform) CalcAngle() float64 {
-	physComp := t.GetComponent("PhysBodyComp")
-	if physComp != nil {
-		casted := physComp.(*PhysBodyComp)
-		if casted.Body != nil {
-			angle := RadiansToDeDegrees(casted.Body.Rotation)
-			return angle
-		}
-	}
-
-	parentEntity := t.GetParent().GetParent()
-	if parentEntity != nil {
-
-		transformComp := parentEntity.GetComponent("Transform")
-		if transformComp != nil {
-			casted := transformComp.(*Transform)
-			copy := t.Angle
-			copy += casted.CalcAngle()
-			return copy
-		}
-	}
-	return t.Angle
-}
-
 type UpdateAble interface {
 	Component
 	Update(dt float64)
@@ -185,6 +111,13 @@ type DrawAble interface {
 	GetLayer() int
 }
 
+// Implemented by drawables that need to draw into their own textures,
+// PreDraw is called every frame before anything else is drawn
+type PreDrawAble interface {
+	DrawAble
+	PreDraw(renderer *sdl.Renderer)
+}
+
 // So you can add callbacks direcly to the entity (dont do this)
 type DrawComp struct {
 	BaseComponent
@@ -203,7 +136,7 @@ func (drw *DrawComp) Draw(renderer *sdl.Renderer) {
 }
 
 func (drw *DrawComp) GetLayer() int {
-	return drw.GetLayer()
+	return drw.Layer
 }
 
 type MouseBox struct { // If the mouse is inside this events will be sent
@@ -217,6 +150,10 @@ func (mb *MouseBox) Name() string {
 	return "MouseBox"
 }
 
+func (mb *MouseBox) SetSize(w, h int) {
+	mb.W, mb.H = w, h
+}
+
 // Mouse click listener
 // If entity also has mbox component will only send clicks inside said mbox
 type MouseClickListener interface {
@@ -233,6 +170,14 @@ type MouseHoverListener interface {
 	MouseLeave() // When leaving
 }
 
+// Wheel is called when scrolling with the mouse inside mbox
+// dx is positive to the right and dy up (away from the user) with "natural" scrolling undone,
+// in whole wheel steps, float so they can be scaled without converting
+type MouseWheelListener interface {
+	Component
+	MouseWheel(x, y int, dx, dy float64)
+}
+
 type KeyboardListener interface {
 	Component
 	KeyDown(sdl.Keycode)
@@ -264,6 +209,9 @@ func (kb *KeyboardComp) Name() string {
 type PhysBodyComp struct {
 	BaseComponent
 	Body *box2dlite.Body
+
+	lastPosition box2dlite.Vec2
+	lastRotation float64
 }
 
 func (e *Engine) NewPhysBodyComp(x, y, w, h float64, mass float64) *PhysBodyComp {
@@ -289,6 +237,20 @@ func (pb *PhysBodyComp) Init() {
 		pb.Parent.GetEngine().World.AddBody(pb.Body)
 	}
 }
+
+// Marks the transform dirty when the body moved, the transform reads the body itself
+// but the draw system only updates bounds of transforms that say they changed
+func (pb *PhysBodyComp) Update(dt float64) {
+	if pb.Body == nil || (pb.Body.Position == pb.lastPosition && pb.Body.Rotation == pb.lastRotation) {
+		return
+	}
+	pb.lastPosition = pb.Body.Position
+	pb.lastRotation = pb.Body.Rotation
+	if transform, ok := pb.GetComponent("Transform").(*Transform); ok {
+		transform.SetDirty()
+	}
+}
+
 func (pb *PhysBodyComp) Destroy() {
 	if pb.Body != nil {
 		pb.GetParent().GetEngine().World.RemoveBody(pb.Body)
@@ -0,0 +1,128 @@
+package vroom
+
+import (
+	"github.com/jonas747/go-box2d-lite/box2dlite"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	debugLine = iota
+	debugRect
+	debugCircle
+	debugText
+)
+
+type debugCommand struct {
+	kind   int
+	screen bool
+	filled bool
+	color  sdl.Color
+	a, b   box2dlite.Vec2
+	radius float64
+	text   string
+}
+
+// Records debug draw commands in either world or screen space
+type DebugCanvas struct {
+	debug  *DebugDraw
+	screen bool
+}
+
+func (dc *DebugCanvas) add(cmd debugCommand) {
+	if dc.debug == nil || !dc.debug.Enabled {
+		return
+	}
+	cmd.screen = dc.screen
+	dc.debug.commands = append(dc.debug.commands, cmd)
+}
+
+func (dc *DebugCanvas) Line(x1, y1, x2, y2 float64, color sdl.Color) {
+	dc.add(debugCommand{kind: debugLine, color: color, a: box2dlite.Vec2{x1, y1}, b: box2dlite.Vec2{x2, y2}})
+}
+
+// x and y is the top left corner
+func (dc *DebugCanvas) Rect(x, y, w, h float64, color sdl.Color, filled bool) {
+	dc.add(debugCommand{kind: debugRect, color: color, filled: filled, a: box2dlite.Vec2{x, y}, b: box2dlite.Vec2{x + w, y + h}})
+}
+
+func (dc *DebugCanvas) Circle(x, y, radius float64, color sdl.Color, filled bool) {
+	dc.add(debugCommand{kind: debugCircle, color: color, filled: filled, a: box2dlite.Vec2{x, y}, radius: radius})
+}
+
+// Draws text with DebugDraw.Font, x and y is the top left corner
+func (dc *DebugCanvas) Text(x, y float64, text string, color sdl.Color) {
+	dc.add(debugCommand{kind: debugText, color: color, a: box2dlite.Vec2{x, y}, text: text})
+}
+
+// Immediate mode debug drawing, everything drawn is only shown for the current frame
+// The embedded canvas draws in world space, use Screen to draw in screen space
+type DebugDraw struct {
+	DebugCanvas
+	Screen DebugCanvas
+
+	Enabled   bool
+	ToggleKey sdl.Keycode // Toggles Enabled when pressed, 0 to disable
+	Font      string      // Font used for text, a BMFont or a ttf font
+
+	engine   *Engine
+	commands []debugCommand
+}
+
+func NewDebugDraw(e *Engine) *DebugDraw {
+	d := &DebugDraw{
+		engine: e,
+	}
+	d.DebugCanvas = DebugCanvas{debug: d}
+	d.Screen = DebugCanvas{debug: d, screen: true}
+	return d
+}
+
+func (d *DebugDraw) Toggle() {
+	d.Enabled = !d.Enabled
+	if !d.Enabled {
+		d.commands = nil
+	}
+}
+
+// Draws all the commands recorded this frame and clears them
+func (d *DebugDraw) Draw(renderer *sdl.Renderer) {
+	for _, cmd := range d.commands {
+		offset := box2dlite.Vec2{}
+		if !cmd.screen {
+			offset = d.engine.Camera
+		}
+		a := cmd.a.Sub(offset)
+		b := cmd.b.Sub(offset)
+
+		setDrawColor(renderer, cmd.color)
+
+		switch cmd.kind {
+		case debugLine:
+			renderer.DrawLine(int(a.X), int(a.Y), int(b.X), int(b.Y))
+		case debugRect:
+			rect := &sdl.Rect{X: int32(a.X), Y: int32(a.Y), W: int32(b.X - a.X), H: int32(b.Y - a.Y)}
+			if cmd.filled {
+				renderer.FillRect(rect)
+			} else {
+				renderer.DrawRect(rect)
+			}
+		case debugCircle:
+			points := CirclePoints(a, cmd.radius, circleSegments(cmd.radius))
+			if cmd.filled {
+				FillPolygon(renderer, points)
+			} else {
+				DrawPolyline(renderer, points, true, 1)
+			}
+		case debugText:
+			if d.Font == "" {
+				continue
+			}
+			font := d.engine.GetGlyphFont(d.Font)
+			if font == nil {
+				continue
+			}
+			font.DrawText(renderer, cmd.text, a.X, a.Y, cmd.color)
+		}
+	}
+	d.commands = d.commands[:0]
+}
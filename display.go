@@ -0,0 +1,211 @@
+package vroom
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+)
+
+const (
+	SCALEASPECT  = iota // Scales as much as possible while keeping the aspect ratio, the rest is letterboxed
+	SCALEINTEGER        // Same as aspect but only whole scale factors, for crisp pixel art
+	SCALESTRETCH        // Fills the whole window ignoring the aspect ratio
+)
+
+// Handles the window and the virtual resolution
+// When a logical size is set everything is drawn at that size and then scaled up to the window,
+// mouse coordinates are converted back to the logical size before being sent to the systems
+type Display struct {
+	LogicalWidth, LogicalHeight int // 0 to draw directly to the window
+	ScaleMode                   int
+	LetterboxColor              sdl.Color
+
+	Resizable     bool        // Has to be set before InitSDL
+	FullscreenKey sdl.Keycode // Toggles fullscreen when pressed, 0 to disable
+
+	OnResize func(w, h int) // Called when the window changes size
+
+	fullscreen bool
+	borderless bool
+
+	engine   *Engine
+	target   *RenderTarget
+	viewport sdl.Rect // Where the logical screen ends up inside the window
+}
+
+func NewDisplay(e *Engine) *Display {
+	return &Display{
+		engine: e,
+	}
+}
+
+// Sets the virtual resolution, use 0, 0 to disable it
+func (e *Engine) SetLogicalSize(w, h int, scaleMode int) {
+	e.Display.LogicalWidth = w
+	e.Display.LogicalHeight = h
+	e.Display.ScaleMode = scaleMode
+	e.Display.updateViewport()
+}
+
+func (d *Display) Active() bool {
+	return d.LogicalWidth > 0 && d.LogicalHeight > 0
+}
+
+func (d *Display) IsFullscreen() bool {
+	return d.fullscreen
+}
+
+func (d *Display) IsBorderless() bool {
+	return d.borderless
+}
+
+// Switches between fullscreen (at the desktop resolution) and windowed
+func (d *Display) SetFullscreen(fullscreen bool) error {
+	var flags uint32
+	if fullscreen {
+		flags = sdl.WINDOW_FULLSCREEN_DESKTOP
+	}
+
+	err := d.engine.window.SetFullscreen(flags)
+	if err != nil {
+		return err
+	}
+	d.fullscreen = fullscreen
+	d.updateViewport()
+	return nil
+}
+
+func (d *Display) ToggleFullscreen() error {
+	return d.SetFullscreen(!d.fullscreen)
+}
+
+func (d *Display) SetBorderless(borderless bool) {
+	d.engine.window.SetBordered(!borderless)
+	d.borderless = borderless
+}
+
+// Size of the window in pixels
+func (d *Display) WindowSize() (int, int) {
+	w, h, err := d.engine.renderer.GetRendererOutputSize()
+	if err != nil {
+		return d.engine.window.GetSize()
+	}
+	return w, h
+}
+
+// Size of the screen everything is drawn on, the logical size if set otherwise the window size
+func (d *Display) ScreenSize() (int, int) {
+	if d.Active() {
+		return d.LogicalWidth, d.LogicalHeight
+	}
+	return d.WindowSize()
+}
+
+// The area of the window the logical screen is drawn in
+func (d *Display) Viewport() sdl.Rect {
+	return d.viewport
+}
+
+func (d *Display) updateViewport() {
+	if !d.Active() || d.engine.renderer == nil {
+		return
+	}
+
+	winW, winH := d.WindowSize()
+	scaleX := float64(winW) / float64(d.LogicalWidth)
+	scaleY := float64(winH) / float64(d.LogicalHeight)
+
+	if d.ScaleMode != SCALESTRETCH {
+		scale := math.Min(scaleX, scaleY)
+		if d.ScaleMode == SCALEINTEGER && scale >= 1 {
+			scale = math.Floor(scale)
+		}
+		scaleX = scale
+		scaleY = scale
+	}
+
+	w := int32(float64(d.LogicalWidth) * scaleX)
+	h := int32(float64(d.LogicalHeight) * scaleY)
+	d.viewport = sdl.Rect{X: (int32(winW) - w) / 2, Y: (int32(winH) - h) / 2, W: w, H: h}
+}
+
+// Converts window coordinates to the logical screen
+func (d *Display) WindowToLogical(x, y int) (int, int) {
+	if !d.Active() || d.viewport.W == 0 || d.viewport.H == 0 {
+		return x, y
+	}
+
+	lx := float64(x-int(d.viewport.X)) * float64(d.LogicalWidth) / float64(d.viewport.W)
+	ly := float64(y-int(d.viewport.Y)) * float64(d.LogicalHeight) / float64(d.viewport.H)
+	return int(math.Floor(lx)), int(math.Floor(ly))
+}
+
+// Converts logical screen coordinates to window coordinates
+func (d *Display) LogicalToWindow(x, y int) (int, int) {
+	if !d.Active() || d.LogicalWidth == 0 || d.LogicalHeight == 0 {
+		return x, y
+	}
+
+	wx := float64(x)*float64(d.viewport.W)/float64(d.LogicalWidth) + float64(d.viewport.X)
+	wy := float64(y)*float64(d.viewport.H)/float64(d.LogicalHeight) + float64(d.viewport.Y)
+	return int(wx), int(wy)
+}
+
+func (d *Display) windowEvent(evt *sdl.WindowEvent) {
+	if evt.Event != sdl.WINDOWEVENT_SIZE_CHANGED {
+		return
+	}
+
+	d.updateViewport()
+	if d.OnResize != nil {
+		d.OnResize(int(evt.Data1), int(evt.Data2))
+	}
+}
+
+// The texture the final image should be drawn to, nil is the window
+func (d *Display) screenTexture() *sdl.Texture {
+	if d.Active() && d.target != nil {
+		return d.target.Texture
+	}
+	return nil
+}
+
+// Starts drawing on the logical screen, returns false if there is none
+func (d *Display) Begin(renderer *sdl.Renderer) bool {
+	if !d.Active() {
+		return false
+	}
+
+	if d.target == nil || d.target.Width != d.LogicalWidth || d.target.Height != d.LogicalHeight {
+		if d.target != nil {
+			d.target.Destroy()
+		}
+		target, err := d.engine.NewRenderTarget(d.LogicalWidth, d.LogicalHeight)
+		if err != nil {
+			d.target = nil
+			return false
+		}
+		d.target = target
+	}
+
+	clear := d.engine.ClearColor
+	d.target.ClearColor = sdl.Color{clear.R, clear.G, clear.B, 255}
+	d.target.Begin(renderer)
+	return true
+}
+
+// Scales the logical screen onto the window
+func (d *Display) End(renderer *sdl.Renderer) {
+	d.target.End(renderer)
+	d.updateViewport()
+
+	renderer.SetDrawBlendMode(sdl.BLENDMODE_NONE)
+	renderer.SetDrawColor(d.LetterboxColor.R, d.LetterboxColor.G, d.LetterboxColor.B, 255)
+	renderer.Clear()
+	renderer.Copy(d.target.Texture, nil, &d.viewport)
+}
+
+func (d *Display) Destroy() {
+	if d.target != nil {
+		d.target.Destroy()
+	}
+}
@@ -0,0 +1,492 @@
+package vroom
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+)
+
+// Shows the selected option, clicking it opens a list of all the options on top of everything else
+// While open it captures the mouse, clicking outside the list closes it
+type Dropdown struct {
+	Widget
+	Options   []string
+	Selected  int // -1 shows Text instead
+	OnChanged func(index int, option string)
+
+	open    bool
+	hovered int
+}
+
+func NewDropdown(options []string, selected int, onChanged func(index int, option string)) *Dropdown {
+	return &Dropdown{
+		Widget:    Widget{Layer: UILAYER},
+		Options:   options,
+		Selected:  selected,
+		OnChanged: onChanged,
+	}
+}
+
+func (d *Dropdown) Init() {
+	d.initWidget(d)
+}
+
+func (d *Dropdown) Name() string {
+	return "Dropdown"
+}
+
+func (d *Dropdown) IsOpen() bool {
+	return d.open
+}
+
+func (d *Dropdown) Open() {
+	if d.open || len(d.Options) < 1 {
+		return
+	}
+	d.open = true
+	d.hovered = d.Selected
+	d.engine().UI.AddOverlay(d)
+	d.engine().UI.CaptureMouse(d)
+}
+
+func (d *Dropdown) Close() {
+	if !d.open {
+		return
+	}
+	d.open = false
+	d.engine().UI.RemoveOverlay(d)
+	d.engine().UI.ReleaseMouse(d)
+}
+
+func (d *Dropdown) Select(index int) {
+	if index < 0 || index >= len(d.Options) || index == d.Selected {
+		return
+	}
+	d.Selected = index
+	if d.OnChanged != nil {
+		d.OnChanged(index, d.Options[index])
+	}
+}
+
+func (d *Dropdown) SelectedOption() string {
+	if d.Selected < 0 || d.Selected >= len(d.Options) {
+		return ""
+	}
+	return d.Options[d.Selected]
+}
+
+func (d *Dropdown) click(x, y int) {
+	d.Open()
+}
+
+// Opens the list, or picks the highlighted option while open
+func (d *Dropdown) activate() {
+	if !d.open {
+		d.playSound(d.ClickSound, d.style().ClickSound)
+		d.Open()
+		return
+	}
+	if d.hovered != -1 {
+		d.playSound(d.ClickSound, d.style().ClickSound)
+		d.Select(d.hovered)
+	}
+	d.Close()
+}
+
+// Up and down move the highlight while open
+func (d *Dropdown) navigate(dir int) bool {
+	if !d.open {
+		return false
+	}
+	switch dir {
+	case NAVUP:
+		if d.hovered > 0 {
+			d.hovered--
+		}
+	case NAVDOWN:
+		if d.hovered < len(d.Options)-1 {
+			d.hovered++
+		}
+	}
+	return true
+}
+
+func (d *Dropdown) cancel() bool {
+	if !d.open {
+		return false
+	}
+	d.Close()
+	return true
+}
+
+func (d *Dropdown) FocusLost() {
+	d.Close()
+	d.Widget.FocusLost()
+}
+
+func (d *Dropdown) SetDisabled(disabled bool) {
+	if disabled {
+		d.Close()
+	}
+	d.Widget.SetDisabled(disabled)
+}
+
+func (d *Dropdown) Destroy() {
+	d.Close()
+}
+
+func (d *Dropdown) rowHeight() float64 {
+	return float64(d.lineHeight() + d.style().Padding.Vertical())
+}
+
+// The open list goes below the dropdown, or above it if it doesn't fit on the screen
+func (d *Dropdown) listRect() Rect {
+	rect := d.Rect()
+	list := Rect{rect.X, rect.Bottom(), rect.W, d.rowHeight() * float64(len(d.Options))}
+
+	screen := d.engine().ViewRect(true)
+	if list.Bottom() > screen.Bottom() && rect.Y-list.H >= screen.Y {
+		list.Y = rect.Y - list.H
+	}
+	return list
+}
+
+func (d *Dropdown) optionAt(x, y int) int {
+	list := d.listRect()
+	if !list.Contains(float64(x), float64(y)) {
+		return -1
+	}
+	return int((float64(y) - list.Y) / d.rowHeight())
+}
+
+func (d *Dropdown) CapturedMouseMove(x, y int) {
+	d.hovered = d.optionAt(x, y)
+}
+
+func (d *Dropdown) CapturedMouseButton(x, y, button int, up bool) {
+	if up {
+		return
+	}
+	index := d.optionAt(x, y)
+	if index != -1 && button == sdl.BUTTON_LEFT {
+		d.playSound(d.ClickSound, d.style().ClickSound)
+		d.Select(index)
+	}
+	d.Close()
+
+	// The mouse may have left while the list was open
+	d.IsHover = d.Rect().Contains(float64(x), float64(y))
+	d.updateState()
+}
+
+func (d *Dropdown) Size() (int, int) {
+	style := d.style()
+	widest, h := d.textSize(d.Text)
+	for _, option := range d.Options {
+		w, _ := d.textSize(option)
+		widest = int(math.Max(float64(widest), float64(w)))
+	}
+	return widest + h + style.Padding.Left + style.Padding.Horizontal(), h + style.Padding.Vertical()
+}
+
+func (d *Dropdown) Draw(renderer *sdl.Renderer) {
+	state := d.State()
+	if d.open {
+		state = WIDGETPRESSED
+	}
+	stateStyle := d.stateStyle(state)
+
+	rect := d.Rect()
+	d.drawFrame(renderer, rect, state)
+
+	content := d.contentRect()
+	arrow := Rect{content.Right() - content.H, content.Y, content.H, content.H}
+	text := d.SelectedOption()
+	if d.Selected < 0 {
+		text = d.Text
+	}
+	d.drawText(renderer, text, Rect{content.X, content.Y, arrow.X - content.X, content.H}, ALIGNLEFT, stateStyle.TextColor)
+	drawArrow(renderer, arrow, stateStyle.TextColor)
+}
+
+func (d *Dropdown) DrawOverlay(renderer *sdl.Renderer) {
+	list := d.listRect()
+	d.drawFrame(renderer, list, WIDGETIDLE)
+
+	style := d.style()
+	rowHeight := d.rowHeight()
+	for k, option := range d.Options {
+		row := Rect{list.X, list.Y + float64(k)*rowHeight, list.W, rowHeight}
+		state := WIDGETIDLE
+		if k == d.hovered {
+			state = WIDGETHOVER
+			fillRect(renderer, row.Grow(-1), style.States[WIDGETHOVER].Fill)
+		}
+		if k == d.Selected {
+			fillRect(renderer, Rect{row.X + 1, row.Y + 1, 3, row.H - 2}, style.Accent)
+		}
+		textRect := Rect{row.X + float64(style.Padding.Left), row.Y, row.W - float64(style.Padding.Horizontal()), row.H}
+		d.drawText(renderer, option, textRect, ALIGNLEFT, style.States[state].TextColor)
+	}
+}
+
+// Scrollable list of items, one can be selected
+type ListBox struct {
+	Widget
+	Items       []string
+	Selected    int // -1 for none
+	VisibleRows int // Rows shown at once, used for the preferred height
+	OnSelect    func(index int, item string)
+
+	Scroll float64 // Pixels scrolled from the top
+
+	hovered     int
+	draggingBar bool
+	dragOffset  float64
+}
+
+// Width of the scrollbar in pixels
+const LISTBOXBARWIDTH = 8
+
+// Rows scrolled per step of the mouse wheel
+const LISTBOXWHEELROWS = 3
+
+func NewListBox(items []string, visibleRows int, onSelect func(index int, item string)) *ListBox {
+	return &ListBox{
+		Widget:      Widget{Layer: UILAYER},
+		Items:       items,
+		Selected:    -1,
+		VisibleRows: visibleRows,
+		OnSelect:    onSelect,
+		hovered:     -1,
+	}
+}
+
+func (l *ListBox) Init() {
+	l.initWidget(l)
+}
+
+func (l *ListBox) Name() string {
+	return "ListBox"
+}
+
+func (l *ListBox) SetItems(items []string) {
+	l.Items = items
+	if l.Selected >= len(items) {
+		l.Selected = -1
+	}
+	l.ScrollBy(0)
+}
+
+func (l *ListBox) Select(index int) {
+	if index < -1 || index >= len(l.Items) || index == l.Selected {
+		return
+	}
+	l.Selected = index
+	if index != -1 {
+		l.ScrollTo(index)
+	}
+	if l.OnSelect != nil && index != -1 {
+		l.OnSelect(index, l.Items[index])
+	}
+}
+
+// Up and down change the selection, moving the focus out at the ends
+func (l *ListBox) navigate(dir int) bool {
+	switch {
+	case dir == NAVUP && l.Selected > 0:
+		l.Select(l.Selected - 1)
+	case dir == NAVDOWN && l.Selected < len(l.Items)-1:
+		l.Select(l.Selected + 1)
+	default:
+		return false
+	}
+	return true
+}
+
+// Picks the selected item again
+func (l *ListBox) activate() {
+	if l.OnSelect != nil && l.Selected >= 0 && l.Selected < len(l.Items) {
+		l.playSound(l.ClickSound, l.style().ClickSound)
+		l.OnSelect(l.Selected, l.Items[l.Selected])
+	}
+}
+
+func (l *ListBox) rowHeight() float64 {
+	return float64(l.lineHeight() + l.style().Padding.Vertical())
+}
+
+// Inside the frame, where the rows are drawn
+func (l *ListBox) viewRect() Rect {
+	rect := l.Rect().Grow(-1)
+	if l.maxScroll() > 0 {
+		rect.W -= LISTBOXBARWIDTH
+	}
+	return rect
+}
+
+func (l *ListBox) maxScroll() float64 {
+	view := l.Rect().Grow(-1)
+	return math.Max(0, l.rowHeight()*float64(len(l.Items))-view.H)
+}
+
+func (l *ListBox) ScrollBy(pixels float64) {
+	l.Scroll = math.Max(0, math.Min(l.maxScroll(), l.Scroll+pixels))
+}
+
+// Scrolls just enough to show the item
+func (l *ListBox) ScrollTo(index int) {
+	view := l.viewRect()
+	top := float64(index) * l.rowHeight()
+	if top < l.Scroll {
+		l.Scroll = top
+	} else if top+l.rowHeight() > l.Scroll+view.H {
+		l.Scroll = top + l.rowHeight() - view.H
+	}
+	l.ScrollBy(0)
+}
+
+func (l *ListBox) MouseWheel(x, y int, dx, dy float64) {
+	if l.Disabled || l.draggingBar {
+		return
+	}
+	l.ScrollBy(-dy * LISTBOXWHEELROWS * l.rowHeight())
+	l.hover(x, y)
+}
+
+func (l *ListBox) itemAt(x, y int) int {
+	view := l.viewRect()
+	if !view.Contains(float64(x), float64(y)) {
+		return -1
+	}
+	index := int((float64(y) - view.Y + l.Scroll) / l.rowHeight())
+	if index >= len(l.Items) {
+		return -1
+	}
+	return index
+}
+
+func (l *ListBox) barRect() (Rect, Rect) {
+	rect := l.Rect().Grow(-1)
+	bar := Rect{rect.Right() - LISTBOXBARWIDTH, rect.Y, LISTBOXBARWIDTH, rect.H}
+
+	total := l.rowHeight() * float64(len(l.Items))
+	thumb := bar
+	if total > 0 {
+		thumb.H = math.Max(LISTBOXBARWIDTH, bar.H*bar.H/total)
+	}
+	if maxScroll := l.maxScroll(); maxScroll > 0 {
+		thumb.Y += (bar.H - thumb.H) * l.Scroll / maxScroll
+	}
+	return bar, thumb
+}
+
+func (l *ListBox) hover(x, y int) {
+	l.hovered = l.itemAt(x, y)
+}
+
+func (l *ListBox) press(x, y int) {
+	if l.maxScroll() <= 0 {
+		return
+	}
+	bar, thumb := l.barRect()
+	if !bar.Contains(float64(x), float64(y)) {
+		return
+	}
+
+	// Clicking the bar outside the thumb jumps there
+	if !thumb.Contains(float64(x), float64(y)) {
+		l.dragOffset = thumb.H / 2
+		l.dragBar(y)
+	} else {
+		l.dragOffset = float64(y) - thumb.Y
+	}
+	l.draggingBar = true
+	l.engine().UI.CaptureMouse(l)
+}
+
+func (l *ListBox) dragBar(y int) {
+	bar, thumb := l.barRect()
+	if bar.H <= thumb.H {
+		return
+	}
+	fraction := (float64(y) - l.dragOffset - bar.Y) / (bar.H - thumb.H)
+	l.Scroll = 0
+	l.ScrollBy(fraction * l.maxScroll())
+}
+
+func (l *ListBox) CapturedMouseMove(x, y int) {
+	if l.draggingBar {
+		l.dragBar(y)
+	}
+}
+
+func (l *ListBox) CapturedMouseButton(x, y, button int, up bool) {
+	if up && button == sdl.BUTTON_LEFT {
+		l.draggingBar = false
+		l.engine().UI.ReleaseMouse(l)
+		l.IsHover = l.Rect().Contains(float64(x), float64(y))
+		l.release()
+	}
+}
+
+func (l *ListBox) click(x, y int) {
+	if index := l.itemAt(x, y); index != -1 {
+		l.Select(index)
+	}
+}
+
+func (l *ListBox) MouseLeave() {
+	l.hovered = -1
+	l.Widget.MouseLeave()
+}
+
+func (l *ListBox) Size() (int, int) {
+	style := l.style()
+	widest := 0
+	for _, item := range l.Items {
+		w, _ := l.textSize(item)
+		widest = int(math.Max(float64(widest), float64(w)))
+	}
+	rows := l.VisibleRows
+	if rows < 1 {
+		rows = 5
+	}
+	return widest + style.Padding.Horizontal() + LISTBOXBARWIDTH + 2, int(l.rowHeight())*rows + 2
+}
+
+func (l *ListBox) Draw(renderer *sdl.Renderer) {
+	style := l.style()
+	state := WIDGETIDLE
+	if l.Disabled {
+		state = WIDGETDISABLED
+	}
+	l.drawFrame(renderer, l.Rect(), state)
+
+	view := l.viewRect()
+	rowHeight := l.rowHeight()
+	first := int(l.Scroll / rowHeight)
+	restoreClip := clipRect(renderer, view)
+	for k := first; k < len(l.Items); k++ {
+		row := Rect{view.X, view.Y + float64(k)*rowHeight - l.Scroll, view.W, rowHeight}
+		if row.Y >= view.Bottom() {
+			break
+		}
+
+		rowState := state
+		if k == l.Selected {
+			fillRect(renderer, row, style.Accent)
+		} else if k == l.hovered && !l.Disabled {
+			rowState = WIDGETHOVER
+			fillRect(renderer, row, style.States[WIDGETHOVER].Fill)
+		}
+		textRect := Rect{row.X + float64(style.Padding.Left), row.Y, row.W - float64(style.Padding.Horizontal()), row.H}
+		l.drawText(renderer, l.Items[k], textRect, ALIGNLEFT, style.States[rowState].TextColor)
+	}
+	restoreClip()
+
+	if l.maxScroll() > 0 {
+		bar, thumb := l.barRect()
+		fillRect(renderer, bar, style.States[WIDGETPRESSED].Fill)
+		fillRect(renderer, thumb.Grow(-1), l.stateStyle(l.State()).Border)
+	}
+}
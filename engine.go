@@ -1,6 +1,7 @@
 package vroom
 
 import (
+	"fmt"
 	"github.com/jonas747/go-box2d-lite/box2dlite"
 	"github.com/veandco/go-sdl2/sdl"
 	"github.com/veandco/go-sdl2/sdl_image"
@@ -27,32 +28,79 @@ type Engine struct {
 	MouseClickSystem *MouseClickSystem
 	MouseHoverSystem *MouseHoverSystem
 	Keyboardsystem   *KeyboardSystem
+	MouseWheelSystem *MouseWheelSystem
+	UI               *UISystem
+
+	// Input
+	Input    *Input     // Keyboard and mouse state of the current frame
+	Actions  *ActionMap // Named actions and axes, see actions.go
+	Gamepads *GamepadSystem
 
 	// Assets
-	Textures map[string]*sdl.Texture
-	Fonts    map[string]*ttf.Font
-	Sounds   map[string]*mix.Chunk
+	Textures   map[string]*sdl.Texture
+	Fonts      map[string]*ttf.Font
+	GlyphFonts map[string]*GlyphFont
+	Sounds     map[string]*mix.Chunk
+
+	// Particle effects by name, see LoadParticleEffect
+	ParticleEffects     map[string]*ParticleEffect
+	particleEffectPaths map[string]string
+
+	// Factories for objects in Tiled maps, by type
+	ObjectTypes map[string]ObjectFactory
 
 	//Physics
 	World        *box2dlite.World
 	PhysicsScale float64
+	PhysicsDebug *PhysicsDebugDraw
+
+	// Rendering
+	Display       *Display
+	RenderTargets []*RenderTarget
+	PostProcess   *PostProcessChain
+	viewTarget    *RenderTarget // Target currently being drawn by RenderToTarget
 
 	// Misc
-	ClearColor sdl.Color
+	ClearColor   sdl.Color
+	Localization *Localization
+	Debug        *DebugDraw
 }
 
 func (e *Engine) InitCoreSystems() {
-	e.DrawSystem = &DrawSystem{}
+	e.DrawSystem = NewDrawSystem(e)
 	e.UpdateSystem = &UpdateSystem{}
 	e.MouseClickSystem = &MouseClickSystem{}
 	e.MouseHoverSystem = &MouseHoverSystem{}
 	e.Keyboardsystem = &KeyboardSystem{}
+	e.MouseWheelSystem = &MouseWheelSystem{}
+	e.UI = NewUISystem(e)
+	e.Gamepads = NewGamepadSystem()
+
+	// Nothing behind a modal gets input
+	e.MouseClickSystem.Blocked = e.UI.Blocked
+	e.MouseHoverSystem.Blocked = e.UI.Blocked
+	e.Keyboardsystem.Blocked = e.UI.Blocked
+	e.MouseWheelSystem.Blocked = e.UI.Blocked
+	e.Gamepads.Blocked = e.UI.Blocked
 
 	e.AddSystem(e.DrawSystem)
 	e.AddSystem(e.UpdateSystem)
 	e.AddSystem(e.MouseClickSystem)
 	e.AddSystem(e.MouseHoverSystem)
 	e.AddSystem(e.Keyboardsystem)
+	e.AddSystem(e.MouseWheelSystem)
+	e.AddSystem(e.UI)
+	e.AddSystem(e.Gamepads)
+
+	e.Input = NewInput(e)
+	e.Actions = NewActionMap()
+
+	e.Localization = NewLocalization()
+	e.AddSystem(e.Localization)
+
+	e.Debug = NewDebugDraw(e)
+	e.PostProcess = NewPostProcessChain(e)
+	e.Display = NewDisplay(e)
 
 	if e.PhysicsScale == 0 {
 		e.PhysicsScale = 30
@@ -62,6 +110,7 @@ func (e *Engine) InitCoreSystems() {
 	iterations := 10
 	world := box2dlite.NewWorld(gravity, iterations)
 	e.World = world
+	e.PhysicsDebug = NewPhysicsDebugDraw(e)
 }
 
 func (e *Engine) InitSDL(w, h int, title string) error {
@@ -69,19 +118,34 @@ func (e *Engine) InitSDL(w, h int, title string) error {
 	if err != nil {
 		return err
 	}
+	// SDL starts with text input on, the UI turns it on while a text input has the focus
+	sdl.StopTextInput()
+	// Not fatal, the game just won't have gamepads or rumble
+	err = sdl.InitSubSystem(sdl.INIT_GAMECONTROLLER | sdl.INIT_HAPTIC)
+	if err != nil {
+		fmt.Println("Failed initializing gamepads:", err)
+	}
+
+	var windowFlags uint32 = sdl.WINDOW_SHOWN
+	if e.Display.Resizable {
+		windowFlags |= sdl.WINDOW_RESIZABLE
+	}
 
 	window, err := sdl.CreateWindow(title, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
-		w, h, sdl.WINDOW_SHOWN)
+		w, h, windowFlags)
 	if err != nil {
 		return err
 	}
 	e.window = window
 
-	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	// Lets SDL merge consecutive draws with the same texture, see DrawSystem.Batching
+	sdl.SetHint("SDL_RENDER_BATCHING", "1")
+	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED|sdl.RENDERER_TARGETTEXTURE)
 	if err != nil {
 		return err
 	}
 	e.renderer = renderer
+	e.Display.updateViewport()
 
 	// Init sdl_image
 	flags := img.Init(img.INIT_PNG)
@@ -205,7 +269,45 @@ func (e *Engine) ApplyCamera(x, y int) (int, int) {
 	return xo, yo
 }
 
+// Zoom of what is currently being drawn, the Scale of the render target or 1 when drawing on the screen
+func (e *Engine) ViewScale() float64 {
+	if e.viewTarget == nil || e.viewTarget.Scale == 0 {
+		return 1
+	}
+	return e.viewTarget.Scale
+}
+
+// The area of the world that is currently being drawn, with ignoreCamera the area on the screen
+func (e *Engine) ViewRect(ignoreCamera bool) Rect {
+	view := Rect{}
+	if e.viewTarget != nil {
+		scale := e.ViewScale()
+		view.W = float64(e.viewTarget.Width) / scale
+		view.H = float64(e.viewTarget.Height) / scale
+	} else {
+		w, h := e.Display.ScreenSize()
+		view.W = float64(w)
+		view.H = float64(h)
+	}
+
+	if !ignoreCamera {
+		view.X = e.Camera.X
+		view.Y = e.Camera.Y
+	}
+	return view
+}
+
 func (e *Engine) Destroy() {
+	for _, target := range e.RenderTargets {
+		target.Destroy()
+	}
+	e.PostProcess.Destroy()
+	e.Display.Destroy()
+	for _, font := range e.GlyphFonts {
+		font.Destroy()
+	}
+	e.Gamepads.Destroy()
+
 	e.renderer.Destroy()
 	e.window.Destroy()
 	img.Quit()
@@ -219,6 +321,21 @@ func (e *Engine) GetKey(key sdl.Keycode) bool {
 	return e.Keyboardsystem.Keys[key]
 }
 
+// False if the player has no gamepad
+func (e *Engine) GetGamepadButton(player int, button sdl.GameControllerButton) bool {
+	pad := e.Gamepads.Gamepad(player)
+	return pad != nil && pad.Button(button)
+}
+
+// 0 if the player has no gamepad
+func (e *Engine) GetGamepadAxis(player int, axis sdl.GameControllerAxis) float64 {
+	pad := e.Gamepads.Gamepad(player)
+	if pad == nil {
+		return 0
+	}
+	return pad.Axis(axis)
+}
+
 func RadiansToDeDegrees(radians float64) float64 {
 	return radians * (180 / math.Pi)
 }
@@ -135,6 +135,14 @@ func (be *BaseEntity) AddChild(e Entity, addToScene bool) {
 	}
 
 	e.SetParent(be)
+	transformChanged(e)
+}
+
+// The world matrix depends on the parent so it changes with it
+func transformChanged(e Entity) {
+	if transform, ok := e.GetComponent("Transform").(*Transform); ok {
+		transform.SetDirty()
+	}
 }
 
 func (be *BaseEntity) RemoveChild(e Entity, removeFromScene bool) {
@@ -160,6 +168,7 @@ func (be *BaseEntity) RemoveChild(e Entity, removeFromScene bool) {
 	}
 
 	e.SetParent(nil)
+	transformChanged(e)
 }
 
 func (be *BaseEntity) GetChildren(recursive bool) []Entity {
@@ -0,0 +1,320 @@
+package vroom
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+	"sort"
+)
+
+// Directions for navigating between focusable components
+const (
+	NAVUP = iota
+	NAVDOWN
+	NAVLEFT
+	NAVRIGHT
+	NAVDIRECTIONS // Number of directions
+)
+
+// Focusable component that can be reached with tab and the arrow keys or d-pad, all widgets are
+type Navigable interface {
+	Focusable
+	Bounds() (Rect, bool)
+	CanFocus() bool
+	TabOrder() int              // Lower goes first, 0 goes after all the others in screen order
+	Neighbor(dir int) Navigable // Configured neighbor in the direction, nil to find the closest one
+	Navigate(dir int) bool      // Lets it use the direction itself (sliders...), returns true if it did
+	Activate()                  // Enter, space or the A button
+	Cancel() bool               // Escape or the B button, returns true if it used it
+}
+
+// Navigation is kept inside the root entity while the scope is on top, see UISystem.PushFocusScope
+type focusScope struct {
+	root     Entity
+	previous Focusable
+	onCancel func()
+}
+
+// Returns true if the component belongs to root or one of its children
+func inEntity(component Component, root Entity) bool {
+	for entity := component.GetParent(); entity != nil; entity = entity.GetParent() {
+		if entity == root {
+			return true
+		}
+	}
+	return false
+}
+
+func (ui *UISystem) topScope() *focusScope {
+	if len(ui.scopes) < 1 {
+		return nil
+	}
+	return &ui.scopes[len(ui.scopes)-1]
+}
+
+// Returns true if the component is outside the modal scope on top
+func (ui *UISystem) outsideScope(component Component) bool {
+	scope := ui.topScope()
+	return scope != nil && !inEntity(component, scope.root)
+}
+
+// Focusable components in the current scope, in tab order
+func (ui *UISystem) tabOrder() []Navigable {
+	out := make([]Navigable, 0, len(ui.navigables))
+	for _, navigable := range ui.navigables {
+		if navigable.CanFocus() && !ui.outsideScope(navigable) {
+			out = append(out, navigable)
+		}
+	}
+	sort.Stable(byTabOrder(out))
+	return out
+}
+
+// Explicit tab orders first, then top to bottom and left to right
+type byTabOrder []Navigable
+
+func (b byTabOrder) Len() int      { return len(b) }
+func (b byTabOrder) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byTabOrder) Less(i, j int) bool {
+	oi, oj := b[i].TabOrder(), b[j].TabOrder()
+	if oi != oj {
+		if oi == 0 || oj == 0 {
+			return oj == 0
+		}
+		return oi < oj
+	}
+	ri, _ := b[i].Bounds()
+	rj, _ := b[j].Bounds()
+	if ri.Y != rj.Y {
+		return ri.Y < rj.Y
+	}
+	return ri.X < rj.X
+}
+
+// The focused component if it's navigable and can still have the focus
+func (ui *UISystem) focusedNavigable() Navigable {
+	navigable, ok := ui.focused.(Navigable)
+	if !ok || !navigable.CanFocus() {
+		return nil
+	}
+	return navigable
+}
+
+// Moves the focus to the next component in tab order, or the previous one if reverse
+// Wraps around at the ends, returns false if there's nothing to focus
+func (ui *UISystem) FocusNext(reverse bool) bool {
+	order := ui.tabOrder()
+	if len(order) < 1 {
+		return false
+	}
+
+	current := -1
+	if focused := ui.focusedNavigable(); focused != nil {
+		for k, v := range order {
+			if v == focused {
+				current = k
+				break
+			}
+		}
+	}
+
+	next := 0
+	switch {
+	case current == -1 && reverse:
+		next = len(order) - 1
+	case current != -1 && reverse:
+		next = (current - 1 + len(order)) % len(order)
+	case current != -1:
+		next = (current + 1) % len(order)
+	}
+	ui.SetFocus(order[next])
+	return true
+}
+
+// Focuses the first component in tab order if nothing is focused
+func (ui *UISystem) FocusFirst() bool {
+	if ui.focusedNavigable() != nil {
+		return true
+	}
+	return ui.FocusNext(false)
+}
+
+// Moves the focus in the direction, the focused component gets to use it first and then
+// its configured neighbor is used, or the closest component in that direction
+func (ui *UISystem) Navigate(dir int) bool {
+	focused := ui.focusedNavigable()
+	if focused == nil {
+		return ui.FocusFirst()
+	}
+	if focused.Navigate(dir) {
+		return true
+	}
+
+	if neighbor := focused.Neighbor(dir); neighbor != nil && neighbor.CanFocus() && !ui.outsideScope(neighbor) {
+		ui.SetFocus(neighbor)
+		return true
+	}
+	if closest := ui.closestInDirection(focused, dir); closest != nil {
+		ui.SetFocus(closest)
+	}
+	return true
+}
+
+// Finds the component closest to from in the direction, things straight ahead are preferred
+// over ones that are closer but off to the side
+func (ui *UISystem) closestInDirection(from Navigable, dir int) Navigable {
+	fromRect, _ := from.Bounds()
+	fromCenter := fromRect.Center()
+
+	var best Navigable
+	bestScore := math.Inf(1)
+	for _, candidate := range ui.tabOrder() {
+		if candidate == from {
+			continue
+		}
+		rect, _ := candidate.Bounds()
+		center := rect.Center()
+
+		var along, side float64
+		switch dir {
+		case NAVUP:
+			along, side = fromRect.Y-rect.Bottom(), center.X-fromCenter.X
+		case NAVDOWN:
+			along, side = rect.Y-fromRect.Bottom(), center.X-fromCenter.X
+		case NAVLEFT:
+			along, side = fromRect.X-rect.Right(), center.Y-fromCenter.Y
+		case NAVRIGHT:
+			along, side = rect.X-fromRect.Right(), center.Y-fromCenter.Y
+		}
+		// Overlapping a bit still counts, half of it has to be in the direction
+		if along < -math.Min(fromRect.W, fromRect.H)/2 {
+			continue
+		}
+
+		score := math.Max(0, along) + math.Abs(side)*2
+		if score < bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	return best
+}
+
+// Activates the focused component, returns false if nothing is focused
+func (ui *UISystem) Activate() bool {
+	focused := ui.focusedNavigable()
+	if focused == nil {
+		return false
+	}
+	focused.Activate()
+	return true
+}
+
+// Lets the focused component cancel first (closing a dropdown...), then calls the
+// cancel function of the modal scope, otherwise the focus is cleared
+func (ui *UISystem) Cancel() bool {
+	if focused := ui.focusedNavigable(); focused != nil && focused.Cancel() {
+		return true
+	}
+	if scope := ui.topScope(); scope != nil {
+		if scope.onCancel != nil {
+			scope.onCancel()
+		}
+		return true
+	}
+	if ui.focused != nil {
+		ui.ClearFocus()
+		return true
+	}
+	return false
+}
+
+// Keeps the focus inside root until it's popped, for dialogs. The first component in root
+// is focused and the one focused before is focused again when popped. onCancel is called
+// when escape or B is pressed while nothing inside used it, can be nil
+func (ui *UISystem) PushFocusScope(root Entity, onCancel func()) {
+	ui.scopes = append(ui.scopes, focusScope{root: root, previous: ui.focused, onCancel: onCancel})
+	if ui.focused != nil && ui.outsideScope(ui.focused) {
+		ui.ClearFocus()
+	}
+	ui.FocusFirst()
+}
+
+// Removes the scope for root, and any pushed after it
+func (ui *UISystem) PopFocusScope(root Entity) {
+	for k := len(ui.scopes) - 1; k >= 0; k-- {
+		if ui.scopes[k].root != root {
+			continue
+		}
+		previous := ui.scopes[k].previous
+		ui.scopes = ui.scopes[:k]
+
+		ui.ClearFocus()
+		if navigable, ok := previous.(Navigable); ok && !navigable.CanFocus() {
+			return
+		}
+		ui.SetFocus(previous)
+		return
+	}
+}
+
+// True while a modal focus scope is active
+func (ui *UISystem) InFocusScope() bool {
+	return len(ui.scopes) > 0
+}
+
+// Handles the navigation keys, returns true if the key was used
+// Arrows, enter and escape are only used while something is focused or a scope is active
+// so they still reach the game otherwise, tab always focuses something if it can
+func (ui *UISystem) navigationKey(key sdl.Keycode) bool {
+	if !ui.KeyboardNavigation {
+		return false
+	}
+	if key == sdl.K_TAB {
+		return ui.FocusNext(sdl.GetModState()&sdl.KMOD_SHIFT != 0)
+	}
+	if ui.focusedNavigable() == nil && !ui.InFocusScope() {
+		return false
+	}
+
+	switch key {
+	case sdl.K_UP:
+		return ui.Navigate(NAVUP)
+	case sdl.K_DOWN:
+		return ui.Navigate(NAVDOWN)
+	case sdl.K_LEFT:
+		return ui.Navigate(NAVLEFT)
+	case sdl.K_RIGHT:
+		return ui.Navigate(NAVRIGHT)
+	case sdl.K_RETURN, sdl.K_KP_ENTER, sdl.K_SPACE:
+		return ui.Activate()
+	case sdl.K_ESCAPE:
+		return ui.Cancel()
+	}
+	return false
+}
+
+// D-pad navigates, A activates and B cancels, returns true if the button was used
+// Like the arrow keys only while something is focused or a scope is active, use FocusFirst to start
+func (ui *UISystem) ControllerButton(button sdl.GameControllerButton) bool {
+	if ui.menu != nil {
+		ui.menuButton(button)
+		return true
+	}
+	if !ui.ControllerNavigation || (ui.focusedNavigable() == nil && !ui.InFocusScope()) {
+		return false
+	}
+	switch button {
+	case sdl.CONTROLLER_BUTTON_DPAD_UP:
+		return ui.Navigate(NAVUP)
+	case sdl.CONTROLLER_BUTTON_DPAD_DOWN:
+		return ui.Navigate(NAVDOWN)
+	case sdl.CONTROLLER_BUTTON_DPAD_LEFT:
+		return ui.Navigate(NAVLEFT)
+	case sdl.CONTROLLER_BUTTON_DPAD_RIGHT:
+		return ui.Navigate(NAVRIGHT)
+	case sdl.CONTROLLER_BUTTON_A:
+		return ui.Activate()
+	case sdl.CONTROLLER_BUTTON_B:
+		return ui.Cancel()
+	}
+	return false
+}
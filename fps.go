@@ -4,9 +4,15 @@ import (
 	"fmt"
 )
 
+// Anything that displays text, Label, RichLabel and GlyphLabel all work
+type TextSetter interface {
+	SetText(text string)
+}
+
+// Use a GlyphLabel since the text changes every frame
 type FPSCounter struct {
 	BaseComponent
-	Label *Label
+	Label TextSetter // Leave it nil instead of assigning a nil *Label, that isn't == nil in the interface
 }
 
 func (fps *FPSCounter) Update(dt float64) {
@@ -0,0 +1,352 @@
+package vroom
+
+import (
+	"fmt"
+	"github.com/jonas747/go-box2d-lite/box2dlite"
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+	"time"
+)
+
+// Default dead zone of the sticks and triggers, see Gamepad.DeadZone
+const GAMEPADDEADZONE = 0.2
+
+// Player of gamepads that aren't assigned to anyone
+const NOPLAYER = -1
+
+// Gets the input of the gamepads, player is the player the gamepad is assigned to or NOPLAYER
+// Axis values have the dead zone applied, sticks go from -1 to 1 and triggers from 0 to 1
+type GamepadListener interface {
+	Component
+	GamepadButtonDown(player int, button sdl.GameControllerButton)
+	GamepadButtonUp(player int, button sdl.GameControllerButton)
+	GamepadAxis(player int, axis sdl.GameControllerAxis, value float64)
+}
+
+// An opened game controller
+type Gamepad struct {
+	ID       sdl.JoystickID // Instance id of the joystick, used in the sdl events
+	Name     string
+	Player   int     // NOPLAYER if it's not assigned, see GamepadSystem.AssignPlayer
+	DeadZone float64 // Stick and trigger values closer to 0 than this are 0, the rest is rescaled
+
+	controller *sdl.GameController
+	haptic     *sdl.Haptic
+	noHaptic   bool // Opening the haptic failed, don't try again
+
+	buttons  [sdl.CONTROLLER_BUTTON_MAX]bool
+	pressed  [sdl.CONTROLLER_BUTTON_MAX]bool
+	released [sdl.CONTROLLER_BUTTON_MAX]bool
+	axes     [sdl.CONTROLLER_AXIS_MAX]float64 // Raw values, -1 to 1
+}
+
+func validButton(button sdl.GameControllerButton) bool {
+	return button >= 0 && button < sdl.CONTROLLER_BUTTON_MAX
+}
+
+func validAxis(axis sdl.GameControllerAxis) bool {
+	return axis >= 0 && axis < sdl.CONTROLLER_AXIS_MAX
+}
+
+// True while the button is held
+func (g *Gamepad) Button(button sdl.GameControllerButton) bool {
+	return validButton(button) && g.buttons[button]
+}
+
+// True in the frame the button went down
+func (g *Gamepad) JustPressed(button sdl.GameControllerButton) bool {
+	return validButton(button) && g.pressed[button]
+}
+
+// True in the frame the button went up
+func (g *Gamepad) JustReleased(button sdl.GameControllerButton) bool {
+	return validButton(button) && g.released[button]
+}
+
+// Value of the axis with the dead zone applied, sticks go from -1 to 1 and triggers from 0 to 1
+func (g *Gamepad) Axis(axis sdl.GameControllerAxis) float64 {
+	if !validAxis(axis) {
+		return 0
+	}
+	return applyDeadZone(g.axes[axis], g.DeadZone)
+}
+
+// Value without the dead zone
+func (g *Gamepad) RawAxis(axis sdl.GameControllerAxis) float64 {
+	if !validAxis(axis) {
+		return 0
+	}
+	return g.axes[axis]
+}
+
+// Position of the left or right stick, the dead zone is applied to the length so diagonals
+// aren't cut off like they would be with Axis, the length is at most 1
+func (g *Gamepad) Stick(right bool) box2dlite.Vec2 {
+	x, y := g.axes[sdl.CONTROLLER_AXIS_LEFTX], g.axes[sdl.CONTROLLER_AXIS_LEFTY]
+	if right {
+		x, y = g.axes[sdl.CONTROLLER_AXIS_RIGHTX], g.axes[sdl.CONTROLLER_AXIS_RIGHTY]
+	}
+	length := math.Sqrt(x*x + y*y)
+	scaled := applyDeadZone(length, g.DeadZone)
+	if scaled == 0 {
+		return box2dlite.Vec2{}
+	}
+	return box2dlite.Vec2{x / length * scaled, y / length * scaled}
+}
+
+// Values closer to 0 than deadZone are 0, the rest is rescaled to start at 0
+func applyDeadZone(value, deadZone float64) float64 {
+	if math.Abs(value) <= deadZone {
+		return 0
+	}
+	scaled := (math.Abs(value) - deadZone) / (1 - deadZone)
+	return math.Copysign(math.Min(1, scaled), value)
+}
+
+// Rumbles with strength from 0 to 1 for duration, returns an error if the gamepad can't rumble
+func (g *Gamepad) Rumble(strength float64, duration time.Duration) error {
+	if g.haptic == nil {
+		if g.noHaptic {
+			return fmt.Errorf("Gamepad %q can't rumble", g.Name)
+		}
+		haptic, err := sdl.HapticOpenFromJoystick(g.controller.GetJoystick())
+		if err == nil {
+			err = haptic.RumbleInit()
+			if err != nil {
+				haptic.Close()
+			}
+		}
+		if err != nil {
+			g.noHaptic = true
+			return err
+		}
+		g.haptic = haptic
+	}
+	strength = math.Max(0, math.Min(1, strength))
+	return g.haptic.RumblePlay(float32(strength), uint32(duration/time.Millisecond))
+}
+
+func (g *Gamepad) StopRumble() {
+	if g.haptic != nil {
+		g.haptic.RumbleStop()
+	}
+}
+
+func (g *Gamepad) close() {
+	if g.haptic != nil {
+		g.haptic.Close()
+		g.haptic = nil
+	}
+	if g.controller != nil {
+		g.controller.Close()
+		g.controller = nil
+	}
+}
+
+// Opens game controllers as they're plugged in and closes them when they're removed,
+// keeps their state and sends their input to the GamepadListeners
+// New gamepads are given to the first player without one if AutoAssign is set
+type GamepadSystem struct {
+	BaseSystem
+	Blocked func(Component) bool // Components it returns true for get no button downs
+
+	AutoAssign bool
+	MaxPlayers int // Players AutoAssign assigns gamepads to
+
+	OnConnected    func(pad *Gamepad)
+	OnDisconnected func(pad *Gamepad) // The gamepad is already closed
+
+	pads []*Gamepad
+}
+
+func NewGamepadSystem() *GamepadSystem {
+	return &GamepadSystem{
+		AutoAssign: true,
+		MaxPlayers: 4,
+	}
+}
+
+func (gs *GamepadSystem) AddComponent(component Component) {
+	if _, ok := component.(GamepadListener); ok {
+		gs.Components = append(gs.Components, component)
+	}
+}
+
+// The connected gamepads in the order they were connected
+func (gs *GamepadSystem) Gamepads() []*Gamepad {
+	return gs.pads
+}
+
+// The gamepad assigned to player, nil if there's none
+func (gs *GamepadSystem) Gamepad(player int) *Gamepad {
+	if player == NOPLAYER {
+		return nil
+	}
+	for _, pad := range gs.pads {
+		if pad.Player == player {
+			return pad
+		}
+	}
+	return nil
+}
+
+func (gs *GamepadSystem) byID(id sdl.JoystickID) *Gamepad {
+	for _, pad := range gs.pads {
+		if pad.ID == id {
+			return pad
+		}
+	}
+	return nil
+}
+
+// Gives the gamepad to player, a gamepad the player had before is unassigned
+// NOPLAYER unassigns it
+func (gs *GamepadSystem) AssignPlayer(pad *Gamepad, player int) {
+	if player != NOPLAYER {
+		if old := gs.Gamepad(player); old != nil && old != pad {
+			old.Player = NOPLAYER
+		}
+	}
+	pad.Player = player
+}
+
+// Lowest player below MaxPlayers without a gamepad, NOPLAYER if they all have one
+func (gs *GamepadSystem) freePlayer() int {
+	for player := 0; player < gs.MaxPlayers; player++ {
+		if gs.Gamepad(player) == nil {
+			return player
+		}
+	}
+	return NOPLAYER
+}
+
+// Opens the controller at the device index, called for the devices connected at
+// startup too since SDL sends added events for those
+func (gs *GamepadSystem) open(index int) *Gamepad {
+	if !sdl.IsGameController(index) {
+		return nil
+	}
+	controller := sdl.GameControllerOpen(index)
+	if controller == nil {
+		fmt.Println("Failed opening gamepad:", sdl.GetError())
+		return nil
+	}
+	id := controller.GetJoystick().InstanceID()
+	if pad := gs.byID(id); pad != nil {
+		controller.Close() // Already open, SDL counts the opens
+		return pad
+	}
+
+	pad := &Gamepad{
+		ID:         id,
+		Name:       controller.Name(),
+		Player:     NOPLAYER,
+		DeadZone:   GAMEPADDEADZONE,
+		controller: controller,
+	}
+	if gs.AutoAssign {
+		pad.Player = gs.freePlayer()
+	}
+	gs.pads = append(gs.pads, pad)
+	if gs.OnConnected != nil {
+		gs.OnConnected(pad)
+	}
+	return pad
+}
+
+// Closes the gamepad with the instance id, the buttons still held are released first
+func (gs *GamepadSystem) remove(id sdl.JoystickID) *Gamepad {
+	for k, pad := range gs.pads {
+		if pad.ID != id {
+			continue
+		}
+		for button := range pad.buttons {
+			if pad.buttons[button] {
+				gs.buttonEvent(pad, sdl.GameControllerButton(button), false)
+			}
+		}
+		for axis := range pad.axes {
+			if pad.axes[axis] != 0 {
+				gs.axisEvent(pad, sdl.GameControllerAxis(axis), 0)
+			}
+		}
+
+		pad.close()
+		gs.pads = append(gs.pads[:k], gs.pads[k+1:]...)
+		if gs.OnDisconnected != nil {
+			gs.OnDisconnected(pad)
+		}
+		return pad
+	}
+	return nil
+}
+
+// Called at the start of every frame before the events
+func (gs *GamepadSystem) beginFrame() {
+	for _, pad := range gs.pads {
+		pad.pressed = [sdl.CONTROLLER_BUTTON_MAX]bool{}
+		pad.released = [sdl.CONTROLLER_BUTTON_MAX]bool{}
+	}
+}
+
+// Updates the state of the button, returns false if it didn't change
+func (gs *GamepadSystem) setButton(pad *Gamepad, button sdl.GameControllerButton, down bool) bool {
+	if !validButton(button) || pad.buttons[button] == down {
+		return false
+	}
+	pad.buttons[button] = down
+	if down {
+		pad.pressed[button] = true
+	} else {
+		pad.released[button] = true
+	}
+	return true
+}
+
+// Sends the button to the listeners
+func (gs *GamepadSystem) buttonEvent(pad *Gamepad, button sdl.GameControllerButton, down bool) {
+	gs.setButton(pad, button, down)
+	gs.ForEachComponent(func(comp Component) bool {
+		listener, ok := comp.(GamepadListener)
+		if !ok {
+			return false
+		}
+		if !down {
+			listener.GamepadButtonUp(pad.Player, button)
+		} else if gs.Blocked == nil || !gs.Blocked(comp) {
+			listener.GamepadButtonDown(pad.Player, button)
+		}
+		return true
+	})
+}
+
+// Updates the axis with a raw value from -1 to 1, the listeners get it when the value
+// with the dead zone changes
+func (gs *GamepadSystem) axisEvent(pad *Gamepad, axis sdl.GameControllerAxis, value float64) {
+	if !validAxis(axis) {
+		return
+	}
+	old := pad.Axis(axis)
+	pad.axes[axis] = value
+	current := pad.Axis(axis)
+	if current == old {
+		return
+	}
+
+	gs.ForEachComponent(func(comp Component) bool {
+		listener, ok := comp.(GamepadListener)
+		if !ok || (current != 0 && gs.Blocked != nil && gs.Blocked(comp)) {
+			return false
+		}
+		listener.GamepadAxis(pad.Player, axis, current)
+		return true
+	})
+}
+
+// Closes all the gamepads
+func (gs *GamepadSystem) Destroy() {
+	for _, pad := range gs.pads {
+		pad.close()
+	}
+	gs.pads = nil
+}
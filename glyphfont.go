@@ -0,0 +1,478 @@
+package vroom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/sdl_image"
+	"github.com/veandco/go-sdl2/sdl_ttf"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Size of the atlas pages glyphs from ttf fonts are packed into
+const GLYPHPAGESIZE = 512
+
+// Where a character is in the font pages and how to place it
+type Glyph struct {
+	Page             int
+	Src              sdl.Rect
+	XOffset, YOffset int
+	Advance          int
+}
+
+type kerningPair struct {
+	first, second rune
+}
+
+// Font that draws text from glyphs in a few textures instead of creating a texture for every string
+// Either loaded from a BMFont file, or built from a ttf font where every glyph is rasterized once the first time it's used
+type GlyphFont struct {
+	Pages      []*sdl.Texture
+	Glyphs     map[rune]*Glyph
+	LineHeight int
+	Base       int // Distance from the top of a line to the baseline
+
+	kerning map[kerningPair]int
+
+	// For fonts built from ttf fonts
+	ttfFont   *ttf.Font
+	engine    *Engine
+	surfaces  []*sdl.Surface
+	dirty     []bool
+	penX      int
+	penY      int
+	rowHeight int
+}
+
+// Returns the glyph font with this name, BMFonts loaded with LoadBMFont are returned as they are,
+// for ttf fonts loaded with LoadFont a glyph font is created the first time
+func (e *Engine) GetGlyphFont(name string) *GlyphFont {
+	if font, ok := e.GlyphFonts[name]; ok {
+		return font
+	}
+
+	ttfFont := e.GetFont(name)
+	if ttfFont == nil {
+		return nil
+	}
+
+	font := &GlyphFont{
+		Glyphs:     make(map[rune]*Glyph),
+		LineHeight: ttfFont.LineSkip(),
+		Base:       ttfFont.Ascent(),
+		kerning:    make(map[kerningPair]int),
+		ttfFont:    ttfFont,
+		engine:     e,
+	}
+	if e.GlyphFonts == nil {
+		e.GlyphFonts = make(map[string]*GlyphFont)
+	}
+	e.GlyphFonts[name] = font
+	return font
+}
+
+// Loads a AngelCode BMFont (.fnt, text or xml format), the pages are loaded from the same folder
+func (e *Engine) LoadBMFont(path, name string) error {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var font *GlyphFont
+	var pages []string
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '<' {
+		font, pages, err = parseBMFontXML(raw)
+	} else {
+		font, pages, err = parseBMFontText(raw)
+	}
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	for _, page := range pages {
+		texture, err := img.LoadTexture(e.renderer, filepath.Join(dir, page))
+		if err != nil {
+			font.Destroy()
+			return err
+		}
+		font.Pages = append(font.Pages, texture)
+	}
+
+	if e.GlyphFonts == nil {
+		e.GlyphFonts = make(map[string]*GlyphFont)
+	}
+	if old, ok := e.GlyphFonts[name]; ok {
+		old.Destroy()
+	}
+	e.GlyphFonts[name] = font
+	return nil
+}
+
+func newBMFont() *GlyphFont {
+	return &GlyphFont{
+		Glyphs:  make(map[rune]*Glyph),
+		kerning: make(map[kerningPair]int),
+	}
+}
+
+// Splits a line like: char id=32 x=0 file="some name.png"
+func bmfontFields(line string) (string, map[string]string) {
+	fields := make(map[string]string)
+	line = strings.TrimSpace(line)
+	tag := line
+	if space := strings.IndexByte(line, ' '); space != -1 {
+		tag = line[:space]
+		line = line[space+1:]
+	} else {
+		return tag, fields
+	}
+
+	for len(line) > 0 {
+		line = strings.TrimLeft(line, " \t")
+		eq := strings.IndexByte(line, '=')
+		if eq == -1 {
+			break
+		}
+		key := line[:eq]
+		line = line[eq+1:]
+
+		var value string
+		if strings.HasPrefix(line, "\"") {
+			end := strings.IndexByte(line[1:], '"')
+			if end == -1 {
+				value, line = line[1:], ""
+			} else {
+				value, line = line[1:end+1], line[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(line, " \t")
+			if end == -1 {
+				value, line = line, ""
+			} else {
+				value, line = line[:end], line[end:]
+			}
+		}
+		fields[key] = value
+	}
+	return tag, fields
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func parseBMFontText(raw []byte) (*GlyphFont, []string, error) {
+	font := newBMFont()
+	pages := make([]string, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		tag, fields := bmfontFields(scanner.Text())
+		switch tag {
+		case "common":
+			font.LineHeight = atoi(fields["lineHeight"])
+			font.Base = atoi(fields["base"])
+		case "page":
+			id := atoi(fields["id"])
+			for len(pages) <= id {
+				pages = append(pages, "")
+			}
+			pages[id] = fields["file"]
+		case "char":
+			font.Glyphs[rune(atoi(fields["id"]))] = &Glyph{
+				Page:    atoi(fields["page"]),
+				Src:     sdl.Rect{X: int32(atoi(fields["x"])), Y: int32(atoi(fields["y"])), W: int32(atoi(fields["width"])), H: int32(atoi(fields["height"]))},
+				XOffset: atoi(fields["xoffset"]),
+				YOffset: atoi(fields["yoffset"]),
+				Advance: atoi(fields["xadvance"]),
+			}
+		case "kerning":
+			pair := kerningPair{rune(atoi(fields["first"])), rune(atoi(fields["second"]))}
+			font.kerning[pair] = atoi(fields["amount"])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(pages) < 1 {
+		return nil, nil, fmt.Errorf("BMFont has no pages")
+	}
+	return font, pages, nil
+}
+
+type bmfontXML struct {
+	Common struct {
+		LineHeight int `xml:"lineHeight,attr"`
+		Base       int `xml:"base,attr"`
+	} `xml:"common"`
+	Pages []struct {
+		ID   int    `xml:"id,attr"`
+		File string `xml:"file,attr"`
+	} `xml:"pages>page"`
+	Chars []struct {
+		ID       int `xml:"id,attr"`
+		X        int `xml:"x,attr"`
+		Y        int `xml:"y,attr"`
+		Width    int `xml:"width,attr"`
+		Height   int `xml:"height,attr"`
+		XOffset  int `xml:"xoffset,attr"`
+		YOffset  int `xml:"yoffset,attr"`
+		XAdvance int `xml:"xadvance,attr"`
+		Page     int `xml:"page,attr"`
+	} `xml:"chars>char"`
+	Kernings []struct {
+		First  int `xml:"first,attr"`
+		Second int `xml:"second,attr"`
+		Amount int `xml:"amount,attr"`
+	} `xml:"kernings>kerning"`
+}
+
+func parseBMFontXML(raw []byte) (*GlyphFont, []string, error) {
+	var decoded bmfontXML
+	err := xml.Unmarshal(raw, &decoded)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	font := newBMFont()
+	font.LineHeight = decoded.Common.LineHeight
+	font.Base = decoded.Common.Base
+
+	pages := make([]string, 0)
+	for _, page := range decoded.Pages {
+		for len(pages) <= page.ID {
+			pages = append(pages, "")
+		}
+		pages[page.ID] = page.File
+	}
+	if len(pages) < 1 {
+		return nil, nil, fmt.Errorf("BMFont has no pages")
+	}
+
+	for _, char := range decoded.Chars {
+		font.Glyphs[rune(char.ID)] = &Glyph{
+			Page:    char.Page,
+			Src:     sdl.Rect{X: int32(char.X), Y: int32(char.Y), W: int32(char.Width), H: int32(char.Height)},
+			XOffset: char.XOffset,
+			YOffset: char.YOffset,
+			Advance: char.XAdvance,
+		}
+	}
+	for _, kerning := range decoded.Kernings {
+		font.kerning[kerningPair{rune(kerning.First), rune(kerning.Second)}] = kerning.Amount
+	}
+	return font, pages, nil
+}
+
+// Returns the glyph for r, rasterizing it first if needed
+// Characters missing from the font use ? instead, nil if that's missing too
+func (gf *GlyphFont) Glyph(r rune) *Glyph {
+	glyph, ok := gf.Glyphs[r]
+	if !ok && gf.ttfFont != nil {
+		// Also remembers glyphs that failed so they're not tried again
+		glyph = gf.rasterize(r)
+		gf.Glyphs[r] = glyph
+	}
+	if glyph != nil {
+		return glyph
+	}
+
+	if r != '?' {
+		return gf.Glyph('?')
+	}
+	return nil
+}
+
+// Renders a glyph from the ttf font into the atlas pages
+func (gf *GlyphFont) rasterize(r rune) *Glyph {
+	surface := gf.ttfFont.RenderUTF8_Blended(string(r), sdl.Color{255, 255, 255, 255})
+	if surface == nil {
+		return nil
+	}
+	defer surface.Free()
+
+	w, h := int(surface.W), int(surface.H)
+	if w > GLYPHPAGESIZE || h > GLYPHPAGESIZE {
+		return nil
+	}
+
+	// Next row, then next page when it doesn't fit
+	if gf.penX+w > GLYPHPAGESIZE {
+		gf.penX = 0
+		gf.penY += gf.rowHeight + 1
+		gf.rowHeight = 0
+	}
+	if len(gf.surfaces) < 1 || gf.penY+h > GLYPHPAGESIZE {
+		page, err := sdl.CreateRGBSurface(0, GLYPHPAGESIZE, GLYPHPAGESIZE, 32, 0x00ff0000, 0x0000ff00, 0x000000ff, 0xff000000)
+		if err != nil {
+			fmt.Println("Failed creating glyph page: ", err)
+			return nil
+		}
+		gf.surfaces = append(gf.surfaces, page)
+		gf.Pages = append(gf.Pages, nil)
+		gf.dirty = append(gf.dirty, true)
+		gf.penX, gf.penY, gf.rowHeight = 0, 0, 0
+	}
+
+	// The advance the font uses, the surface can be wider for glyphs reaching past it
+	advance, _, err := gf.ttfFont.SizeUTF8(string(r))
+	if err != nil {
+		advance = w
+	}
+
+	pageIndex := len(gf.surfaces) - 1
+	dst := sdl.Rect{X: int32(gf.penX), Y: int32(gf.penY), W: int32(w), H: int32(h)}
+	surface.SetBlendMode(sdl.BLENDMODE_NONE)
+	surface.Blit(nil, gf.surfaces[pageIndex], &dst)
+	gf.dirty[pageIndex] = true
+
+	gf.penX += w + 1
+	if h > gf.rowHeight {
+		gf.rowHeight = h
+	}
+
+	return &Glyph{
+		Page:    pageIndex,
+		Src:     dst,
+		Advance: advance,
+	}
+}
+
+// Uploads pages that got new glyphs since the last time
+func (gf *GlyphFont) upload() {
+	for k, dirty := range gf.dirty {
+		if !dirty {
+			continue
+		}
+		texture, err := gf.engine.renderer.CreateTextureFromSurface(gf.surfaces[k])
+		if err != nil {
+			fmt.Println("Failed creating glyph page texture: ", err)
+			continue
+		}
+		if gf.Pages[k] != nil {
+			gf.Pages[k].Destroy()
+		}
+		gf.Pages[k] = texture
+		gf.dirty[k] = false
+	}
+}
+
+// Extra space between two characters, for ttf fonts it's measured the first time a pair is used
+// so text is spaced the same as in a Label using the font
+func (gf *GlyphFont) Kerning(first, second rune) int {
+	pair := kerningPair{first, second}
+	amount, ok := gf.kerning[pair]
+	if ok || gf.ttfFont == nil {
+		return amount
+	}
+
+	firstGlyph, secondGlyph := gf.Glyph(first), gf.Glyph(second)
+	if firstGlyph != nil && secondGlyph != nil {
+		w, _, err := gf.ttfFont.SizeUTF8(string([]rune{first, second}))
+		if err == nil {
+			amount = w - firstGlyph.Advance - secondGlyph.Advance
+		}
+	}
+	gf.kerning[pair] = amount
+	return amount
+}
+
+// Width of the widest line in pixels
+func (gf *GlyphFont) Measure(text string) int {
+	widest := 0
+	for _, line := range strings.Split(text, "\n") {
+		w := 0
+		prev := rune(-1)
+		for _, r := range line {
+			glyph := gf.Glyph(r)
+			if glyph == nil {
+				continue
+			}
+			if prev != -1 {
+				w += gf.Kerning(prev, r)
+			}
+			w += glyph.Advance
+			prev = r
+		}
+		if w > widest {
+			widest = w
+		}
+	}
+	return widest
+}
+
+// Draws a single line of text with the top left corner at x, y
+func (gf *GlyphFont) DrawLine(renderer *sdl.Renderer, text string, x, y float64, scale float64, color sdl.Color) {
+	if scale == 0 {
+		scale = 1
+	}
+
+	// Make sure all the glyphs are there before uploading
+	for _, r := range text {
+		gf.Glyph(r)
+	}
+	gf.upload()
+
+	for _, page := range gf.Pages {
+		if page != nil {
+			page.SetColorMod(color.R, color.G, color.B)
+			page.SetAlphaMod(color.A)
+		}
+	}
+
+	penX := x
+	prev := rune(-1)
+	for _, r := range text {
+		glyph := gf.Glyph(r)
+		if glyph == nil {
+			continue
+		}
+		if prev != -1 {
+			penX += float64(gf.Kerning(prev, r)) * scale
+		}
+		prev = r
+
+		if glyph.Src.W > 0 && glyph.Src.H > 0 && glyph.Page < len(gf.Pages) && gf.Pages[glyph.Page] != nil {
+			src := glyph.Src
+			dst := &sdl.Rect{
+				X: int32(penX + float64(glyph.XOffset)*scale),
+				Y: int32(y + float64(glyph.YOffset)*scale),
+				W: int32(float64(src.W) * scale),
+				H: int32(float64(src.H) * scale),
+			}
+			renderer.Copy(gf.Pages[glyph.Page], &src, dst)
+		}
+		penX += float64(glyph.Advance) * scale
+	}
+}
+
+// Draws text with the top left corner at x, y, newlines start a new line
+func (gf *GlyphFont) DrawText(renderer *sdl.Renderer, text string, x, y float64, color sdl.Color) {
+	for k, line := range strings.Split(text, "\n") {
+		gf.DrawLine(renderer, line, x, y+float64(k*gf.LineHeight), 1, color)
+	}
+}
+
+func (gf *GlyphFont) Destroy() {
+	for _, page := range gf.Pages {
+		if page != nil {
+			page.Destroy()
+		}
+	}
+	for _, surface := range gf.surfaces {
+		surface.Free()
+	}
+	gf.Pages = nil
+	gf.surfaces = nil
+	gf.dirty = nil
+	gf.Glyphs = make(map[rune]*Glyph)
+	gf.penX, gf.penY, gf.rowHeight = 0, 0, 0
+}
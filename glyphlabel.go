@@ -0,0 +1,188 @@
+package vroom
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+)
+
+// Label drawn from a GlyphFont, changing the text is cheap since no textures are created
+// so use this for text that changes often like counters
+// Font is the name of a BMFont loaded with LoadBMFont or a ttf font loaded with LoadFont
+type GlyphLabel struct {
+	BaseComponent
+	LocalizedText // Set Key to show localized text instead of Text
+	Font          string
+	Color         sdl.Color
+	CenterHor     bool
+	CenterVert    bool
+	IgnoreCamera  bool
+	Layer         int
+	Scale         float64 // 0 is treated as 1, use whole numbers for pixel art fonts
+
+	MaxWidth    int // Wrap lines longer than this (before scaling), 0 for no wrapping
+	Align       int // ALIGNLEFT, ALIGNCENTER or ALIGNRIGHT
+	LineSpacing int
+
+	Text          string
+	Lines         []string
+	Width, Height int // Size after scaling
+
+	lineOffsets []float64
+}
+
+func NewGlyphLabel(text, font string, center bool) *GlyphLabel {
+	return &GlyphLabel{
+		Font:       font,
+		Color:      sdl.Color{255, 255, 255, 255},
+		CenterHor:  center,
+		CenterVert: center,
+		Text:       text,
+	}
+}
+
+func (gl *GlyphLabel) Init() {
+	if gl.GetComponent("Transform") == nil {
+		transform := &Transform{}
+		gl.AddComponent(transform)
+	}
+	if gl.Key != "" {
+		gl.Relocalize()
+	} else {
+		gl.SetText(gl.Text)
+	}
+}
+
+// Shows the text for key in the current locale, see Localization.Text
+func (gl *GlyphLabel) SetKey(key string, args ...interface{}) {
+	gl.LocalizedText = LocalizedText{Key: key, Args: args}
+	gl.Relocalize()
+}
+
+// Shows the plural form of key for n, see Localization.Plural
+func (gl *GlyphLabel) SetPluralKey(key string, n int, args ...interface{}) {
+	gl.LocalizedText = LocalizedText{Key: key, Args: args, Count: n, Plural: true}
+	gl.Relocalize()
+}
+
+func (gl *GlyphLabel) Relocalize() {
+	if gl.Key != "" {
+		gl.SetText(gl.localize(gl.Parent.GetEngine()))
+	}
+}
+
+func (gl *GlyphLabel) Name() string {
+	return "GlyphLabel"
+}
+
+func (gl *GlyphLabel) Size() (int, int) {
+	return gl.Width, gl.Height
+}
+
+func (gl *GlyphLabel) GetLayer() int {
+	return gl.Layer
+}
+
+func (gl *GlyphLabel) scale() float64 {
+	if gl.Scale == 0 {
+		return 1
+	}
+	return gl.Scale
+}
+
+func (gl *GlyphLabel) SetText(text string) {
+	gl.Text = text
+
+	engine := gl.Parent.GetEngine()
+	font := engine.GetGlyphFont(gl.Font)
+	if font == nil {
+		gl.Lines = nil
+		gl.Width, gl.Height = 0, 0
+		return
+	}
+
+	lines := wrapLines(font.Measure, text, gl.MaxWidth)
+	gl.Lines = make([]string, len(lines))
+	widths := make([]int, len(lines))
+	blockWidth := gl.MaxWidth
+	for k, line := range lines {
+		gl.Lines[k] = line.Text
+		widths[k] = font.Measure(line.Text)
+		if gl.MaxWidth <= 0 && widths[k] > blockWidth {
+			blockWidth = widths[k]
+		}
+	}
+
+	gl.lineOffsets = make([]float64, len(lines))
+	for k, w := range widths {
+		switch gl.Align {
+		case ALIGNCENTER:
+			gl.lineOffsets[k] = math.Floor(float64(blockWidth-w) / 2)
+		case ALIGNRIGHT:
+			gl.lineOffsets[k] = float64(blockWidth - w)
+		}
+	}
+
+	scale := gl.scale()
+	gl.Width = int(float64(blockWidth) * scale)
+	gl.Height = int(float64(len(lines)*font.LineHeight+(len(lines)-1)*gl.LineSpacing) * scale)
+
+	if engine.DrawSystem != nil {
+		engine.DrawSystem.UpdateBounds(gl)
+	}
+}
+
+func (gl *GlyphLabel) topLeft() (float64, float64, bool) {
+	transformComp := gl.GetComponent("Transform")
+	if transformComp == nil {
+		return 0, 0, false
+	}
+	transform, ok := transformComp.(*Transform)
+	if !ok {
+		return 0, 0, false
+	}
+
+	position := transform.WorldPosition()
+	if gl.CenterHor {
+		position.X -= float64(gl.Width / 2)
+	}
+	if gl.CenterVert {
+		position.Y -= float64(gl.Height / 2)
+	}
+	return position.X, position.Y, true
+}
+
+func (gl *GlyphLabel) Bounds() (Rect, bool) {
+	x, y, ok := gl.topLeft()
+	if !ok {
+		return Rect{}, gl.IgnoreCamera
+	}
+	return Rect{x, y, float64(gl.Width), float64(gl.Height)}, gl.IgnoreCamera
+}
+
+func (gl *GlyphLabel) Draw(renderer *sdl.Renderer) {
+	x, y, ok := gl.topLeft()
+	if !ok || len(gl.Lines) < 1 {
+		return
+	}
+
+	engine := gl.Parent.GetEngine()
+	font := engine.GetGlyphFont(gl.Font)
+	if font == nil {
+		return
+	}
+
+	if !gl.IgnoreCamera {
+		x -= engine.Camera.X
+		y -= engine.Camera.Y
+	}
+
+	scale := gl.scale()
+	lineStep := float64(font.LineHeight+gl.LineSpacing) * scale
+	for k, line := range gl.Lines {
+		offset := 0.0
+		if k < len(gl.lineOffsets) {
+			offset = gl.lineOffsets[k] * scale
+		}
+		font.DrawLine(renderer, line, math.Floor(x+offset), math.Floor(y+float64(k)*lineStep), scale, gl.Color)
+	}
+}
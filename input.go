@@ -0,0 +1,198 @@
+package vroom
+
+import (
+	"github.com/jonas747/go-box2d-lite/box2dlite"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// State of the keyboard and mouse for the current frame, so components can check for presses
+// in Update instead of keeping track of the previous state themselves
+// Like the actions, keys and clicks used by the UI and presses while a modal is open aren't seen
+// here, releases always are so nothing gets stuck
+type Input struct {
+	MouseX, MouseY   int     // Logical screen coordinates
+	MouseDX, MouseDY int     // How far the mouse moved this frame
+	WheelX, WheelY   float64 // How far the wheel was scrolled this frame, see MouseWheelListener
+
+	engine   *Engine
+	mod      sdl.Keymod
+	hasMouse bool // Got a position, the first one doesn't count as movement
+
+	keys         map[sdl.Keycode]bool
+	keysPressed  map[sdl.Keycode]bool
+	keysReleased map[sdl.Keycode]bool
+	keyRepeats   map[sdl.Keycode]int // Repeat events this frame
+
+	buttons         map[int]bool
+	buttonsPressed  map[int]bool
+	buttonsReleased map[int]bool
+}
+
+func NewInput(e *Engine) *Input {
+	return &Input{
+		engine:          e,
+		keys:            make(map[sdl.Keycode]bool),
+		keysPressed:     make(map[sdl.Keycode]bool),
+		keysReleased:    make(map[sdl.Keycode]bool),
+		keyRepeats:      make(map[sdl.Keycode]int),
+		buttons:         make(map[int]bool),
+		buttonsPressed:  make(map[int]bool),
+		buttonsReleased: make(map[int]bool),
+	}
+}
+
+// True while the key is held
+func (in *Input) KeyDown(key sdl.Keycode) bool {
+	return in.keys[key]
+}
+
+// True in the frame the key went down, repeats don't count
+func (in *Input) KeyPressed(key sdl.Keycode) bool {
+	return in.keysPressed[key]
+}
+
+// True in the frame the key went up
+func (in *Input) KeyReleased(key sdl.Keycode) bool {
+	return in.keysReleased[key]
+}
+
+// True if the key was auto repeated this frame because it's held
+func (in *Input) KeyRepeated(key sdl.Keycode) bool {
+	return in.keyRepeats[key] > 0
+}
+
+// How many times the key was pressed or repeated this frame, for moving through menus and text
+func (in *Input) KeyPresses(key sdl.Keycode) int {
+	count := in.keyRepeats[key]
+	if in.keysPressed[key] {
+		count++
+	}
+	return count
+}
+
+// True while the mouse button (sdl.BUTTON_LEFT, sdl.BUTTON_RIGHT...) is held
+func (in *Input) MouseDown(button int) bool {
+	return in.buttons[button]
+}
+
+// True in the frame the mouse button went down
+func (in *Input) MousePressed(button int) bool {
+	return in.buttonsPressed[button]
+}
+
+// True in the frame the mouse button went up
+func (in *Input) MouseReleased(button int) bool {
+	return in.buttonsReleased[button]
+}
+
+// Mouse position in the world, with the camera applied
+func (in *Input) MouseWorld() box2dlite.Vec2 {
+	return box2dlite.Vec2{float64(in.MouseX) + in.engine.Camera.X, float64(in.MouseY) + in.engine.Camera.Y}
+}
+
+// The held modifiers, check them with Mod(sdl.KMOD_CTRL) etc.
+func (in *Input) Modifiers() sdl.Keymod {
+	return in.mod
+}
+
+// True if any of the modifiers in mod is held, left or right
+func (in *Input) Mod(mod sdl.Keymod) bool {
+	return in.mod&mod != 0
+}
+
+func (in *Input) Shift() bool {
+	return in.Mod(sdl.KMOD_SHIFT)
+}
+
+func (in *Input) Ctrl() bool {
+	return in.Mod(sdl.KMOD_CTRL)
+}
+
+func (in *Input) Alt() bool {
+	return in.Mod(sdl.KMOD_ALT)
+}
+
+// Called at the start of every frame before the events
+func (in *Input) beginFrame() {
+	in.MouseDX, in.MouseDY = 0, 0
+	in.WheelX, in.WheelY = 0, 0
+	in.mod = sdl.GetModState() // Key events update it, this is for when there are none
+	for key := range in.keysPressed {
+		delete(in.keysPressed, key)
+	}
+	for key := range in.keysReleased {
+		delete(in.keysReleased, key)
+	}
+	for key := range in.keyRepeats {
+		delete(in.keyRepeats, key)
+	}
+	for button := range in.buttonsPressed {
+		delete(in.buttonsPressed, button)
+	}
+	for button := range in.buttonsReleased {
+		delete(in.buttonsReleased, button)
+	}
+}
+
+// The modifiers held with the key event, so they're right for the keys pressed this frame
+func (in *Input) modEvent(mod sdl.Keymod) {
+	in.mod = mod
+}
+
+func (in *Input) keyEvent(key sdl.Keycode, down, repeat bool) {
+	if down && repeat {
+		if in.keys[key] {
+			in.keyRepeats[key]++
+		}
+		return
+	}
+	if in.keys[key] == down {
+		return
+	}
+	in.keys[key] = down
+	if down {
+		in.keysPressed[key] = true
+	} else {
+		in.keysReleased[key] = true
+	}
+}
+
+func (in *Input) mouseButtonEvent(button int, down bool) {
+	if in.buttons[button] == down {
+		return
+	}
+	in.buttons[button] = down
+	if down {
+		in.buttonsPressed[button] = true
+	} else {
+		in.buttonsReleased[button] = true
+	}
+}
+
+func (in *Input) wheelEvent(dx, dy float64) {
+	in.WheelX += dx
+	in.WheelY += dy
+}
+
+func (in *Input) mouseMove(x, y int) {
+	if in.hasMouse {
+		in.MouseDX += x - in.MouseX
+		in.MouseDY += y - in.MouseY
+	}
+	in.MouseX, in.MouseY = x, y
+	in.hasMouse = true
+}
+
+// Releases everything, when the window loses focus and the ups would go elsewhere
+func (in *Input) Reset() {
+	for key, down := range in.keys {
+		if down {
+			in.keyEvent(key, false, false)
+		}
+	}
+	for button, down := range in.buttons {
+		if down {
+			in.mouseButtonEvent(button, false)
+		}
+	}
+}
@@ -3,22 +3,45 @@ package vroom
 import (
 	"fmt"
 	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/sdl_ttf"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Text alignment
+const (
+	ALIGNLEFT = iota
+	ALIGNCENTER
+	ALIGNRIGHT
+	ALIGNJUSTIFY // Stretches every line but the last one in a paragraph to the full width
 )
 
 // Label component
+// Multiple lines are rendered into a single texture, lines are split on newlines
+// and wrapped at MaxWidth if it's set
 type Label struct {
 	BaseComponent
-	Font         string
-	FontOutline  string
-	CenterHor    bool
-	CenterVert   bool
-	IgnoreCamera bool
-	Color        sdl.Color
-	ColorOutline sdl.Color
+	LocalizedText // Set Key to show localized text instead of Text
+	Font          string
+	FontOutline   string
+	CenterHor     bool
+	CenterVert    bool
+	IgnoreCamera  bool
+	Color         sdl.Color
+	ColorOutline  sdl.Color
+	StyleName     string // Takes the font and colors from this style in the current theme if set, see ApplyTheme
+
+	MaxWidth    int // Wrap lines longer than this, 0 for no wrapping
+	Align       int
+	LineSpacing int // Extra pixels between lines
 
 	Text          string
+	Lines         []string // Lines after wrapping, set by SetText
 	Width, Height int
 	Texture       *sdl.Texture
+
+	lineRects []Rect
 }
 
 // Helper function to create label struct
@@ -34,6 +57,13 @@ func NewLabel(text string, center bool, font, outline string) *Label {
 	}
 }
 
+// Label using the style from the current theme for its font and colors
+func NewThemedLabel(text string, center bool, style string) *Label {
+	label := NewLabel(text, center, "", "")
+	label.StyleName = style
+	return label
+}
+
 func NewSimpleLabelEntity(x, y float64, text string, center bool, font, outline string, ignoreCamera bool) (Entity, *Label) {
 	label := NewLabel(text, center, font, outline)
 	label.IgnoreCamera = ignoreCamera
@@ -43,7 +73,51 @@ func NewSimpleLabelEntity(x, y float64, text string, center bool, font, outline
 }
 
 func (l *Label) Init() {
-	if l.Text != "" {
+	l.ApplyTheme()
+	if l.Key != "" {
+		l.Relocalize()
+	} else if l.Text != "" {
+		l.SetText(l.Text)
+	}
+}
+
+// Shows the text for key in the current locale, see Localization.Text
+func (l *Label) SetKey(key string, args ...interface{}) {
+	l.LocalizedText = LocalizedText{Key: key, Args: args}
+	l.Relocalize()
+}
+
+// Shows the plural form of key for n, see Localization.Plural
+func (l *Label) SetPluralKey(key string, n int, args ...interface{}) {
+	l.LocalizedText = LocalizedText{Key: key, Args: args, Count: n, Plural: true}
+	l.Relocalize()
+}
+
+func (l *Label) Relocalize() {
+	if l.Key != "" {
+		l.SetText(l.localize(l.Parent.GetEngine()))
+	}
+}
+
+// Takes the font, outline font, text color (of the idle state) and outline color from the style
+// named StyleName in the current theme, or the Label style if there's none with that name
+// The fonts have to be ttf fonts. Does nothing if StyleName isn't set or there's no theme
+func (l *Label) ApplyTheme() {
+	if l.StyleName == "" || l.Parent == nil || l.Parent.GetEngine() == nil || l.Parent.GetEngine().UI == nil {
+		return
+	}
+	style := l.Parent.GetEngine().UI.ThemeStyle(l.StyleName, "Label")
+	if style == nil {
+		return
+	}
+
+	l.Font = style.Font
+	l.FontOutline = style.Outline
+	l.Color = style.States[WIDGETIDLE].TextColor
+	if style.OutlineColor.A > 0 {
+		l.ColorOutline = style.OutlineColor
+	}
+	if l.Texture != nil {
 		l.SetText(l.Text)
 	}
 }
@@ -62,9 +136,9 @@ func (l *Label) Draw(renderer *sdl.Renderer) {
 		return
 	}
 
-	position := casted.CalcPos()
+	position := casted.WorldPosition()
 	if !l.IgnoreCamera {
-		position.Sub(l.Parent.GetEngine().Camera)
+		position = position.Sub(l.Parent.GetEngine().Camera)
 	}
 
 	center := &sdl.Point{X: int32(l.Width / 2), Y: int32(l.Height / 2)}
@@ -77,7 +151,7 @@ func (l *Label) Draw(renderer *sdl.Renderer) {
 		position.Y -= float64(l.Height / 2)
 		center.Y -= int32(l.Height / 2)
 	}
-	angle := casted.CalcAngle()
+	angle := casted.WorldAngle()
 
 	dstRect := &sdl.Rect{X: int32(position.X), Y: int32(position.Y), W: int32(l.Width), H: int32(l.Height)}
 	renderer.CopyEx(l.Texture, nil, dstRect, float64(angle), center, sdl.FLIP_NONE)
@@ -86,27 +160,297 @@ func (l *Label) Draw(renderer *sdl.Renderer) {
 func (l *Label) SetText(text string) {
 	if l.Texture != nil {
 		l.Texture.Destroy()
+		l.Texture = nil
 	}
 
-	var texture *sdl.Texture
+	engine := l.Parent.GetEngine()
 
-	if l.FontOutline != "" {
-		texture = l.Parent.GetEngine().CreateOutlinedTextTexture(l.Font, l.FontOutline, text, l.Color, l.ColorOutline)
+	var texture *sdl.Texture
+	if !strings.Contains(text, "\n") && l.MaxWidth <= 0 {
+		// Single line, no need to put it together
+		texture = engine.CreateOutlinedTextTexture(l.Font, l.FontOutline, text, l.Color, l.ColorOutline)
+		l.Lines = []string{text}
+		l.lineRects = nil
 	} else {
-		texture = l.Parent.GetEngine().CreateTextTexture(l.Font, text, l.Color)
+		texture = l.renderLines(engine, text)
 	}
 
+	l.Text = text
 	if texture == nil {
 		fmt.Println("Texture not found!!")
 		return
 	}
 
 	l.Texture = texture
-	l.Text = text
 
 	_, _, w, h, _ := texture.Query()
 	l.Width = w
 	l.Height = h
+
+	if len(l.lineRects) < 1 {
+		l.lineRects = []Rect{{0, 0, float64(w), float64(h)}}
+	}
+
+	if engine.DrawSystem != nil {
+		engine.DrawSystem.UpdateBounds(l)
+	}
+}
+
+// Font used for measuring, the outline font is a bit bigger so that one is used if set
+func (l *Label) measureFont(engine *Engine) *ttf.Font {
+	if l.FontOutline != "" {
+		if outline := engine.GetFont(l.FontOutline); outline != nil {
+			return outline
+		}
+	}
+	return engine.GetFont(l.Font)
+}
+
+type labelPiece struct {
+	surface *sdl.Surface
+	x, y    int32
+}
+
+// Renders the wrapped lines into a single texture
+func (l *Label) renderLines(engine *Engine, text string) *sdl.Texture {
+	font := l.measureFont(engine)
+	if font == nil {
+		return nil
+	}
+
+	lines := WrapText(font, text, l.MaxWidth)
+	l.Lines = make([]string, len(lines))
+	for k, line := range lines {
+		l.Lines[k] = line.Text
+	}
+
+	lineHeight := font.LineSkip()
+	if lineHeight < font.Height() {
+		lineHeight = font.Height()
+	}
+
+	render := func(s string) *sdl.Surface {
+		return engine.renderTextSurface(l.Font, l.FontOutline, s, l.Color, l.ColorOutline)
+	}
+
+	// Render the lines first to know how wide the block is
+	lineSurfaces := make([]*sdl.Surface, len(lines))
+	blockWidth := l.MaxWidth
+	for k, line := range lines {
+		if line.Text == "" {
+			continue
+		}
+		lineSurfaces[k] = render(line.Text)
+		if lineSurfaces[k] != nil && l.MaxWidth <= 0 && int(lineSurfaces[k].W) > blockWidth {
+			blockWidth = int(lineSurfaces[k].W)
+		}
+	}
+
+	pieces := make([]labelPiece, 0, len(lines))
+	l.lineRects = make([]Rect, len(lines))
+	for k, line := range lines {
+		y := int32(k * (lineHeight + l.LineSpacing))
+		surface := lineSurfaces[k]
+		if surface == nil {
+			l.lineRects[k] = Rect{0, float64(y), 0, float64(lineHeight)}
+			continue
+		}
+
+		words := strings.Fields(line.Text)
+		if l.Align == ALIGNJUSTIFY && !line.End && len(words) > 1 {
+			surface.Free()
+			pieces = l.justify(pieces, words, render, blockWidth, y)
+			l.lineRects[k] = Rect{0, float64(y), float64(blockWidth), float64(lineHeight)}
+			continue
+		}
+
+		var x int32
+		switch l.Align {
+		case ALIGNCENTER:
+			x = (int32(blockWidth) - surface.W) / 2
+		case ALIGNRIGHT:
+			x = int32(blockWidth) - surface.W
+		}
+		pieces = append(pieces, labelPiece{surface, x, y})
+		l.lineRects[k] = Rect{float64(x), float64(y), float64(surface.W), float64(lineHeight)}
+	}
+
+	height := len(lines)*lineHeight + (len(lines)-1)*l.LineSpacing
+	if blockWidth < 1 || height < 1 || len(pieces) < 1 {
+		for _, piece := range pieces {
+			piece.surface.Free()
+		}
+		return nil
+	}
+
+	target, err := sdl.CreateRGBSurface(0, int32(blockWidth), int32(height), 32, 0x00ff0000, 0x0000ff00, 0x000000ff, 0xff000000)
+	if err != nil {
+		fmt.Println("Failed creating label surface: ", err)
+		return nil
+	}
+
+	// The pieces don't overlap so they can just be copied over
+	for _, piece := range pieces {
+		piece.surface.SetBlendMode(sdl.BLENDMODE_NONE)
+		piece.surface.Blit(nil, target, &sdl.Rect{piece.x, piece.y, piece.surface.W, piece.surface.H})
+		piece.surface.Free()
+	}
+
+	texture, err := engine.renderer.CreateTextureFromSurface(target)
+	target.Free()
+	if err != nil {
+		return nil
+	}
+	return texture
+}
+
+// Spreads the words out over the full width
+func (l *Label) justify(pieces []labelPiece, words []string, render func(string) *sdl.Surface, width int, y int32) []labelPiece {
+	surfaces := make([]*sdl.Surface, 0, len(words))
+	total := 0
+	for _, word := range words {
+		surface := render(word)
+		if surface == nil {
+			continue
+		}
+		surfaces = append(surfaces, surface)
+		total += int(surface.W)
+	}
+	if len(surfaces) < 1 {
+		return pieces
+	}
+
+	gap := 0.0
+	if len(surfaces) > 1 {
+		gap = float64(width-total) / float64(len(surfaces)-1)
+	}
+
+	x := 0.0
+	for _, surface := range surfaces {
+		pieces = append(pieces, labelPiece{surface, int32(x), y})
+		x += float64(surface.W) + gap
+	}
+	return pieces
+}
+
+// Rect of line i, relative to the top left corner of the label
+func (l *Label) LineRect(i int) Rect {
+	if i < 0 || i >= len(l.lineRects) {
+		return Rect{}
+	}
+	return l.lineRects[i]
+}
+
+// Position of the top left corner of the label
+func (l *Label) topLeft(transform *Transform) (float64, float64) {
+	position := transform.WorldPosition()
+	if l.CenterHor {
+		position.X -= float64(l.Width / 2)
+	}
+	if l.CenterVert {
+		position.Y -= float64(l.Height / 2)
+	}
+	return position.X, position.Y
+}
+
+// Area covered by the text in world space (or screen space if IgnoreCamera is set)
+func (l *Label) Bounds() (Rect, bool) {
+	transformComp := l.GetComponent("Transform")
+	if transformComp == nil {
+		return Rect{}, l.IgnoreCamera
+	}
+	transform, ok := transformComp.(*Transform)
+	if !ok {
+		return Rect{}, l.IgnoreCamera
+	}
+
+	x, y := l.topLeft(transform)
+	bounds := Rect{x, y, float64(l.Width), float64(l.Height)}
+	if transform.WorldAngle() != 0 {
+		// Rotated around a corner at most, so this covers it
+		bounds = bounds.Grow(math.Max(float64(l.Width), float64(l.Height)))
+	}
+	return bounds, l.IgnoreCamera
+}
+
+func (l *Label) DrawTexture() *sdl.Texture {
+	return l.Texture
+}
+
+type TextLine struct {
+	Text string
+	End  bool // Last line of a paragraph
+}
+
+func textWidth(font *ttf.Font, text string) int {
+	w, _, err := font.SizeUTF8(text)
+	if err != nil {
+		return 0
+	}
+	return w
+}
+
+// Splits text into lines no wider than maxWidth, newlines always start a new line
+// With a maxWidth of 0 it's only split on newlines
+func WrapText(font *ttf.Font, text string, maxWidth int) []TextLine {
+	return wrapLines(func(s string) int { return textWidth(font, s) }, text, maxWidth)
+}
+
+func wrapLines(measure func(string) int, text string, maxWidth int) []TextLine {
+	paragraphs := strings.Split(text, "\n")
+	lines := make([]TextLine, 0, len(paragraphs))
+
+	for _, paragraph := range paragraphs {
+		if maxWidth <= 0 {
+			lines = append(lines, TextLine{paragraph, true})
+			continue
+		}
+
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if measure(candidate) <= maxWidth {
+				line = candidate
+				continue
+			}
+
+			if line != "" {
+				lines = append(lines, TextLine{line, false})
+			}
+
+			// Words too long to fit on a line of their own are broken up
+			for measure(word) > maxWidth {
+				cut := fitText(measure, word, maxWidth)
+				lines = append(lines, TextLine{word[:cut], false})
+				word = word[cut:]
+			}
+			line = word
+		}
+		lines = append(lines, TextLine{line, true})
+	}
+	return lines
+}
+
+// Returns how many bytes of text fit within maxWidth, at least one character
+func fitText(measure func(string) int, text string, maxWidth int) int {
+	_, size := utf8.DecodeRuneInString(text)
+	fit := size
+	for i := size; i < len(text); {
+		_, size = utf8.DecodeRuneInString(text[i:])
+		if measure(text[:i+size]) > maxWidth {
+			break
+		}
+		i += size
+		fit = i
+	}
+	return fit
+}
+
+func (l *Label) Size() (int, int) {
+	return l.Width, l.Height
 }
 
 func (l *Label) Name() string {
@@ -0,0 +1,453 @@
+package vroom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Picks the plural category for a count
+// Categories lists them in the order the forms appear in PO files
+type PluralRule struct {
+	Categories []string
+	Select     func(n int) string
+}
+
+var pluralOneOther = &PluralRule{
+	Categories: []string{"one", "other"},
+	Select: func(n int) string {
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	},
+}
+
+var pluralSlavic = &PluralRule{
+	Categories: []string{"one", "few", "many"},
+	Select: func(n int) string {
+		if n%10 == 1 && n%100 != 11 {
+			return "one"
+		}
+		if n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14) {
+			return "few"
+		}
+		return "many"
+	},
+}
+
+// Built in plural rules by language, anything else uses the english one/other rule
+var DefaultPluralRules = map[string]*PluralRule{
+	"fr": {
+		Categories: []string{"one", "other"},
+		Select: func(n int) string {
+			if n <= 1 {
+				return "one"
+			}
+			return "other"
+		},
+	},
+	"pl": {
+		Categories: []string{"one", "few", "many"},
+		Select: func(n int) string {
+			// Only 1 itself is singular, 21 jabłek is many unlike in russian
+			if n == 1 {
+				return "one"
+			}
+			if n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14) {
+				return "few"
+			}
+			return "many"
+		},
+	},
+	"ru": pluralSlavic,
+	"uk": pluralSlavic,
+	"ja": {Categories: []string{"other"}, Select: func(n int) string { return "other" }},
+	"zh": {Categories: []string{"other"}, Select: func(n int) string { return "other" }},
+	"ko": {Categories: []string{"other"}, Select: func(n int) string { return "other" }},
+}
+
+var pluralCategories = map[string]bool{"zero": true, "one": true, "two": true, "few": true, "many": true, "other": true}
+
+type translation struct {
+	text    string
+	forms   map[string]string // Plural forms by category
+	indexed []string          // Plural forms from PO files
+}
+
+// Implemented by components showing localized text, called when the locale changes
+type Localizable interface {
+	Component
+	Relocalize()
+}
+
+// String tables per locale, keeps track of Localizable components so they can be updated
+// when the locale changes
+type Localization struct {
+	BaseSystem
+	Locale   string
+	Fallback string // Used for keys missing in the current locale
+
+	PluralRules     map[string]*PluralRule // Overrides DefaultPluralRules, by language
+	OnLocaleChanged func(locale string)
+
+	tables map[string]map[string]*translation
+}
+
+func NewLocalization() *Localization {
+	return &Localization{
+		PluralRules: make(map[string]*PluralRule),
+		tables:      make(map[string]map[string]*translation),
+	}
+}
+
+func (l *Localization) AddComponent(component Component) {
+	_, ok := component.(Localizable)
+	if ok {
+		l.Components = append(l.Components, component)
+	}
+}
+
+// Switches the locale and updates all the localized components, hidden ones too so they're
+// in the new language when they're shown again
+func (l *Localization) SetLocale(locale string) {
+	l.Locale = locale
+	for _, comp := range l.Components {
+		if cast, ok := comp.(Localizable); ok && comp.GetParent() != nil {
+			cast.Relocalize()
+		}
+	}
+
+	if l.OnLocaleChanged != nil {
+		l.OnLocaleChanged(locale)
+	}
+}
+
+// Locales that have strings loaded
+func (l *Localization) Locales() []string {
+	locales := make([]string, 0, len(l.tables))
+	for locale := range l.tables {
+		locales = append(locales, locale)
+	}
+	return locales
+}
+
+// Loads a string table, the format is picked from the extension
+// .json: {"key": "text", "apples": {"one": "{n} apple", "other": "{n} apples"}, "menu": {"start": "Start"}}
+// Nested objects are joined with a dot, so the last one is menu.start
+// .po: gettext files, msgctxt is ignored
+// .csv: first row is key followed by a column per locale, plural forms use keys like apples#one
+// All the locales in a csv file are loaded if locale is empty
+func (l *Localization) LoadStrings(path, locale string) error {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return l.loadJSON(raw, locale)
+	case ".po":
+		return l.loadPO(raw, locale)
+	case ".csv":
+		return l.loadCSV(raw, locale)
+	}
+	return fmt.Errorf("Unknown string table format: %s", path)
+}
+
+func (l *Localization) table(locale string) map[string]*translation {
+	if l.tables == nil {
+		l.tables = make(map[string]map[string]*translation)
+	}
+	table, ok := l.tables[locale]
+	if !ok {
+		table = make(map[string]*translation)
+		l.tables[locale] = table
+	}
+	return table
+}
+
+func (l *Localization) loadJSON(raw []byte, locale string) error {
+	var decoded map[string]interface{}
+	err := json.Unmarshal(raw, &decoded)
+	if err != nil {
+		return err
+	}
+
+	table := l.table(locale)
+	var add func(prefix string, values map[string]interface{})
+	add = func(prefix string, values map[string]interface{}) {
+		for key, value := range values {
+			switch cast := value.(type) {
+			case string:
+				table[prefix+key] = &translation{text: cast}
+			case map[string]interface{}:
+				if isPluralObject(cast) {
+					t := &translation{forms: make(map[string]string)}
+					for category, form := range cast {
+						t.forms[category], _ = form.(string)
+					}
+					t.text = t.forms["other"]
+					table[prefix+key] = t
+				} else {
+					add(prefix+key+".", cast)
+				}
+			}
+		}
+	}
+	add("", decoded)
+	return nil
+}
+
+func isPluralObject(values map[string]interface{}) bool {
+	if len(values) < 1 {
+		return false
+	}
+	for key, value := range values {
+		if _, ok := value.(string); !ok || !pluralCategories[key] {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *Localization) loadPO(raw []byte, locale string) error {
+	table := l.table(locale)
+
+	var id, plural, field string
+	var strs []string
+	index := 0
+
+	flush := func() {
+		if id != "" && len(strs) > 0 {
+			t := &translation{text: strs[0]}
+			if plural != "" {
+				t.indexed = strs
+			}
+			// Untranslated entries are left out so the fallback is used
+			if t.text != "" {
+				table[id] = t
+			}
+		}
+		id, plural, field = "", "", ""
+		strs = nil
+	}
+
+	appendTo := func(value string) {
+		switch field {
+		case "msgid":
+			id += value
+		case "msgid_plural":
+			plural += value
+		case "msgstr":
+			for len(strs) <= index {
+				strs = append(strs, "")
+			}
+			strs[index] += value
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "\"") {
+			value, err := strconv.Unquote(line)
+			if err != nil {
+				return err
+			}
+			appendTo(value)
+			continue
+		}
+
+		space := strings.IndexByte(line, ' ')
+		if space == -1 {
+			continue
+		}
+		keyword, rest := line[:space], strings.TrimSpace(line[space+1:])
+		value, err := strconv.Unquote(rest)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case keyword == "msgctxt":
+			flush()
+			field = ""
+		case keyword == "msgid":
+			if id != "" || len(strs) > 0 {
+				flush()
+			}
+			field = "msgid"
+		case keyword == "msgid_plural":
+			field = "msgid_plural"
+		case keyword == "msgstr":
+			field = "msgstr"
+			index = 0
+		case strings.HasPrefix(keyword, "msgstr["):
+			field = "msgstr"
+			index, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(keyword, "msgstr["), "]"))
+		default:
+			continue
+		}
+		appendTo(value)
+	}
+	flush()
+	return scanner.Err()
+}
+
+func (l *Localization) loadCSV(raw []byte, locale string) error {
+	reader := csv.NewReader(bytes.NewReader(raw))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) < 1 {
+		return nil
+	}
+
+	header := records[0]
+	for column := 1; column < len(header); column++ {
+		columnLocale := strings.TrimSpace(header[column])
+		if locale != "" && columnLocale != locale {
+			continue
+		}
+
+		table := l.table(columnLocale)
+		for _, record := range records[1:] {
+			if len(record) <= column || record[0] == "" || record[column] == "" {
+				continue
+			}
+
+			key := record[0]
+			category := ""
+			if hash := strings.LastIndex(key, "#"); hash != -1 && pluralCategories[key[hash+1:]] {
+				key, category = key[:hash], key[hash+1:]
+			}
+
+			if category == "" {
+				table[key] = &translation{text: record[column]}
+				continue
+			}
+
+			t, ok := table[key]
+			if !ok || t.forms == nil {
+				t = &translation{forms: make(map[string]string)}
+				table[key] = t
+			}
+			t.forms[category] = record[column]
+			if category == "other" || t.text == "" {
+				t.text = record[column]
+			}
+		}
+	}
+	return nil
+}
+
+// Language part of a locale, en-US and en_US becomes en
+func localeLanguage(locale string) string {
+	if i := strings.IndexAny(locale, "-_"); i != -1 {
+		return locale[:i]
+	}
+	return locale
+}
+
+// Finds the translation for key, trying the current locale, its language and then the fallback
+func (l *Localization) lookup(key string) (*translation, string) {
+	for _, locale := range []string{l.Locale, localeLanguage(l.Locale), l.Fallback, localeLanguage(l.Fallback)} {
+		if locale == "" {
+			continue
+		}
+		if t, ok := l.tables[locale][key]; ok {
+			return t, locale
+		}
+	}
+	return nil, ""
+}
+
+func (l *Localization) Has(key string) bool {
+	t, _ := l.lookup(key)
+	return t != nil
+}
+
+func (l *Localization) pluralRule(locale string) *PluralRule {
+	language := localeLanguage(locale)
+	if rule, ok := l.PluralRules[language]; ok {
+		return rule
+	}
+	if rule, ok := DefaultPluralRules[language]; ok {
+		return rule
+	}
+	return pluralOneOther
+}
+
+// Returns the localized text for key with {0}, {1}... replaced by args
+// Missing keys return the key itself so they're easy to spot
+func (l *Localization) Text(key string, args ...interface{}) string {
+	t, _ := l.lookup(key)
+	if t == nil {
+		return key
+	}
+	return formatLocalized(t.text, -1, args)
+}
+
+// Same as Text but picks the plural form for n, {n} is replaced by n
+func (l *Localization) Plural(key string, n int, args ...interface{}) string {
+	t, locale := l.lookup(key)
+	if t == nil {
+		return key
+	}
+
+	rule := l.pluralRule(locale)
+	category := rule.Select(n)
+
+	text := t.text
+	if form, ok := t.forms[category]; ok {
+		text = form
+	} else if len(t.indexed) > 0 {
+		for k, v := range rule.Categories {
+			if v == category && k < len(t.indexed) {
+				text = t.indexed[k]
+			}
+		}
+	}
+	return formatLocalized(text, n, args)
+}
+
+func formatLocalized(text string, n int, args []interface{}) string {
+	if !strings.Contains(text, "{") {
+		return text
+	}
+	if n >= 0 {
+		text = strings.Replace(text, "{n}", strconv.Itoa(n), -1)
+	}
+	for k, arg := range args {
+		text = strings.Replace(text, "{"+strconv.Itoa(k)+"}", fmt.Sprint(arg), -1)
+	}
+	return text
+}
+
+// Key and arguments for components showing localized text
+type LocalizedText struct {
+	Key    string
+	Args   []interface{}
+	Count  int
+	Plural bool // Use the plural form for Count
+}
+
+func (lt *LocalizedText) localize(e *Engine) string {
+	if lt.Plural {
+		return e.Localization.Plural(lt.Key, lt.Count, lt.Args...)
+	}
+	return e.Localization.Text(lt.Key, lt.Args...)
+}
@@ -2,6 +2,7 @@ package vroom
 
 import (
 	"github.com/veandco/go-sdl2/sdl"
+	"math"
 	"time"
 )
 
@@ -38,25 +39,39 @@ func (e *Engine) Loop() {
 }
 
 func (e *Engine) ProcessEvents() {
+	e.Input.beginFrame()
+	e.Actions.beginFrame()
+	e.Gamepads.beginFrame()
+
 	var event sdl.Event
 	for event = sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
 		switch evt := event.(type) {
 		case *sdl.QuitEvent:
 			e.running = false // byebye
+		case *sdl.WindowEvent:
+			if e.window.GetID() != evt.WindowID {
+				break
+			}
+			e.Display.windowEvent(evt)
+			if evt.Event == sdl.WINDOWEVENT_FOCUS_LOST {
+				e.Input.Reset()
+				e.Actions.Reset()
+			}
 		case *sdl.MouseMotionEvent:
 			if e.window.GetID() != evt.WindowID {
 				break
 			}
-			x := int(evt.X)
-			y := int(evt.Y)
+			x, y := e.Display.WindowToLogical(int(evt.X), int(evt.Y))
+			e.Input.mouseMove(x, y)
 
-			e.MouseHoverSystem.MouseMove(x, y)
+			if !e.UI.captureMouseMove(x, y) {
+				e.MouseHoverSystem.MouseMove(x, y)
+			}
 		case *sdl.MouseButtonEvent:
 			if e.window.GetID() != evt.WindowID {
 				break
 			}
-			x := int(evt.X)
-			y := int(evt.Y)
+			x, y := e.Display.WindowToLogical(int(evt.X), int(evt.Y))
 
 			button := int(evt.Button)
 
@@ -64,24 +79,127 @@ func (e *Engine) ProcessEvents() {
 			if evt.Type == sdl.MOUSEBUTTONDOWN {
 				up = false
 			}
-			e.MouseClickSystem.MouseButtonEvent(x, y, button, up)
+			if !up && e.Actions.Listening() {
+				e.Actions.buttonEvent(MouseButtonBinding(button), true)
+				break
+			}
+			used := e.UI.mouseButton(x, y, button, up)
+			if !used {
+				used = e.MouseClickSystem.MouseButtonEvent(x, y, button, up)
+			}
+			if up || (!used && !e.UI.ModalOpen()) {
+				e.Input.mouseButtonEvent(button, !up)
+				e.Actions.buttonEvent(MouseButtonBinding(button), !up)
+			}
 		case *sdl.MouseWheelEvent:
 			if e.window.GetID() != evt.WindowID {
 				break
 			}
-
+			dx, dy := float64(evt.X), float64(evt.Y)
+			if evt.Direction == sdl.MOUSEWHEEL_FLIPPED {
+				dx, dy = -dx, -dy
+			}
+			if !e.UI.mouseWheel() {
+				e.MouseWheelSystem.MouseWheelEvent(e.Input.MouseX, e.Input.MouseY, dx, dy)
+				if !e.UI.ModalOpen() {
+					e.Input.wheelEvent(dx, dy)
+				}
+			}
 		case *sdl.KeyUpEvent:
 			if e.window.GetID() != evt.WindowID {
 				break
 			}
+			e.Input.modEvent(sdl.Keymod(evt.Keysym.Mod))
+			// Key ups always go through so no key gets stuck
+			e.UI.keyEvent(evt.Keysym.Sym, true)
 			e.Keyboardsystem.KeyboardEvent(evt.Keysym.Sym, true)
+			e.Input.keyEvent(evt.Keysym.Sym, false, false)
+			e.Actions.buttonEvent(KeyBinding(evt.Keysym.Sym), false)
 		case *sdl.KeyDownEvent:
 			if e.window.GetID() != evt.WindowID {
 				break
 			}
-			e.Keyboardsystem.KeyboardEvent(evt.Keysym.Sym, false)
+			e.Input.modEvent(sdl.Keymod(evt.Keysym.Mod))
+			if e.Actions.Listening() {
+				if evt.Repeat == 0 {
+					e.Actions.buttonEvent(KeyBinding(evt.Keysym.Sym), true)
+				}
+				break
+			}
+			if e.Debug.ToggleKey != 0 && evt.Keysym.Sym == e.Debug.ToggleKey && evt.Repeat == 0 {
+				e.Debug.Toggle()
+			}
+			if e.PhysicsDebug.ToggleKey != 0 && evt.Keysym.Sym == e.PhysicsDebug.ToggleKey && evt.Repeat == 0 {
+				e.PhysicsDebug.Toggle()
+			}
+			if e.Display.FullscreenKey != 0 && evt.Keysym.Sym == e.Display.FullscreenKey && evt.Repeat == 0 {
+				e.Display.ToggleFullscreen()
+			}
+			if !e.UI.keyEvent(evt.Keysym.Sym, false) {
+				e.Keyboardsystem.KeyboardEvent(evt.Keysym.Sym, false)
+				if !e.UI.ModalOpen() {
+					e.Input.keyEvent(evt.Keysym.Sym, true, evt.Repeat != 0)
+					e.Actions.buttonEvent(KeyBinding(evt.Keysym.Sym), true)
+				}
+			}
+		case *sdl.TextInputEvent:
+			if e.window.GetID() != evt.WindowID {
+				break
+			}
+			e.UI.textInput(cString(evt.Text[:]))
+		case *sdl.TextEditingEvent:
+			if e.window.GetID() != evt.WindowID {
+				break
+			}
+			e.UI.textEditing(cString(evt.Text[:]), int(evt.Start), int(evt.Length))
+		case *sdl.ControllerDeviceEvent:
+			if evt.Type == sdl.CONTROLLERDEVICEADDED {
+				e.Gamepads.open(int(evt.Which)) // Which is the device index here
+			} else if evt.Type == sdl.CONTROLLERDEVICEREMOVED {
+				e.Actions.removeGamepad(e.Gamepads.byID(evt.Which))
+				e.Gamepads.remove(evt.Which)
+			}
+		case *sdl.ControllerButtonEvent:
+			pad := e.Gamepads.byID(evt.Which)
+			if pad == nil {
+				break
+			}
+			button := sdl.GameControllerButton(evt.Button)
+			pressed := evt.State == sdl.PRESSED
+			if pressed && e.Actions.acceptsGamepad(pad) && e.Actions.Listening() {
+				e.Gamepads.setButton(pad, button, true)
+				e.Actions.gamepadButtonEvent(pad, button, true)
+				break
+			}
+			if pressed && e.UI.ControllerButton(button) {
+				e.Gamepads.setButton(pad, button, true)
+				break
+			}
+			e.Gamepads.buttonEvent(pad, button, pressed)
+			if !pressed || !e.UI.ModalOpen() {
+				e.Actions.gamepadButtonEvent(pad, button, pressed)
+			}
+		case *sdl.ControllerAxisEvent:
+			pad := e.Gamepads.byID(evt.Which)
+			if pad == nil {
+				break
+			}
+			axis := sdl.GameControllerAxis(evt.Axis)
+			value := math.Max(-1, float64(evt.Value)/32767)
+			e.Gamepads.axisEvent(pad, axis, value)
+			e.Actions.gamepadAxisEvent(pad, axis, value)
+		}
+	}
+}
+
+// Text in sdl events is null terminated
+func cString(b []byte) string {
+	for k, v := range b {
+		if v == 0 {
+			return string(b[:k])
 		}
 	}
+	return string(b)
 }
 
 func (e *Engine) StepPhysics(dt float64) {
@@ -90,11 +208,37 @@ func (e *Engine) StepPhysics(dt float64) {
 
 func (e *Engine) Update(dt float64) {
 	e.UpdateSystem.Update(dt)
+	e.UI.update(dt)
+	e.UI.Layout()
 }
 
 func (e *Engine) Draw() {
-	e.renderer.SetDrawColor(e.ClearColor.R, e.ClearColor.G, e.ClearColor.B, 255)
-	e.renderer.Clear()
+	e.DrawSystem.PreDraw(e.renderer)
+
+	for _, target := range e.RenderTargets {
+		e.RenderToTarget(target)
+	}
+
+	display := e.Display.Begin(e.renderer)
+	postProcess := e.PostProcess.Active() && e.PostProcess.Begin(e.renderer)
+	if !postProcess && !display {
+		e.renderer.SetDrawColor(e.ClearColor.R, e.ClearColor.G, e.ClearColor.B, 255)
+		e.renderer.Clear()
+	}
+
 	e.DrawSystem.Draw(e.renderer)
+	e.UI.DrawOverlays(e.renderer)
+
+	if postProcess {
+		e.PostProcess.End(e.renderer)
+	}
+
+	// Debug overlays are drawn on top, unaffected by post processing
+	e.PhysicsDebug.Draw(e.renderer)
+	e.Debug.Draw(e.renderer)
+
+	if display {
+		e.Display.End(e.renderer)
+	}
 	e.renderer.Present()
 }
@@ -0,0 +1,206 @@
+package vroom
+
+import (
+	"fmt"
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+)
+
+// Sprite that is split into 9 parts by the insets, the corners are drawn unscaled
+// while the edges and center are stretched or tiled to fill the size
+type NineSliceSprite struct {
+	BaseComponent
+	Texture      *sdl.Texture
+	Region       *sdl.Rect // Part of the texture to use, nil for the whole texture
+	IgnoreCamera bool
+	Layer        int
+
+	Width, Height int
+
+	// Border insets in pixels
+	Left, Top, Right, Bottom int
+
+	TileEdges  bool // Tile the edges instead of stretching them
+	TileCenter bool // Tile the center instead of stretching it
+}
+
+// Creates a new nine slice sprite from texture name
+// if w and h is 0 it will take that from the texture
+func (e *Engine) NewNineSliceSprite(w, h int, ignoreCamera bool, texture string, left, top, right, bottom int) *NineSliceSprite {
+	tex := e.GetTexture(texture)
+	if tex == nil {
+		fmt.Println("Can't find texture: ", texture)
+		return nil
+	}
+
+	_, _, rw, rh, _ := tex.Query()
+	if w <= 0 {
+		w = rw
+	}
+	if h <= 0 {
+		h = rh
+	}
+
+	return &NineSliceSprite{
+		Texture:      tex,
+		Width:        w,
+		Height:       h,
+		IgnoreCamera: ignoreCamera,
+		Left:         left,
+		Top:          top,
+		Right:        right,
+		Bottom:       bottom,
+	}
+}
+
+func (ns *NineSliceSprite) Init() {
+	if ns.GetComponent("Transform") == nil {
+		transform := &Transform{}
+		ns.AddComponent(transform)
+	}
+}
+
+func (ns *NineSliceSprite) Bounds() (Rect, bool) {
+	return spriteBounds(ns, ns.Width, ns.Height), ns.IgnoreCamera
+}
+
+func (ns *NineSliceSprite) DrawTexture() *sdl.Texture {
+	return ns.Texture
+}
+
+func (ns *NineSliceSprite) SetSize(w, h int) {
+	if w == ns.Width && h == ns.Height {
+		return
+	}
+	ns.Width, ns.Height = w, h
+	if ns.Parent != nil && ns.Parent.GetEngine() != nil && ns.Parent.GetEngine().DrawSystem != nil {
+		ns.Parent.GetEngine().DrawSystem.UpdateBounds(ns)
+	}
+}
+
+func (ns *NineSliceSprite) Name() string {
+	return "NineSliceSprite"
+}
+
+func (ns *NineSliceSprite) GetLayer() int {
+	return ns.Layer
+}
+
+func (ns *NineSliceSprite) region() sdl.Rect {
+	if ns.Region != nil {
+		return *ns.Region
+	}
+	_, _, w, h, _ := ns.Texture.Query()
+	return sdl.Rect{W: int32(w), H: int32(h)}
+}
+
+func (ns *NineSliceSprite) Draw(renderer *sdl.Renderer) {
+	if ns.Texture == nil {
+		return
+	}
+	transform := ns.GetComponent("Transform")
+	if transform == nil {
+		return
+	}
+
+	casted, ok := transform.(*Transform)
+	if !ok {
+		return
+	}
+
+	position := casted.WorldPosition()
+	if !ns.IgnoreCamera {
+		position = position.Sub(ns.Parent.GetEngine().Camera)
+	}
+
+	angle := casted.WorldAngle()
+	scale := casted.WorldScale()
+	sx := math.Abs(scale.X)
+	sy := math.Abs(scale.Y)
+
+	w := int32(float64(ns.Width) * sx)
+	h := int32(float64(ns.Height) * sy)
+	originX := int32(position.X) - w/2
+	originY := int32(position.Y) - h/2
+
+	src := ns.region()
+
+	// Column and row boundaries in the source texture
+	srcX := [4]int32{src.X, src.X + int32(ns.Left), src.X + src.W - int32(ns.Right), src.X + src.W}
+	srcY := [4]int32{src.Y, src.Y + int32(ns.Top), src.Y + src.H - int32(ns.Bottom), src.Y + src.H}
+
+	// Scale the borders with the transform, and shrink them if the sprite is smaller than the borders
+	left := int32(float64(ns.Left) * sx)
+	right := int32(float64(ns.Right) * sx)
+	top := int32(float64(ns.Top) * sy)
+	bottom := int32(float64(ns.Bottom) * sy)
+	if left+right > w && left+right > 0 {
+		left = w * left / (left + right)
+		right = w - left
+	}
+	if top+bottom > h && top+bottom > 0 {
+		top = h * top / (top + bottom)
+		bottom = h - top
+	}
+
+	dstX := [4]int32{0, left, w - right, w}
+	dstY := [4]int32{0, top, h - bottom, h}
+
+	// Everything rotates around the center of the whole sprite
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			srcRect := sdl.Rect{X: srcX[col], Y: srcY[row], W: srcX[col+1] - srcX[col], H: srcY[row+1] - srcY[row]}
+			dstRect := sdl.Rect{X: dstX[col], Y: dstY[row], W: dstX[col+1] - dstX[col], H: dstY[row+1] - dstY[row]}
+			if srcRect.W <= 0 || srcRect.H <= 0 || dstRect.W <= 0 || dstRect.H <= 0 {
+				continue
+			}
+
+			isCorner := col != 1 && row != 1
+			isCenter := col == 1 && row == 1
+
+			tile := (isCenter && ns.TileCenter) || (!isCenter && !isCorner && ns.TileEdges)
+			if tile {
+				ns.drawTiled(renderer, srcRect, dstRect, sx, sy, originX, originY, w, h, angle)
+			} else {
+				ns.drawPart(renderer, srcRect, dstRect, originX, originY, w, h, angle)
+			}
+		}
+	}
+}
+
+func (ns *NineSliceSprite) drawPart(renderer *sdl.Renderer, src, dst sdl.Rect, originX, originY, w, h int32, angle float64) {
+	center := &sdl.Point{X: w/2 - dst.X, Y: h/2 - dst.Y}
+	dst.X += originX
+	dst.Y += originY
+	renderer.CopyEx(ns.Texture, &src, &dst, angle, center, sdl.FLIP_NONE)
+}
+
+// Repeats the source part over the destination, cutting off the last tile in each direction
+func (ns *NineSliceSprite) drawTiled(renderer *sdl.Renderer, src, dst sdl.Rect, sx, sy float64, originX, originY, w, h int32, angle float64) {
+	tileW := int32(float64(src.W) * sx)
+	tileH := int32(float64(src.H) * sy)
+	if tileW <= 0 || tileH <= 0 {
+		return
+	}
+
+	for y := dst.Y; y < dst.Y+dst.H; y += tileH {
+		for x := dst.X; x < dst.X+dst.W; x += tileW {
+			part := sdl.Rect{X: x, Y: y, W: tileW, H: tileH}
+			partSrc := src
+
+			if part.X+part.W > dst.X+dst.W {
+				part.W = dst.X + dst.W - part.X
+				partSrc.W = int32(float64(part.W) / sx)
+			}
+			if part.Y+part.H > dst.Y+dst.H {
+				part.H = dst.Y + dst.H - part.Y
+				partSrc.H = int32(float64(part.H) / sy)
+			}
+			if partSrc.W <= 0 || partSrc.H <= 0 {
+				continue
+			}
+
+			ns.drawPart(renderer, partSrc, part, originX, originY, w, h, angle)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package vroom
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+)
+
+// Background for a group of widgets, give the entity a UIElement to lay out the children
+// Drawn on UIPANELLAYER so the children end up on top
+type Panel struct {
+	Widget
+}
+
+func NewPanel() *Panel {
+	return &Panel{
+		Widget: Widget{Layer: UIPANELLAYER},
+	}
+}
+
+func (p *Panel) Init() {
+	p.initWidget(p)
+}
+
+func (p *Panel) Name() string {
+	return "Panel"
+}
+
+// Panels only hold other widgets
+func (p *Panel) CanFocus() bool {
+	return false
+}
+
+// Panels are sized by their element, they have no content of their own
+func (p *Panel) Size() (int, int) {
+	return 0, 0
+}
+
+func (p *Panel) Draw(renderer *sdl.Renderer) {
+	state := WIDGETIDLE
+	if p.Disabled {
+		state = WIDGETDISABLED
+	}
+	p.drawFrame(renderer, p.Rect(), state)
+}
+
+// Panel with a title bar showing Text, it can be dragged around by the title bar
+// and closed with the close button if Closable. The element padding at the top is
+// grown to fit the title bar so add the UIElement before the window
+type Window struct {
+	Panel
+	Closable bool
+	OnClose  func() // Called when closed with the close button, before it's hidden
+
+	dragging     bool
+	lastX, lastY int
+}
+
+func NewWindow(title string, closable bool) *Window {
+	return &Window{
+		Panel:    Panel{Widget: Widget{Text: title, Layer: UIPANELLAYER}},
+		Closable: closable,
+	}
+}
+
+func (w *Window) Init() {
+	w.initWidget(w)
+	if el := elementOf(w.Parent); el != nil {
+		titleHeight := int(w.titleHeight())
+		if el.Padding.Top < titleHeight+w.style().Padding.Top {
+			el.Padding.Top = titleHeight + w.style().Padding.Top
+		}
+	}
+}
+
+func (w *Window) Name() string {
+	return "Window"
+}
+
+func (w *Window) titleHeight() float64 {
+	return float64(w.lineHeight() + w.style().Padding.Vertical())
+}
+
+func (w *Window) titleRect() Rect {
+	rect := w.Rect()
+	rect.H = w.titleHeight()
+	return rect
+}
+
+func (w *Window) closeRect() Rect {
+	title := w.titleRect()
+	return Rect{title.Right() - title.H, title.Y, title.H, title.H}.Grow(-3)
+}
+
+// Hides the window with all its children
+func (w *Window) Close() {
+	if w.OnClose != nil {
+		w.OnClose()
+	}
+	SetEntityEnabled(w.Parent, false)
+}
+
+// Shows the window again after it's been closed
+func (w *Window) Show() {
+	SetEntityEnabled(w.Parent, true)
+}
+
+func (w *Window) press(x, y int) {
+	fx, fy := float64(x), float64(y)
+	if !w.titleRect().Contains(fx, fy) || (w.Closable && w.closeRect().Contains(fx, fy)) {
+		return
+	}
+	w.dragging = true
+	w.lastX, w.lastY = x, y
+	w.engine().UI.CaptureMouse(w)
+}
+
+func (w *Window) click(x, y int) {
+	if w.Closable && w.closeRect().Contains(float64(x), float64(y)) {
+		w.Close()
+	}
+}
+
+// Moves the element offset if there is one, otherwise the transform
+func (w *Window) CapturedMouseMove(x, y int) {
+	if !w.dragging {
+		return
+	}
+	dx, dy := float64(x-w.lastX), float64(y-w.lastY)
+	w.lastX, w.lastY = x, y
+
+	if el := elementOf(w.Parent); el != nil {
+		el.OffsetX += dx
+		el.OffsetY += dy
+		return
+	}
+	if transform, ok := w.GetComponent("Transform").(*Transform); ok {
+		transform.SetPosition(transform.Position.X+dx, transform.Position.Y+dy)
+	}
+}
+
+func (w *Window) CapturedMouseButton(x, y, button int, up bool) {
+	if up && button == sdl.BUTTON_LEFT {
+		w.dragging = false
+		w.engine().UI.ReleaseMouse(w)
+		w.IsHover = w.Rect().Contains(float64(x), float64(y))
+		w.release()
+	}
+}
+
+func (w *Window) Size() (int, int) {
+	style := w.style()
+	tw, _ := w.textSize(w.Text)
+	return tw + int(w.titleHeight()) + style.Padding.Horizontal(), int(w.titleHeight())
+}
+
+func (w *Window) Draw(renderer *sdl.Renderer) {
+	state := WIDGETIDLE
+	if w.Disabled {
+		state = WIDGETDISABLED
+	}
+	style := w.style()
+	w.drawFrame(renderer, w.Rect(), state)
+
+	title := w.titleRect()
+	titleState := WIDGETHOVER
+	if w.dragging {
+		titleState = WIDGETPRESSED
+	}
+	w.drawFrame(renderer, title, titleState)
+	textRect := Rect{title.X + float64(style.Padding.Left), title.Y, title.W - float64(style.Padding.Horizontal()), title.H}
+	if w.Closable {
+		textRect.W -= title.H
+	}
+	w.drawText(renderer, w.Text, textRect, ALIGNLEFT, style.States[titleState].TextColor)
+
+	if w.Closable {
+		cross := w.closeRect()
+		setDrawColor(renderer, style.States[titleState].TextColor)
+		inset := math.Floor(cross.W / 4)
+		renderer.DrawLine(int(cross.X+inset), int(cross.Y+inset), int(cross.Right()-inset), int(cross.Bottom()-inset))
+		renderer.DrawLine(int(cross.Right()-inset), int(cross.Y+inset), int(cross.X+inset), int(cross.Bottom()-inset))
+	}
+}
@@ -0,0 +1,158 @@
+package vroom
+
+import (
+	"fmt"
+	"github.com/jonas747/go-box2d-lite/box2dlite"
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+)
+
+// Background that scrolls slower (or faster) than the camera and can repeat to fill the view
+// The transform position is where the first tile is when the camera is at 0, 0
+type ParallaxLayer struct {
+	BaseComponent
+	Texture       *sdl.Texture
+	Width, Height int // Size of a single tile
+	Layer         int
+
+	// How much the layer moves with the camera per axis, 0 stays fixed on the screen and 1 moves with the world
+	// The camera zoom (the Scale of the render target) is weighted the same way, 0 doesn't zoom at all
+	ScrollFactor box2dlite.Vec2
+
+	RepeatX, RepeatY bool
+
+	Offset   box2dlite.Vec2 // Added to the position
+	Velocity box2dlite.Vec2 // Moves the offset every second, for drifting clouds etc
+}
+
+// Creates a parallax layer repeating horizontally, if w and h is 0 the texture size is used
+func (e *Engine) NewParallaxLayer(w, h int, texture string, factorX, factorY float64, layer int) *ParallaxLayer {
+	tex := e.GetTexture(texture)
+	if tex == nil {
+		fmt.Println("Can't find texture: ", texture)
+		return nil
+	}
+
+	_, _, rw, rh, _ := tex.Query()
+	if w <= 0 {
+		w = rw
+	}
+	if h <= 0 {
+		h = rh
+	}
+
+	return &ParallaxLayer{
+		Texture:      tex,
+		Width:        w,
+		Height:       h,
+		Layer:        layer,
+		ScrollFactor: box2dlite.Vec2{factorX, factorY},
+		RepeatX:      true,
+	}
+}
+
+func (p *ParallaxLayer) Init() {
+	if p.GetComponent("Transform") == nil {
+		transform := &Transform{}
+		p.AddComponent(transform)
+	}
+}
+
+func (p *ParallaxLayer) Name() string {
+	return "ParallaxLayer"
+}
+
+func (p *ParallaxLayer) GetLayer() int {
+	return p.Layer
+}
+
+func (p *ParallaxLayer) DrawTexture() *sdl.Texture {
+	return p.Texture
+}
+
+func (p *ParallaxLayer) Update(dt float64) {
+	if p.Velocity.X == 0 && p.Velocity.Y == 0 {
+		return
+	}
+	p.Offset = p.Offset.Add(p.Velocity.Mul(dt))
+
+	// Keep the offset small when repeating so it doesn't lose precision over time
+	if p.RepeatX && p.Width > 0 {
+		p.Offset.X = math.Mod(p.Offset.X, float64(p.Width))
+	}
+	if p.RepeatY && p.Height > 0 {
+		p.Offset.Y = math.Mod(p.Offset.Y, float64(p.Height))
+	}
+}
+
+// Returns the first tile position and the number of tiles needed to cover size starting at 0
+func parallaxSpan(start, tile, size float64, repeat bool) (float64, int) {
+	if !repeat {
+		return start, 1
+	}
+	start = math.Mod(start, tile)
+	if start > 0 {
+		start -= tile
+	}
+	return start, int(math.Ceil((size - start) / tile))
+}
+
+func (p *ParallaxLayer) Draw(renderer *sdl.Renderer) {
+	if p.Texture == nil {
+		return
+	}
+
+	var position box2dlite.Vec2
+	scale := box2dlite.Vec2{1, 1}
+	transformComp := p.GetComponent("Transform")
+	if transformComp != nil {
+		if transform, ok := transformComp.(*Transform); ok {
+			position = transform.WorldPosition()
+			scale = transform.WorldScale()
+		}
+	}
+
+	// The renderer zooms everything by the view scale around the top left like the world,
+	// so it's divided out again and replaced by a zoom weighted by the scroll factor
+	engine := p.Parent.GetEngine()
+	zoom := engine.ViewScale()
+	zoomX := (1 + (zoom-1)*p.ScrollFactor.X) / zoom
+	zoomY := (1 + (zoom-1)*p.ScrollFactor.Y) / zoom
+
+	tileW := float64(p.Width) * math.Abs(scale.X) * zoomX
+	tileH := float64(p.Height) * math.Abs(scale.Y) * zoomY
+	if tileW*zoom < 1 || tileH*zoom < 1 {
+		return
+	}
+
+	// The view takes the scale of render targets into account, so zoomed in or out views are still covered
+	view := engine.ViewRect(true)
+	camera := engine.Camera
+
+	originX := (position.X + p.Offset.X - camera.X*p.ScrollFactor.X) * zoomX
+	originY := (position.Y + p.Offset.Y - camera.Y*p.ScrollFactor.Y) * zoomY
+
+	startX, countX := parallaxSpan(originX, tileW, view.W, p.RepeatX)
+	startY, countY := parallaxSpan(originY, tileH, view.H, p.RepeatY)
+
+	var flip sdl.RendererFlip = sdl.FLIP_NONE
+	if scale.X < 0 {
+		flip |= sdl.FLIP_HORIZONTAL
+	}
+	if scale.Y < 0 {
+		flip |= sdl.FLIP_VERTICAL
+	}
+
+	for y := 0; y < countY; y++ {
+		for x := 0; x < countX; x++ {
+			// Round the edges instead of the size so neighbouring tiles don't leave gaps
+			left := math.Floor(startX + float64(x)*tileW)
+			top := math.Floor(startY + float64(y)*tileH)
+			right := math.Floor(startX + float64(x+1)*tileW)
+			bottom := math.Floor(startY + float64(y+1)*tileH)
+
+			dst := &sdl.Rect{X: int32(left), Y: int32(top), W: int32(right - left), H: int32(bottom - top)}
+			renderer.CopyEx(p.Texture, nil, dst, 0, nil, flip)
+		}
+	}
+}
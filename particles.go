@@ -0,0 +1,481 @@
+package vroom
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jonas747/go-box2d-lite/box2dlite"
+	"github.com/veandco/go-sdl2/sdl"
+	"io/ioutil"
+	"math"
+	"math/rand"
+)
+
+// Value at a point in a particles lifetime, T goes from 0 (spawned) to 1 (dead)
+type CurveKey struct {
+	T     float64 `json:"t"`
+	Value float64 `json:"value"`
+}
+
+// Linearly interpolated keys sorted by T, a empty curve returns def
+type Curve []CurveKey
+
+func (c Curve) Eval(t, def float64) float64 {
+	if len(c) < 1 {
+		return def
+	}
+	if t <= c[0].T {
+		return c[0].Value
+	}
+	for i := 1; i < len(c); i++ {
+		if t <= c[i].T {
+			prev := c[i-1]
+			f := (t - prev.T) / (c[i].T - prev.T)
+			return prev.Value + (c[i].Value-prev.Value)*f
+		}
+	}
+	return c[len(c)-1].Value
+}
+
+type ColorKey struct {
+	T     float64   `json:"t"`
+	Color sdl.Color `json:"color"`
+}
+
+// Linearly interpolated colors sorted by T
+type Gradient []ColorKey
+
+func (g Gradient) Eval(t float64) sdl.Color {
+	if len(g) < 1 {
+		return sdl.Color{255, 255, 255, 255}
+	}
+	if t <= g[0].T {
+		return g[0].Color
+	}
+	for i := 1; i < len(g); i++ {
+		if t <= g[i].T {
+			a := g[i-1].Color
+			b := g[i].Color
+			f := (t - g[i-1].T) / (g[i].T - g[i-1].T)
+			return sdl.Color{
+				R: lerpByte(a.R, b.R, f),
+				G: lerpByte(a.G, b.G, f),
+				B: lerpByte(a.B, b.B, f),
+				A: lerpByte(a.A, b.A, f),
+			}
+		}
+	}
+	return g[len(g)-1].Color
+}
+
+func lerpByte(a, b uint8, f float64) uint8 {
+	return uint8(float64(a) + (float64(b)-float64(a))*f)
+}
+
+// Random value between Min and Max
+type Range struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+func (r Range) Random() float64 {
+	return r.Min + rand.Float64()*(r.Max-r.Min)
+}
+
+// Emits Count particles at Time seconds into the effect, repeated Cycles times (0 for forever) every Interval seconds
+type Burst struct {
+	Time     float64 `json:"time"`
+	Count    int     `json:"count"`
+	Cycles   int     `json:"cycles"`
+	Interval float64 `json:"interval"`
+}
+
+// Shapes particles are spawned in
+const (
+	EMITPOINT  = "point"
+	EMITCIRCLE = "circle" // Inside Radius
+	EMITRING   = "ring"   // On the edge of Radius
+	EMITRECT   = "rect"   // Inside Width x Height
+	EMITLINE   = "line"   // Along a horizontal line of Width
+)
+
+// Describes how a emitter behaves, can be loaded from json files with Engine.LoadParticleEffect
+type ParticleEffect struct {
+	Texture      string `json:"texture"` // Empty draws squares
+	Additive     bool   `json:"additive"`
+	MaxParticles int    `json:"max_particles"`
+
+	Duration float64 `json:"duration"` // 0 for forever
+	Loop     bool    `json:"loop"`
+	Rate     float64 `json:"rate"` // Particles per second
+	Bursts   []Burst `json:"bursts"`
+
+	Shape  string  `json:"shape"`
+	Radius float64 `json:"radius"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+
+	Lifetime  Range   `json:"lifetime"`
+	Speed     Range   `json:"speed"`
+	Direction float64 `json:"direction"` // Degrees, 0 is right
+	Spread    float64 `json:"spread"`    // Degrees around the direction
+
+	Gravity box2dlite.Vec2 `json:"gravity"` // Pixels per second squared
+	Drag    float64        `json:"drag"`    // Fraction of velocity lost per second
+
+	StartRotation   Range `json:"start_rotation"`   // Degrees
+	AngularVelocity Range `json:"angular_velocity"` // Degrees per second
+	Size            Range `json:"size"`             // Start size in pixels
+
+	// Over the lifetime of each particle
+	Color    Gradient `json:"color"`
+	Alpha    Curve    `json:"alpha"`    // Multiplied with the color alpha
+	Scale    Curve    `json:"scale"`    // Multiplied with the size
+	Rotation Curve    `json:"rotation"` // Degrees added to the rotation
+
+	// In world space particles stay where they were spawned when the emitter moves,
+	// in local space they move with it
+	LocalSpace bool `json:"local_space"`
+}
+
+// Loads a particle effect from a json file, loading it again updates emitters using it
+func (e *Engine) LoadParticleEffect(path, name string) error {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	effect := &ParticleEffect{}
+	err = json.Unmarshal(raw, effect)
+	if err != nil {
+		return err
+	}
+
+	if e.ParticleEffects == nil {
+		e.ParticleEffects = make(map[string]*ParticleEffect)
+		e.particleEffectPaths = make(map[string]string)
+	}
+
+	if existing, ok := e.ParticleEffects[name]; ok {
+		*existing = *effect
+	} else {
+		e.ParticleEffects[name] = effect
+	}
+	e.particleEffectPaths[name] = path
+	return nil
+}
+
+// Loads all the particle effects from their files again
+func (e *Engine) ReloadParticleEffects() error {
+	for name, path := range e.particleEffectPaths {
+		err := e.LoadParticleEffect(path, name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Engine) GetParticleEffect(name string) *ParticleEffect {
+	return e.ParticleEffects[name]
+}
+
+type particle struct {
+	position box2dlite.Vec2
+	velocity box2dlite.Vec2
+	rotation float64
+	angular  float64
+	size     float64
+	life     float64
+	lifetime float64
+}
+
+// Emits and draws particles using a ParticleEffect
+type ParticleEmitter struct {
+	BaseComponent
+	Effect       *ParticleEffect
+	Emitting     bool
+	Layer        int
+	IgnoreCamera bool
+
+	OnFinished func() // Called when the effect stopped emitting and all particles are dead
+
+	particles []particle
+	count     int
+	time      float64
+	emitAcc   float64
+	burstsRun []int
+	finished  bool
+}
+
+// Creates a emitter using a loaded effect
+func (e *Engine) NewParticleEmitter(effect string) *ParticleEmitter {
+	fx := e.GetParticleEffect(effect)
+	if fx == nil {
+		fmt.Println("Can't find particle effect: ", effect)
+		return nil
+	}
+
+	return &ParticleEmitter{
+		Effect:   fx,
+		Emitting: true,
+	}
+}
+
+func (pe *ParticleEmitter) Name() string {
+	return "ParticleEmitter"
+}
+
+func (pe *ParticleEmitter) GetLayer() int {
+	return pe.Layer
+}
+
+func (pe *ParticleEmitter) Init() {
+	if pe.GetComponent("Transform") == nil {
+		transform := &Transform{}
+		pe.AddComponent(transform)
+	}
+}
+
+func (pe *ParticleEmitter) transform() *Transform {
+	transformComp := pe.GetComponent("Transform")
+	if transformComp == nil {
+		return nil
+	}
+	transform, _ := transformComp.(*Transform)
+	return transform
+}
+
+// Number of particles alive
+func (pe *ParticleEmitter) Count() int {
+	return pe.count
+}
+
+// Starts the effect over
+func (pe *ParticleEmitter) Restart() {
+	pe.time = 0
+	pe.emitAcc = 0
+	pe.burstsRun = nil
+	pe.finished = false
+	pe.Emitting = true
+}
+
+// Kills all particles
+func (pe *ParticleEmitter) Clear() {
+	pe.count = 0
+}
+
+// Emits count particles right away
+func (pe *ParticleEmitter) Burst(count int) {
+	transform := pe.transform()
+	if transform == nil || pe.Effect == nil {
+		return
+	}
+	world := transform.WorldMatrix()
+	for i := 0; i < count; i++ {
+		pe.spawn(world)
+	}
+}
+
+func (pe *ParticleEmitter) spawn(world Matrix) {
+	fx := pe.Effect
+
+	max := fx.MaxParticles
+	if max <= 0 {
+		max = 500
+	}
+	if len(pe.particles) != max {
+		resized := make([]particle, max)
+		copy(resized, pe.particles)
+		pe.particles = resized
+		if pe.count > max {
+			pe.count = max
+		}
+	}
+	if pe.count >= max {
+		return
+	}
+
+	var offset box2dlite.Vec2
+	switch fx.Shape {
+	case EMITCIRCLE:
+		a := rand.Float64() * math.Pi * 2
+		r := fx.Radius * math.Sqrt(rand.Float64())
+		offset = box2dlite.Vec2{math.Cos(a) * r, math.Sin(a) * r}
+	case EMITRING:
+		a := rand.Float64() * math.Pi * 2
+		offset = box2dlite.Vec2{math.Cos(a) * fx.Radius, math.Sin(a) * fx.Radius}
+	case EMITRECT:
+		offset = box2dlite.Vec2{(rand.Float64() - 0.5) * fx.Width, (rand.Float64() - 0.5) * fx.Height}
+	case EMITLINE:
+		offset = box2dlite.Vec2{(rand.Float64() - 0.5) * fx.Width, 0}
+	}
+
+	dir := DegreesToRadians(fx.Direction + (rand.Float64()-0.5)*fx.Spread)
+	speed := fx.Speed.Random()
+	velocity := box2dlite.Vec2{math.Cos(dir) * speed, math.Sin(dir) * speed}
+	rotation := fx.StartRotation.Random()
+
+	if !fx.LocalSpace {
+		offset = world.Apply(offset)
+		velocity = world.ApplyVector(velocity)
+		rotation += world.Angle()
+	}
+
+	lifetime := fx.Lifetime.Random()
+	if lifetime <= 0 {
+		lifetime = 1
+	}
+
+	pe.particles[pe.count] = particle{
+		position: offset,
+		velocity: velocity,
+		rotation: rotation,
+		angular:  fx.AngularVelocity.Random(),
+		size:     fx.Size.Random(),
+		lifetime: lifetime,
+	}
+	pe.count++
+}
+
+func (pe *ParticleEmitter) Update(dt float64) {
+	fx := pe.Effect
+	transform := pe.transform()
+	if fx == nil || transform == nil {
+		return
+	}
+
+	if pe.Emitting {
+		pe.emit(dt, transform.WorldMatrix())
+	}
+
+	// Simulate, dead particles are swapped with the last alive one
+	drag := math.Max(0, 1-fx.Drag*dt)
+	for i := 0; i < pe.count; i++ {
+		p := &pe.particles[i]
+		p.life += dt
+		if p.life >= p.lifetime {
+			pe.count--
+			pe.particles[i] = pe.particles[pe.count]
+			i--
+			continue
+		}
+
+		p.velocity = p.velocity.Add(fx.Gravity.Mul(dt)).Mul(drag)
+		p.position = p.position.Add(p.velocity.Mul(dt))
+		p.rotation += p.angular * dt
+	}
+
+	if !pe.Emitting && pe.count == 0 && !pe.finished {
+		pe.finished = true
+		if pe.OnFinished != nil {
+			pe.OnFinished()
+		}
+	}
+}
+
+func (pe *ParticleEmitter) emit(dt float64, world Matrix) {
+	fx := pe.Effect
+	prevTime := pe.time
+	pe.time += dt
+
+	if len(pe.burstsRun) != len(fx.Bursts) {
+		pe.burstsRun = make([]int, len(fx.Bursts))
+	}
+	for k, burst := range fx.Bursts {
+		if burst.Cycles > 0 && pe.burstsRun[k] >= burst.Cycles {
+			continue
+		}
+		next := burst.Time + float64(pe.burstsRun[k])*burst.Interval
+		if prevTime <= next && pe.time > next {
+			for i := 0; i < burst.Count; i++ {
+				pe.spawn(world)
+			}
+			pe.burstsRun[k]++
+		}
+	}
+
+	pe.emitAcc += fx.Rate * dt
+	for pe.emitAcc >= 1 {
+		pe.emitAcc--
+		pe.spawn(world)
+	}
+
+	if fx.Duration > 0 && pe.time >= fx.Duration {
+		if fx.Loop {
+			pe.time = 0
+			pe.burstsRun = nil
+		} else {
+			pe.Emitting = false
+		}
+	}
+}
+
+func (pe *ParticleEmitter) Draw(renderer *sdl.Renderer) {
+	fx := pe.Effect
+	transform := pe.transform()
+	if fx == nil || transform == nil || pe.count == 0 {
+		return
+	}
+
+	engine := pe.Parent.GetEngine()
+	var texture *sdl.Texture
+	if fx.Texture != "" {
+		texture = engine.GetTexture(fx.Texture)
+	}
+
+	camera := box2dlite.Vec2{}
+	if !pe.IgnoreCamera {
+		camera = engine.Camera
+	}
+
+	world := transform.WorldMatrix()
+	worldAngle := world.Angle()
+
+	blend := sdl.BLENDMODE_BLEND
+	if fx.Additive {
+		blend = sdl.BLENDMODE_ADD
+	}
+	if texture != nil {
+		texture.SetBlendMode(blend)
+	} else {
+		renderer.SetDrawBlendMode(blend)
+	}
+
+	for i := 0; i < pe.count; i++ {
+		p := &pe.particles[i]
+		t := p.life / p.lifetime
+
+		pos := p.position
+		rotation := p.rotation + fx.Rotation.Eval(t, 0)
+		if fx.LocalSpace {
+			pos = world.Apply(pos)
+			rotation += worldAngle
+		}
+		pos = pos.Sub(camera)
+
+		size := p.size * fx.Scale.Eval(t, 1)
+		if size <= 0 {
+			continue
+		}
+
+		color := fx.Color.Eval(t)
+		alpha := float64(color.A) * fx.Alpha.Eval(t, 1)
+		color.A = uint8(math.Max(0, math.Min(255, alpha)))
+
+		dst := &sdl.Rect{X: int32(pos.X - size/2), Y: int32(pos.Y - size/2), W: int32(math.Ceil(size)), H: int32(math.Ceil(size))}
+		if texture != nil {
+			texture.SetColorMod(color.R, color.G, color.B)
+			texture.SetAlphaMod(color.A)
+			renderer.CopyEx(texture, nil, dst, rotation, nil, sdl.FLIP_NONE)
+		} else {
+			renderer.SetDrawColor(color.R, color.G, color.B, color.A)
+			renderer.FillRect(dst)
+		}
+	}
+
+	if texture != nil {
+		texture.SetColorMod(255, 255, 255)
+		texture.SetAlphaMod(255)
+		texture.SetBlendMode(sdl.BLENDMODE_BLEND)
+	}
+}
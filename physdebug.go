@@ -0,0 +1,147 @@
+package vroom
+
+import (
+	"github.com/jonas747/go-box2d-lite/box2dlite"
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+)
+
+// Overlay that draws everything in Engine.World, drawn on top of everything else when enabled
+// box2d-lite has no real sleeping, bodies moving slower than RestingVelocity are shown as sleeping instead
+type PhysicsDebugDraw struct {
+	Enabled   bool
+	ToggleKey sdl.Keycode // Toggles Enabled when pressed, 0 to disable
+
+	DrawBodies   bool
+	DrawJoints   bool
+	DrawContacts bool
+
+	RestingVelocity float64 // In physics units per second
+	NormalLength    float64 // Length of contact normals in pixels
+
+	StaticColor   sdl.Color
+	AwakeColor    sdl.Color
+	SleepingColor sdl.Color
+	JointColor    sdl.Color
+	ContactColor  sdl.Color
+	NormalColor   sdl.Color
+
+	engine *Engine
+}
+
+func NewPhysicsDebugDraw(e *Engine) *PhysicsDebugDraw {
+	return &PhysicsDebugDraw{
+		DrawBodies:      true,
+		DrawJoints:      true,
+		DrawContacts:    true,
+		RestingVelocity: 0.05,
+		NormalLength:    10,
+		StaticColor:     sdl.Color{120, 120, 120, 255},
+		AwakeColor:      sdl.Color{80, 220, 80, 255},
+		SleepingColor:   sdl.Color{80, 120, 220, 255},
+		JointColor:      sdl.Color{200, 200, 80, 255},
+		ContactColor:    sdl.Color{230, 60, 60, 255},
+		NormalColor:     sdl.Color{230, 160, 60, 255},
+		engine:          e,
+	}
+}
+
+func (pd *PhysicsDebugDraw) Toggle() {
+	pd.Enabled = !pd.Enabled
+}
+
+// Converts a position in the physics world to screen space
+func (pd *PhysicsDebugDraw) toScreen(v box2dlite.Vec2) box2dlite.Vec2 {
+	return v.Mul(pd.engine.PhysicsScale).Sub(pd.engine.Camera)
+}
+
+func (pd *PhysicsDebugDraw) Draw(renderer *sdl.Renderer) {
+	world := pd.engine.World
+	if !pd.Enabled || world == nil {
+		return
+	}
+
+	if pd.DrawBodies {
+		for _, body := range world.Bodies {
+			pd.drawBody(renderer, body)
+		}
+	}
+
+	if pd.DrawJoints {
+		for _, joint := range world.Joints {
+			pd.drawJoint(renderer, joint)
+		}
+	}
+
+	if pd.DrawContacts {
+		for _, arbiter := range world.Arbiters {
+			for i := 0; i < arbiter.NumContacts; i++ {
+				pd.drawContact(renderer, arbiter.Contacts[i].Position, arbiter.Contacts[i].Normal)
+			}
+		}
+	}
+}
+
+func (pd *PhysicsDebugDraw) bodyColor(body *box2dlite.Body) sdl.Color {
+	if body.InvMass == 0 {
+		return pd.StaticColor
+	}
+
+	if body.Velocity.Length() < pd.RestingVelocity && math.Abs(body.AngularVelocity) < pd.RestingVelocity {
+		return pd.SleepingColor
+	}
+	return pd.AwakeColor
+}
+
+func (pd *PhysicsDebugDraw) drawBody(renderer *sdl.Renderer, body *box2dlite.Body) {
+	rot := box2dlite.Mat22ByAngle(body.Rotation)
+	h := box2dlite.MulSV(0.5, body.Width)
+
+	corners := []box2dlite.Vec2{
+		{-h.X, -h.Y},
+		{h.X, -h.Y},
+		{h.X, h.Y},
+		{-h.X, h.Y},
+	}
+	for k, c := range corners {
+		corners[k] = pd.toScreen(body.Position.Add(rot.MulV(c)))
+	}
+
+	setDrawColor(renderer, pd.bodyColor(body))
+	DrawPolyline(renderer, corners, true, 1)
+
+	// Line from the center to the right edge to show the rotation
+	center := pd.toScreen(body.Position)
+	edge := pd.toScreen(body.Position.Add(rot.MulV(box2dlite.Vec2{h.X, 0})))
+	DrawLine(renderer, center, edge, 1)
+}
+
+func (pd *PhysicsDebugDraw) drawJoint(renderer *sdl.Renderer, joint *box2dlite.Joint) {
+	b1 := joint.Body1
+	b2 := joint.Body2
+	if b1 == nil || b2 == nil {
+		return
+	}
+
+	r1 := box2dlite.Mat22ByAngle(b1.Rotation)
+	r2 := box2dlite.Mat22ByAngle(b2.Rotation)
+
+	x1 := b1.Position
+	p1 := x1.Add(r1.MulV(joint.LocalAnchor1))
+	x2 := b2.Position
+	p2 := x2.Add(r2.MulV(joint.LocalAnchor2))
+
+	setDrawColor(renderer, pd.JointColor)
+	DrawLine(renderer, pd.toScreen(x1), pd.toScreen(p1), 1)
+	DrawLine(renderer, pd.toScreen(x2), pd.toScreen(p2), 1)
+}
+
+func (pd *PhysicsDebugDraw) drawContact(renderer *sdl.Renderer, position, normal box2dlite.Vec2) {
+	p := pd.toScreen(position)
+
+	setDrawColor(renderer, pd.ContactColor)
+	renderer.FillRect(&sdl.Rect{X: int32(p.X) - 2, Y: int32(p.Y) - 2, W: 5, H: 5})
+
+	setDrawColor(renderer, pd.NormalColor)
+	DrawLine(renderer, p, p.Add(normal.Mul(pd.NormalLength)), 1)
+}
@@ -0,0 +1,533 @@
+package vroom
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+	"sort"
+)
+
+// Seconds the mouse has to rest on a widget before its tooltip shows, the default for UISystem.TooltipDelay
+const TOOLTIPDELAY = 0.6
+
+// Tooltips are wrapped at this width
+const TOOLTIPWIDTH = 300
+
+// Tooltips are placed this far from the mouse so the cursor doesn't cover them
+const (
+	TOOLTIPOFFSETX = 12
+	TOOLTIPOFFSETY = 16
+)
+
+// Entry in a context menu, separators are drawn as a line and can't be picked
+type MenuItem struct {
+	Text      string
+	Action    func()
+	Disabled  bool
+	Separator bool
+}
+
+type tooltip struct {
+	owner Component // nil for tooltips shown with ShowTooltip
+	text  string
+	timer float64
+	shown bool
+	x, y  int
+}
+
+type contextMenu struct {
+	items   []MenuItem
+	rows    []Rect
+	rect    Rect
+	hovered int
+
+	// The release of the click that opened it doesn't pick anything unless the mouse moved
+	armed        bool
+	openX, openY int
+}
+
+type modal struct {
+	root      Entity
+	drawables []DrawAble
+}
+
+// Style for the popups that aren't widgets, by name from the theme ("Tooltip", "ContextMenu")
+func (ui *UISystem) namedStyle(name string) *WidgetStyle {
+	if style := ui.ThemeStyle(name); style != nil {
+		return style
+	}
+	if ui.DefaultStyle != nil {
+		return ui.DefaultStyle
+	}
+	return DefaultWidgetStyle()
+}
+
+func fontLineHeight(font *GlyphFont) int {
+	if font == nil {
+		return 16
+	}
+	return font.LineHeight
+}
+
+// Puts a w*h popup next to x, y. It's flipped to the other side of the point where it
+// would go off the screen, and pushed back inside if it still doesn't fit
+func placePopup(x, y, w, h, offsetX, offsetY float64, screen Rect) Rect {
+	rect := Rect{x + offsetX, y + offsetY, w, h}
+	if rect.Right() > screen.Right() {
+		rect.X = x - offsetX - w
+	}
+	if rect.Bottom() > screen.Bottom() {
+		rect.Y = y - offsetY - h
+	}
+	rect.X = math.Floor(math.Max(screen.X, math.Min(rect.X, screen.Right()-w)))
+	rect.Y = math.Floor(math.Max(screen.Y, math.Min(rect.Y, screen.Bottom()-h)))
+	return rect
+}
+
+// Called every frame from Engine.Update
+func (ui *UISystem) update(dt float64) {
+	ui.checkReload(dt)
+
+	for k := range ui.modals {
+		ui.collectModal(&ui.modals[k])
+	}
+	for _, entity := range ui.destroyed {
+		ui.engine.DestroyEntity(entity)
+	}
+	ui.destroyed = nil
+
+	tip := &ui.tooltip
+	if tip.owner != nil && !tip.shown {
+		tip.timer += dt
+		if tip.timer >= ui.TooltipDelay {
+			tip.shown = true
+			tip.x, tip.y = ui.mouseX, ui.mouseY
+		}
+	}
+}
+
+// Shows text next to the mouse right away, until HideTooltip or the next click
+func (ui *UISystem) ShowTooltip(text string) {
+	ui.tooltip = tooltip{text: text, shown: true, x: ui.mouseX, y: ui.mouseY}
+}
+
+func (ui *UISystem) HideTooltip() {
+	ui.tooltip = tooltip{}
+}
+
+// Starts the timer for the tooltip of owner, widgets call this when the mouse enters them
+func (ui *UISystem) hoverTooltip(owner Component, text string) {
+	if ui.menu != nil {
+		return
+	}
+	ui.tooltip = tooltip{owner: owner, text: text}
+}
+
+func (ui *UISystem) leaveTooltip(owner Component) {
+	if ui.tooltip.owner != nil && ui.tooltip.owner == owner {
+		ui.HideTooltip()
+	}
+}
+
+func (ui *UISystem) drawTooltip(renderer *sdl.Renderer) {
+	tip := ui.tooltip
+	if !tip.shown || tip.text == "" {
+		return
+	}
+	if tip.owner != nil && (!tip.owner.Enabled() || tip.owner.GetParent() == nil || !tip.owner.GetParent().Enabled()) {
+		return
+	}
+
+	style := ui.namedStyle("Tooltip")
+	font := ui.engine.GetGlyphFont(style.Font)
+	lineHeight := fontLineHeight(font)
+	var lines []TextLine
+	widest := 0
+	if font != nil {
+		lines = wrapLines(font.Measure, tip.text, TOOLTIPWIDTH)
+		for _, line := range lines {
+			widest = int(math.Max(float64(widest), float64(font.Measure(line.Text))))
+		}
+	}
+
+	w := float64(widest + style.Padding.Horizontal())
+	h := float64(len(lines)*lineHeight + style.Padding.Vertical())
+	rect := placePopup(float64(tip.x), float64(tip.y), w, h, TOOLTIPOFFSETX, TOOLTIPOFFSETY, ui.engine.ViewRect(true))
+
+	drawStyleFrame(renderer, ui.engine, style, rect, WIDGETIDLE)
+	for k, line := range lines {
+		lineRect := Rect{rect.X + float64(style.Padding.Left), rect.Y + float64(style.Padding.Top+k*lineHeight), float64(widest), float64(lineHeight)}
+		drawStyleText(renderer, font, style, line.Text, lineRect, ALIGNLEFT, style.States[WIDGETIDLE].TextColor)
+	}
+}
+
+// Opens a menu with the items at x, y, moved to stay on the screen. Picking an item or
+// clicking anywhere else closes it, only one menu is open at a time
+func (ui *UISystem) ShowContextMenu(x, y int, items []MenuItem) {
+	if len(items) < 1 {
+		return
+	}
+	ui.HideTooltip()
+
+	style := ui.namedStyle("ContextMenu")
+	font := ui.engine.GetGlyphFont(style.Font)
+	rowHeight := float64(fontLineHeight(font) + style.Padding.Vertical())
+	separatorHeight := float64(style.Padding.Vertical() + 1)
+
+	widest, height := 0, 0.0
+	for _, item := range items {
+		if item.Separator {
+			height += separatorHeight
+			continue
+		}
+		if font != nil {
+			widest = int(math.Max(float64(widest), float64(font.Measure(item.Text))))
+		}
+		height += rowHeight
+	}
+
+	w := float64(widest + style.Padding.Horizontal()*2)
+	rect := placePopup(float64(x), float64(y), w, height, 0, 0, ui.engine.ViewRect(true))
+
+	menu := &contextMenu{items: items, rect: rect, hovered: -1, openX: x, openY: y}
+	rowY := rect.Y
+	for _, item := range items {
+		h := rowHeight
+		if item.Separator {
+			h = separatorHeight
+		}
+		menu.rows = append(menu.rows, Rect{rect.X, rowY, rect.W, h})
+		rowY += h
+	}
+	ui.menu = menu
+}
+
+func (ui *UISystem) CloseContextMenu() {
+	ui.menu = nil
+}
+
+func (ui *UISystem) ContextMenuOpen() bool {
+	return ui.menu != nil
+}
+
+func (m *contextMenu) selectable(index int) bool {
+	return index >= 0 && index < len(m.items) && !m.items[index].Separator && !m.items[index].Disabled
+}
+
+func (m *contextMenu) itemAt(x, y int) int {
+	for k, row := range m.rows {
+		if row.Contains(float64(x), float64(y)) && m.selectable(k) {
+			return k
+		}
+	}
+	return -1
+}
+
+// Moves the highlight to the next item that can be picked, wrapping around
+func (m *contextMenu) step(dir int) {
+	n := len(m.items)
+	start := m.hovered
+	if start < 0 && dir < 0 {
+		start = n
+	}
+	for i := 1; i <= n; i++ {
+		k := ((start+dir*i)%n + n) % n
+		if m.selectable(k) {
+			m.hovered = k
+			return
+		}
+	}
+}
+
+func (ui *UISystem) pickMenuItem(index int) {
+	menu := ui.menu
+	if menu == nil || !menu.selectable(index) {
+		return
+	}
+	ui.CloseContextMenu()
+	if sound := ui.namedStyle("ContextMenu").ClickSound; sound != "" {
+		ui.engine.PlaySound(sound)
+	}
+	if action := menu.items[index].Action; action != nil {
+		action()
+	}
+}
+
+// The open menu gets all the mouse events
+func (ui *UISystem) menuMouseMove(x, y int) {
+	menu := ui.menu
+	menu.hovered = menu.itemAt(x, y)
+	if math.Abs(float64(x-menu.openX))+math.Abs(float64(y-menu.openY)) > 3 {
+		menu.armed = true
+	}
+}
+
+// Clicking outside closes the menu without the click going anywhere else
+func (ui *UISystem) menuMouseButton(x, y, button int, up bool) {
+	menu := ui.menu
+	if !up {
+		if !menu.rect.Contains(float64(x), float64(y)) {
+			ui.CloseContextMenu()
+			return
+		}
+		menu.armed = true
+		return
+	}
+	if menu.armed {
+		ui.pickMenuItem(menu.itemAt(x, y))
+	}
+}
+
+// Up and down move the highlight, enter picks and escape closes, other keys are swallowed
+func (ui *UISystem) menuKey(key sdl.Keycode) {
+	switch key {
+	case sdl.K_UP:
+		ui.menu.step(-1)
+	case sdl.K_DOWN:
+		ui.menu.step(1)
+	case sdl.K_RETURN, sdl.K_KP_ENTER, sdl.K_SPACE:
+		ui.pickMenuItem(ui.menu.hovered)
+	case sdl.K_ESCAPE:
+		ui.CloseContextMenu()
+	}
+}
+
+func (ui *UISystem) menuButton(button sdl.GameControllerButton) {
+	switch button {
+	case sdl.CONTROLLER_BUTTON_DPAD_UP:
+		ui.menu.step(-1)
+	case sdl.CONTROLLER_BUTTON_DPAD_DOWN:
+		ui.menu.step(1)
+	case sdl.CONTROLLER_BUTTON_A:
+		ui.pickMenuItem(ui.menu.hovered)
+	case sdl.CONTROLLER_BUTTON_B:
+		ui.CloseContextMenu()
+	}
+}
+
+func (ui *UISystem) drawContextMenu(renderer *sdl.Renderer) {
+	menu := ui.menu
+	if menu == nil {
+		return
+	}
+	style := ui.namedStyle("ContextMenu")
+	font := ui.engine.GetGlyphFont(style.Font)
+
+	drawStyleFrame(renderer, ui.engine, style, menu.rect, WIDGETIDLE)
+	for k, item := range menu.items {
+		row := menu.rows[k]
+		if item.Separator {
+			fillRect(renderer, Rect{row.X + float64(style.Padding.Left), math.Floor(row.Y + row.H/2), row.W - float64(style.Padding.Horizontal()), 1}, style.States[WIDGETIDLE].Border)
+			continue
+		}
+		state := WIDGETIDLE
+		if item.Disabled {
+			state = WIDGETDISABLED
+		} else if k == menu.hovered {
+			state = WIDGETHOVER
+			fillRect(renderer, row.Grow(-1), style.States[WIDGETHOVER].Fill)
+		}
+		textRect := Rect{row.X + float64(style.Padding.Horizontal()), row.Y, row.W - float64(style.Padding.Horizontal()*2), row.H}
+		drawStyleText(renderer, font, style, item.Text, textRect, ALIGNLEFT, style.States[state].TextColor)
+	}
+}
+
+// Makes root modal, it's drawn on top of everything over ModalShade and everything outside it
+// stops getting mouse and key downs until CloseModal. The focus is kept inside it like with
+// PushFocusScope, onCancel can be nil. root is added to the engine if it isn't already
+func (ui *UISystem) ShowModal(root Entity, onCancel func()) {
+	if ui.IsModal(root) {
+		return
+	}
+	ui.CloseContextMenu()
+	ui.HideTooltip()
+	if !root.Added() {
+		ui.engine.AddEntity(root)
+	}
+
+	ui.modals = append(ui.modals, modal{root: root})
+	ui.collectModal(&ui.modals[len(ui.modals)-1])
+
+	if ui.capture != nil && ui.Blocked(ui.capture) {
+		ui.capture = nil
+	}
+	ui.PushFocusScope(root, onCancel)
+
+	// Whatever is hovered behind it gets a MouseLeave
+	ui.engine.MouseHoverSystem.MouseMove(ui.mouseX, ui.mouseY)
+}
+
+// Closes a modal opened with ShowModal, root is drawn by the draw system again
+// The focus goes back to where it was before it was opened
+func (ui *UISystem) CloseModal(root Entity) {
+	for k := len(ui.modals) - 1; k >= 0; k-- {
+		if ui.modals[k].root != root {
+			continue
+		}
+		drawables := ui.modals[k].drawables
+		ui.modals = append(ui.modals[:k], ui.modals[k+1:]...)
+
+		if root.Added() {
+			for _, drawable := range drawables {
+				ui.engine.DrawSystem.AddComponent(drawable)
+			}
+		}
+		ui.PopFocusScope(root)
+		ui.engine.MouseHoverSystem.MouseMove(ui.mouseX, ui.mouseY)
+		return
+	}
+}
+
+// True while any modal is open
+func (ui *UISystem) ModalOpen() bool {
+	return len(ui.modals) > 0
+}
+
+func (ui *UISystem) IsModal(root Entity) bool {
+	for _, m := range ui.modals {
+		if m.root == root {
+			return true
+		}
+	}
+	return false
+}
+
+// True while a modal is open and the component is outside the one on top, the mouse
+// and keyboard systems skip blocked components
+func (ui *UISystem) Blocked(component Component) bool {
+	if len(ui.modals) < 1 {
+		return false
+	}
+	return !inEntity(component, ui.modals[len(ui.modals)-1].root)
+}
+
+// Takes the drawables of the modal out of the draw system so they can be drawn over the shade,
+// done every frame to pick up children added while it's open
+func (ui *UISystem) collectModal(m *modal) {
+	m.drawables = m.drawables[:0]
+	entities := append([]Entity{m.root}, m.root.GetChildren(true)...)
+	for _, entity := range entities {
+		for _, compSlice := range entity.GetComponents() {
+			for _, comp := range compSlice {
+				if drawable, ok := comp.(DrawAble); ok {
+					m.drawables = append(m.drawables, drawable)
+					ui.engine.DrawSystem.RemoveComponent(drawable)
+				}
+			}
+		}
+	}
+	sort.Stable(byLayer(m.drawables))
+}
+
+type byLayer []DrawAble
+
+func (b byLayer) Len() int           { return len(b) }
+func (b byLayer) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byLayer) Less(i, j int) bool { return b[i].GetLayer() < b[j].GetLayer() }
+
+func (ui *UISystem) drawModals(renderer *sdl.Renderer) {
+	for _, m := range ui.modals {
+		if !m.root.Enabled() {
+			continue
+		}
+		fillRect(renderer, ui.engine.ViewRect(true), ui.ModalShade)
+		for _, drawable := range m.drawables {
+			if drawable.Enabled() && drawable.GetParent() != nil && drawable.GetParent().Enabled() {
+				drawable.Draw(renderer)
+			}
+		}
+	}
+}
+
+// Modal window with a message and a row of buttons, see UISystem.ShowDialog
+type Dialog struct {
+	Root    Entity
+	Window  *Window
+	Message *TextBlock
+	Buttons []*Button
+
+	OnResult    func(index int)
+	CancelIndex int // Result when it's cancelled with escape or B, -1 unless changed
+
+	ui     *UISystem
+	closed bool
+}
+
+// Closes the dialog with the result, like clicking the button at index
+func (d *Dialog) Close(index int) {
+	if d.closed {
+		return
+	}
+	d.closed = true
+	d.ui.CloseModal(d.Root)
+
+	// Destroyed next update since this is usually called from a click
+	SetEntityEnabled(d.Root, false)
+	d.ui.destroyed = append(d.ui.destroyed, d.Root)
+
+	if d.OnResult != nil {
+		d.OnResult(index)
+	}
+}
+
+// Widths for the dialogs from ShowDialog
+const (
+	DIALOGMINWIDTH  = 240
+	DIALOGTEXTWIDTH = 360
+	DIALOGBUTTONMIN = 80
+)
+
+// Opens a modal window in the middle of the screen with the message and a button for each of
+// the texts, onResult gets the index of the button clicked or -1 if it was cancelled
+// The window uses the "Dialog" style of the theme if it has one
+func (ui *UISystem) ShowDialog(title, message string, buttons []string, onResult func(index int)) *Dialog {
+	element := NewAutoUIElement(LAYOUTVERTICAL, 8)
+	element.SetAnchor(0.5, 0.5)
+	element.Padding = UniformInsets(8)
+	element.MinWidth = DIALOGMINWIDTH
+
+	dialog := &Dialog{
+		Window:      NewWindow(title, false),
+		Message:     NewTextBlock(message, DIALOGTEXTWIDTH),
+		OnResult:    onResult,
+		CancelIndex: -1,
+		ui:          ui,
+	}
+	dialog.Window.StyleName = "Dialog"
+	dialog.Root = NewWidgetEntity(dialog.Window, element)
+	dialog.Root.AddChild(NewWidgetEntity(dialog.Message, nil), false)
+
+	rowElement := NewAutoUIElement(LAYOUTHORIZONTAL, 6)
+	rowElement.SetAnchor(1, 0)
+	row := NewEntity(0, 0)
+	row.AddComponent(rowElement)
+	for k, text := range buttons {
+		index := k
+		button := NewButton(text, func() { dialog.Close(index) })
+		buttonElement := NewAutoUIElement(LAYOUTNONE, 0)
+		buttonElement.MinWidth = DIALOGBUTTONMIN
+		row.AddChild(NewWidgetEntity(button, buttonElement), false)
+		dialog.Buttons = append(dialog.Buttons, button)
+	}
+	dialog.Root.AddChild(row, false)
+
+	ui.ShowModal(dialog.Root, func() { dialog.Close(dialog.CancelIndex) })
+	return dialog
+}
+
+// Asks a yes or no question with OKText and CancelText, cancelling counts as no
+func (ui *UISystem) Confirm(title, message string, onResult func(ok bool)) *Dialog {
+	return ui.ShowDialog(title, message, []string{ui.OKText, ui.CancelText}, func(index int) {
+		if onResult != nil {
+			onResult(index == 0)
+		}
+	})
+}
+
+// Shows a message with an OKText button, onClose can be nil
+func (ui *UISystem) Alert(title, message string, onClose func()) *Dialog {
+	return ui.ShowDialog(title, message, []string{ui.OKText}, func(index int) {
+		if onClose != nil {
+			onClose()
+		}
+	})
+}
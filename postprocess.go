@@ -0,0 +1,239 @@
+package vroom
+
+import (
+	"fmt"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// A single step in the post processing chain
+// Apply draws src onto the current render target which is w by h pixels large,
+// this is either a buffer for the next pass or the screen for the last pass
+type PostProcessPass interface {
+	Apply(renderer *sdl.Renderer, src *sdl.Texture, w, h int)
+}
+
+// So you can use a plain function as a pass
+type PostProcessFunc func(renderer *sdl.Renderer, src *sdl.Texture, w, h int)
+
+func (f PostProcessFunc) Apply(renderer *sdl.Renderer, src *sdl.Texture, w, h int) {
+	f(renderer, src, w, h)
+}
+
+// When the chain has passes the world is drawn into a offscreen texture first,
+// then each pass is applied in order before the result ends up on the screen
+type PostProcessChain struct {
+	Passes []PostProcessPass
+
+	// Size the scene is drawn at, 0 uses the size of the screen
+	// Use a small size with a PixelScalePass for pixel art
+	Width, Height int
+
+	engine  *Engine
+	scene   *RenderTarget
+	buffers [2]*RenderTarget
+}
+
+func NewPostProcessChain(e *Engine) *PostProcessChain {
+	return &PostProcessChain{
+		engine: e,
+	}
+}
+
+func (pc *PostProcessChain) AddPass(pass PostProcessPass) {
+	pc.Passes = append(pc.Passes, pass)
+}
+
+func (pc *PostProcessChain) RemovePass(pass PostProcessPass) {
+	for k, v := range pc.Passes {
+		if v == pass {
+			pc.Passes = append(pc.Passes[:k], pc.Passes[k+1:]...)
+			return
+		}
+	}
+}
+
+func (pc *PostProcessChain) Active() bool {
+	return len(pc.Passes) > 0
+}
+
+// Makes sure target exists and has the right size
+func (pc *PostProcessChain) ensureTarget(target *RenderTarget, w, h int) *RenderTarget {
+	if target != nil && target.Width == w && target.Height == h {
+		return target
+	}
+	if target != nil {
+		target.Destroy()
+	}
+
+	target, err := pc.engine.NewRenderTarget(w, h)
+	if err != nil {
+		fmt.Println("Failed creating post process target: ", err)
+		return nil
+	}
+	return target
+}
+
+// Starts drawing the scene into the offscreen texture, returns false if that failed
+func (pc *PostProcessChain) Begin(renderer *sdl.Renderer) bool {
+	outW, outH := pc.engine.Display.ScreenSize()
+
+	w, h := pc.Width, pc.Height
+	if w <= 0 || h <= 0 {
+		w, h = outW, outH
+	}
+
+	pc.scene = pc.ensureTarget(pc.scene, w, h)
+	if pc.scene == nil {
+		return false
+	}
+
+	clear := pc.engine.ClearColor
+	pc.scene.ClearColor = sdl.Color{clear.R, clear.G, clear.B, 255}
+	pc.scene.Begin(renderer)
+	return true
+}
+
+// Runs all the passes, the last one draws onto the screen (or the logical screen if set)
+func (pc *PostProcessChain) End(renderer *sdl.Renderer) {
+	pc.scene.End(renderer)
+
+	outW, outH := pc.engine.Display.ScreenSize()
+
+	src := pc.scene
+	for k, pass := range pc.Passes {
+		if k == len(pc.Passes)-1 {
+			renderer.SetRenderTarget(pc.engine.Display.screenTexture())
+			renderer.SetDrawBlendMode(sdl.BLENDMODE_NONE)
+			renderer.SetDrawColor(0, 0, 0, 255)
+			renderer.Clear()
+			pass.Apply(renderer, src.Texture, outW, outH)
+			break
+		}
+
+		index := k % 2
+		pc.buffers[index] = pc.ensureTarget(pc.buffers[index], outW, outH)
+		dst := pc.buffers[index]
+		if dst == nil {
+			return
+		}
+
+		dst.Begin(renderer)
+		pass.Apply(renderer, src.Texture, outW, outH)
+		dst.End(renderer)
+		src = dst
+	}
+}
+
+func (pc *PostProcessChain) Destroy() {
+	if pc.scene != nil {
+		pc.scene.Destroy()
+	}
+	for _, v := range pc.buffers {
+		if v != nil {
+			v.Destroy()
+		}
+	}
+}
+
+// Scales the source up to fill the target while keeping the aspect ratio, the rest is left black
+// With Integer only whole scale factors are used so pixel art stays crisp
+type PixelScalePass struct {
+	Integer bool
+}
+
+func (p *PixelScalePass) Apply(renderer *sdl.Renderer, src *sdl.Texture, w, h int) {
+	_, _, srcW, srcH, err := src.Query()
+	if err != nil || srcW == 0 || srcH == 0 {
+		return
+	}
+
+	scale := float64(w) / float64(srcW)
+	if scaleY := float64(h) / float64(srcH); scaleY < scale {
+		scale = scaleY
+	}
+	if p.Integer && scale >= 1 {
+		scale = float64(int(scale))
+	}
+
+	dstW := int32(float64(srcW) * scale)
+	dstH := int32(float64(srcH) * scale)
+	dst := &sdl.Rect{X: (int32(w) - dstW) / 2, Y: (int32(h) - dstH) / 2, W: dstW, H: dstH}
+	renderer.Copy(src, nil, dst)
+}
+
+// Darkens every Spacing'th row for a old CRT look
+type ScanlinePass struct {
+	Spacing int
+	Color   sdl.Color
+}
+
+func (s *ScanlinePass) Apply(renderer *sdl.Renderer, src *sdl.Texture, w, h int) {
+	renderer.Copy(src, nil, nil)
+
+	spacing := s.Spacing
+	if spacing < 2 {
+		spacing = 2
+	}
+
+	setDrawColor(renderer, s.Color)
+	for y := 0; y < h; y += spacing {
+		renderer.DrawLine(0, y, w, y)
+	}
+}
+
+// Blends a color over everything, use the alpha to control the strength (fades etc)
+type TintPass struct {
+	Color sdl.Color
+}
+
+func (t *TintPass) Apply(renderer *sdl.Renderer, src *sdl.Texture, w, h int) {
+	renderer.Copy(src, nil, nil)
+	if t.Color.A == 0 {
+		return
+	}
+	setDrawColor(renderer, t.Color)
+	renderer.FillRect(&sdl.Rect{W: int32(w), H: int32(h)})
+}
+
+const (
+	WIPELEFT  = iota // Starts at the left edge
+	WIPERIGHT        // Starts at the right edge
+	WIPEUP           // Starts at the bottom
+	WIPEDOWN         // Starts at the top
+)
+
+// Covers a part of the screen with a color, animate Progress from 0 to 1 for a transition
+type WipePass struct {
+	Progress  float64
+	Direction int
+	Color     sdl.Color
+}
+
+func (wp *WipePass) Apply(renderer *sdl.Renderer, src *sdl.Texture, w, h int) {
+	renderer.Copy(src, nil, nil)
+
+	progress := wp.Progress
+	if progress <= 0 {
+		return
+	}
+	if progress > 1 {
+		progress = 1
+	}
+
+	rect := sdl.Rect{W: int32(w), H: int32(h)}
+	switch wp.Direction {
+	case WIPELEFT:
+		rect.W = int32(float64(w) * progress)
+	case WIPERIGHT:
+		rect.W = int32(float64(w) * progress)
+		rect.X = int32(w) - rect.W
+	case WIPEDOWN:
+		rect.H = int32(float64(h) * progress)
+	case WIPEUP:
+		rect.H = int32(float64(h) * progress)
+		rect.Y = int32(h) - rect.H
+	}
+
+	setDrawColor(renderer, wp.Color)
+	renderer.FillRect(&rect)
+}
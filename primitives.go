@@ -0,0 +1,111 @@
+package vroom
+
+import (
+	"github.com/jonas747/go-box2d-lite/box2dlite"
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+	"sort"
+)
+
+// Low level drawing helpers, all coordinates are in screen space and the current draw color is used
+
+// Draws a line, lines thicker than 1 are drawn as a filled quad
+func DrawLine(renderer *sdl.Renderer, a, b box2dlite.Vec2, thickness int) {
+	if thickness <= 1 {
+		renderer.DrawLine(int(a.X), int(a.Y), int(b.X), int(b.Y))
+		return
+	}
+
+	dir := b.Sub(a)
+	length := math.Hypot(dir.X, dir.Y)
+	if length == 0 {
+		return
+	}
+
+	half := float64(thickness) / 2
+	normal := box2dlite.Vec2{-dir.Y / length * half, dir.X / length * half}
+	FillPolygon(renderer, []box2dlite.Vec2{a.Add(normal), b.Add(normal), b.Sub(normal), a.Sub(normal)})
+}
+
+// Draws lines between the points, if closed the last point is connected to the first
+func DrawPolyline(renderer *sdl.Renderer, points []box2dlite.Vec2, closed bool, thickness int) {
+	if len(points) < 2 {
+		return
+	}
+
+	for i := 0; i < len(points)-1; i++ {
+		DrawLine(renderer, points[i], points[i+1], thickness)
+	}
+	if closed {
+		DrawLine(renderer, points[len(points)-1], points[0], thickness)
+	}
+}
+
+// Fills a polygon using scanlines, works for concave polygons aswell
+func FillPolygon(renderer *sdl.Renderer, points []box2dlite.Vec2) {
+	if len(points) < 3 {
+		return
+	}
+
+	minY := points[0].Y
+	maxY := points[0].Y
+	for _, p := range points {
+		minY = math.Min(minY, p.Y)
+		maxY = math.Max(maxY, p.Y)
+	}
+
+	crossings := make([]float64, 0, len(points))
+	for y := int(math.Ceil(minY)); y <= int(math.Floor(maxY)); y++ {
+		fy := float64(y) + 0.5
+		crossings = crossings[:0]
+
+		for i := range points {
+			a := points[i]
+			b := points[(i+1)%len(points)]
+			if (a.Y <= fy && b.Y > fy) || (b.Y <= fy && a.Y > fy) {
+				x := a.X + (fy-a.Y)/(b.Y-a.Y)*(b.X-a.X)
+				crossings = append(crossings, x)
+			}
+		}
+
+		sort.Float64s(crossings)
+		for i := 0; i+1 < len(crossings); i += 2 {
+			renderer.DrawLine(int(math.Floor(crossings[i]+0.5)), y, int(math.Floor(crossings[i+1]-0.5)), y)
+		}
+	}
+}
+
+// Returns the points making up a circle
+func CirclePoints(center box2dlite.Vec2, radius float64, segments int) []box2dlite.Vec2 {
+	if segments < 3 {
+		segments = 3
+	}
+
+	points := make([]box2dlite.Vec2, segments)
+	for i := 0; i < segments; i++ {
+		a := float64(i) / float64(segments) * math.Pi * 2
+		points[i] = box2dlite.Vec2{center.X + math.Cos(a)*radius, center.Y + math.Sin(a)*radius}
+	}
+	return points
+}
+
+// Picks a segment count so circles look round regardless of size
+func circleSegments(radius float64) int {
+	segments := int(radius / 2)
+	if segments < 12 {
+		return 12
+	}
+	if segments > 64 {
+		return 64
+	}
+	return segments
+}
+
+func setDrawColor(renderer *sdl.Renderer, color sdl.Color) {
+	if color.A < 255 {
+		renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	} else {
+		renderer.SetDrawBlendMode(sdl.BLENDMODE_NONE)
+	}
+	renderer.SetDrawColor(color.R, color.G, color.B, color.A)
+}
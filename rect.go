@@ -0,0 +1,63 @@
+package vroom
+
+import (
+	"github.com/jonas747/go-box2d-lite/box2dlite"
+	"math"
+)
+
+// Axis aligned rectangle, X and Y is the top left corner
+type Rect struct {
+	X, Y, W, H float64
+}
+
+func (r Rect) Right() float64 {
+	return r.X + r.W
+}
+
+func (r Rect) Bottom() float64 {
+	return r.Y + r.H
+}
+
+func (r Rect) Contains(x, y float64) bool {
+	return x >= r.X && x < r.X+r.W && y >= r.Y && y < r.Y+r.H
+}
+
+func (r Rect) Intersects(o Rect) bool {
+	return r.X < o.X+o.W && o.X < r.X+r.W && r.Y < o.Y+o.H && o.Y < r.Y+r.H
+}
+
+func (r Rect) Center() box2dlite.Vec2 {
+	return box2dlite.Vec2{r.X + r.W/2, r.Y + r.H/2}
+}
+
+// Returns the rect grown by amount on every side
+func (r Rect) Grow(amount float64) Rect {
+	return Rect{r.X - amount, r.Y - amount, r.W + amount*2, r.H + amount*2}
+}
+
+// Returns the bounds of the rect after it's been transformed by m
+func (r Rect) Transform(m Matrix) Rect {
+	return PointsBounds([]box2dlite.Vec2{
+		m.Apply(box2dlite.Vec2{r.X, r.Y}),
+		m.Apply(box2dlite.Vec2{r.Right(), r.Y}),
+		m.Apply(box2dlite.Vec2{r.Right(), r.Bottom()}),
+		m.Apply(box2dlite.Vec2{r.X, r.Bottom()}),
+	})
+}
+
+// Smallest rect containing all the points
+func PointsBounds(points []box2dlite.Vec2) Rect {
+	if len(points) < 1 {
+		return Rect{}
+	}
+
+	minX, minY := points[0].X, points[0].Y
+	maxX, maxY := minX, minY
+	for _, p := range points[1:] {
+		minX = math.Min(minX, p.X)
+		minY = math.Min(minY, p.Y)
+		maxX = math.Max(maxX, p.X)
+		maxY = math.Max(maxY, p.Y)
+	}
+	return Rect{minX, minY, maxX - minX, maxY - minY}
+}
@@ -0,0 +1,99 @@
+package vroom
+
+import (
+	"github.com/jonas747/go-box2d-lite/box2dlite"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Offscreen texture that can be drawn into
+// The texture can be used like any other texture afterwards, for example in a Sprite for minimaps
+type RenderTarget struct {
+	Texture       *sdl.Texture
+	Width, Height int
+
+	// Used when the target is rendered automatically every frame, see Engine.AddRenderTarget
+	FromLayer, ToLayer int
+	Scale              float64         // Scale applied while drawing, 0 is treated as 1, this is the camera zoom
+	Camera             *box2dlite.Vec2 // Overrides Engine.Camera while drawing if set
+	ClearColor         sdl.Color
+}
+
+// Creates a new render target, pixels are not filtered when the target is scaled
+func (e *Engine) NewRenderTarget(w, h int) (*RenderTarget, error) {
+	oldQuality := sdl.GetHint(sdl.HINT_RENDER_SCALE_QUALITY)
+	sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, "0")
+	texture, err := e.renderer.CreateTexture(sdl.PIXELFORMAT_RGBA8888, sdl.TEXTUREACCESS_TARGET, w, h)
+	sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, oldQuality)
+	if err != nil {
+		return nil, err
+	}
+	texture.SetBlendMode(sdl.BLENDMODE_BLEND)
+
+	return &RenderTarget{
+		Texture:   texture,
+		Width:     w,
+		Height:    h,
+		FromLayer: MINLAYER,
+		ToLayer:   MAXLAYER,
+	}, nil
+}
+
+func (rt *RenderTarget) Destroy() {
+	if rt.Texture != nil {
+		rt.Texture.Destroy()
+		rt.Texture = nil
+	}
+}
+
+// Makes this the current target for the renderer and clears it
+func (rt *RenderTarget) Begin(renderer *sdl.Renderer) {
+	renderer.SetRenderTarget(rt.Texture)
+	renderer.SetDrawBlendMode(sdl.BLENDMODE_NONE)
+	renderer.SetDrawColor(rt.ClearColor.R, rt.ClearColor.G, rt.ClearColor.B, rt.ClearColor.A)
+	renderer.Clear()
+}
+
+// Switches back to drawing on the screen
+func (rt *RenderTarget) End(renderer *sdl.Renderer) {
+	renderer.SetRenderTarget(nil)
+}
+
+// Adds a target that gets the layers FromLayer-ToLayer drawn into it every frame before the screen is drawn
+func (e *Engine) AddRenderTarget(target *RenderTarget) {
+	e.RenderTargets = append(e.RenderTargets, target)
+}
+
+func (e *Engine) RemoveRenderTarget(target *RenderTarget) {
+	for k, v := range e.RenderTargets {
+		if v == target {
+			e.RenderTargets = append(e.RenderTargets[:k], e.RenderTargets[k+1:]...)
+			return
+		}
+	}
+}
+
+// Draws the targets layer range into it using the targets camera and scale
+func (e *Engine) RenderToTarget(target *RenderTarget) {
+	if target.Texture == nil {
+		return
+	}
+
+	oldCamera := e.Camera
+	if target.Camera != nil {
+		e.Camera = *target.Camera
+	}
+
+	e.viewTarget = target
+	target.Begin(e.renderer)
+	if target.Scale != 0 && target.Scale != 1 {
+		e.renderer.SetScale(float32(target.Scale), float32(target.Scale))
+	}
+
+	e.DrawSystem.DrawLayers(e.renderer, target.FromLayer, target.ToLayer)
+
+	e.renderer.SetScale(1, 1)
+	target.End(e.renderer)
+
+	e.viewTarget = nil
+	e.Camera = oldCamera
+}
@@ -0,0 +1,678 @@
+package vroom
+
+import (
+	"bytes"
+	"github.com/jonas747/go-box2d-lite/box2dlite"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/sdl_ttf"
+	"math"
+	"math/rand"
+	"strings"
+	"unicode/utf8"
+)
+
+// Label with inline markup, [b]bold[/b] [i]italic[/i] [color=#ff8800]colored[/color] [font=name]other font[/font]
+// [icon=texture] draws a texture as high as the text, [wave]wavy[/wave] and [shake]shaky[/shake] moves the characters
+// and [[ is a literal [
+// Every run of text is rendered once and kept until it's no longer used, so changing the text
+// only renders the parts that are new
+type RichLabel struct {
+	BaseComponent
+	LocalizedText  // Set Key to show localized text instead of Text, the text can contain markup
+	Font           string
+	BoldFont       string // Used for bold and italic text, if not set the style is applied to Font instead
+	ItalicFont     string
+	BoldItalicFont string
+
+	Color        sdl.Color
+	CenterHor    bool
+	CenterVert   bool
+	IgnoreCamera bool
+	Layer        int
+
+	MaxWidth    int // Wrap lines longer than this, 0 for no wrapping
+	Align       int // ALIGNLEFT, ALIGNCENTER or ALIGNRIGHT
+	LineSpacing int
+
+	WaveHeight  float64
+	WaveSpeed   float64
+	ShakeAmount float64
+
+	// Characters revealed every second, 0 shows everything right away
+	TypewriterSpeed float64
+	OnRevealed      func()
+
+	Text          string
+	Width, Height int
+
+	pieces   []*richPiece
+	chars    int
+	runs     map[textRunKey]*textRun
+	time     float64
+	revealed float64
+}
+
+func NewRichLabel(text, font string) *RichLabel {
+	return &RichLabel{
+		Font:        font,
+		Color:       sdl.Color{255, 255, 255, 255},
+		Text:        text,
+		WaveHeight:  3,
+		WaveSpeed:   8,
+		ShakeAmount: 1,
+	}
+}
+
+type richStyle struct {
+	font         string
+	bold, italic bool
+	color        sdl.Color
+	wave, shake  bool
+}
+
+type richSpan struct {
+	text  string
+	icon  string
+	style richStyle
+}
+
+type textRunKey struct {
+	font  string
+	style int
+	text  string
+}
+
+type textRun struct {
+	texture *sdl.Texture
+	w, h    int
+	ascent  int
+	used    bool
+}
+
+type richPiece struct {
+	text   string
+	icon   *sdl.Texture
+	run    *textRun
+	style  richStyle
+	x, y   float64
+	w, h   int
+	ascent int
+	char   int // Index of the first character, for the typewriter and wave
+	chars  int
+}
+
+// Splits the markup into spans of text with the same style
+func parseRichText(text string, base richStyle) []richSpan {
+	spans := make([]richSpan, 0)
+	styles := []richStyle{base}
+	tags := []string{""}
+
+	var buf bytes.Buffer
+	flush := func() {
+		if buf.Len() > 0 {
+			spans = append(spans, richSpan{text: buf.String(), style: styles[len(styles)-1]})
+			buf.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); {
+		if text[i] != '[' {
+			buf.WriteByte(text[i])
+			i++
+			continue
+		}
+		if strings.HasPrefix(text[i:], "[[") {
+			buf.WriteByte('[')
+			i += 2
+			continue
+		}
+
+		end := strings.IndexByte(text[i:], ']')
+		if end == -1 {
+			buf.WriteString(text[i:])
+			break
+		}
+		raw := text[i : i+end+1]
+		i += end + 1
+
+		name, value := raw[1:len(raw)-1], ""
+		if eq := strings.IndexByte(name, '='); eq != -1 {
+			name, value = name[:eq], name[eq+1:]
+		}
+		name = strings.ToLower(name)
+
+		// Closing tags pop everything up to the matching tag
+		if strings.HasPrefix(name, "/") {
+			found := -1
+			for k := len(tags) - 1; k > 0; k-- {
+				if tags[k] == name[1:] {
+					found = k
+					break
+				}
+			}
+			if found == -1 {
+				buf.WriteString(raw)
+				continue
+			}
+			flush()
+			styles = styles[:found]
+			tags = tags[:found]
+			continue
+		}
+
+		style := styles[len(styles)-1]
+		switch name {
+		case "b":
+			style.bold = true
+		case "i":
+			style.italic = true
+		case "color":
+			color, err := ParseColor(value)
+			if err != nil {
+				buf.WriteString(raw)
+				continue
+			}
+			style.color = color
+		case "font":
+			style.font = value
+		case "wave":
+			style.wave = true
+		case "shake":
+			style.shake = true
+		case "icon":
+			flush()
+			spans = append(spans, richSpan{icon: value, style: style})
+			continue
+		default:
+			buf.WriteString(raw)
+			continue
+		}
+
+		flush()
+		styles = append(styles, style)
+		tags = append(tags, name)
+	}
+	flush()
+	return spans
+}
+
+func (rl *RichLabel) Init() {
+	if rl.GetComponent("Transform") == nil {
+		transform := &Transform{}
+		rl.AddComponent(transform)
+	}
+	if rl.Key != "" {
+		rl.Relocalize()
+	} else if rl.Text != "" {
+		rl.SetText(rl.Text)
+	}
+}
+
+// Shows the text for key in the current locale, see Localization.Text
+func (rl *RichLabel) SetKey(key string, args ...interface{}) {
+	rl.LocalizedText = LocalizedText{Key: key, Args: args}
+	rl.Relocalize()
+}
+
+// Shows the plural form of key for n, see Localization.Plural
+func (rl *RichLabel) SetPluralKey(key string, n int, args ...interface{}) {
+	rl.LocalizedText = LocalizedText{Key: key, Args: args, Count: n, Plural: true}
+	rl.Relocalize()
+}
+
+func (rl *RichLabel) Relocalize() {
+	if rl.Key != "" {
+		rl.SetText(rl.localize(rl.Parent.GetEngine()))
+	}
+}
+
+func (rl *RichLabel) Name() string {
+	return "RichLabel"
+}
+
+func (rl *RichLabel) GetLayer() int {
+	return rl.Layer
+}
+
+// Returns the font name and the ttf style that has to be set on it for the style
+func (rl *RichLabel) fontFor(style richStyle) (string, int) {
+	ttfStyle := ttf.STYLE_NORMAL
+	if style.bold {
+		ttfStyle |= ttf.STYLE_BOLD
+	}
+	if style.italic {
+		ttfStyle |= ttf.STYLE_ITALIC
+	}
+
+	if style.font != "" {
+		return style.font, ttfStyle
+	}
+
+	switch {
+	case style.bold && style.italic && rl.BoldItalicFont != "":
+		return rl.BoldItalicFont, ttf.STYLE_NORMAL
+	case style.bold && !style.italic && rl.BoldFont != "":
+		return rl.BoldFont, ttf.STYLE_NORMAL
+	case style.italic && !style.bold && rl.ItalicFont != "":
+		return rl.ItalicFont, ttf.STYLE_NORMAL
+	}
+	return rl.Font, ttfStyle
+}
+
+// Returns the cached run or renders it in white, the color is applied when drawing
+func (rl *RichLabel) run(engine *Engine, style richStyle, text string) *textRun {
+	name, ttfStyle := rl.fontFor(style)
+	key := textRunKey{name, ttfStyle, text}
+	if run, ok := rl.runs[key]; ok {
+		run.used = true
+		return run
+	}
+
+	font := engine.GetFont(name)
+	if font == nil {
+		return nil
+	}
+
+	oldStyle := font.GetStyle()
+	font.SetStyle(ttfStyle)
+	surface := font.RenderUTF8_Blended(text, sdl.Color{255, 255, 255, 255})
+	ascent := font.Ascent()
+	font.SetStyle(oldStyle)
+	if surface == nil {
+		return nil
+	}
+
+	texture, err := engine.renderer.CreateTextureFromSurface(surface)
+	run := &textRun{
+		texture: texture,
+		w:       int(surface.W),
+		h:       int(surface.H),
+		ascent:  ascent,
+		used:    true,
+	}
+	surface.Free()
+	if err != nil {
+		return nil
+	}
+
+	if rl.runs == nil {
+		rl.runs = make(map[textRunKey]*textRun)
+	}
+	rl.runs[key] = run
+	return run
+}
+
+func (rl *RichLabel) measure(engine *Engine, style richStyle, text string) int {
+	name, ttfStyle := rl.fontFor(style)
+	font := engine.GetFont(name)
+	if font == nil {
+		return 0
+	}
+
+	oldStyle := font.GetStyle()
+	font.SetStyle(ttfStyle)
+	w := textWidth(font, text)
+	font.SetStyle(oldStyle)
+	return w
+}
+
+const (
+	richPieceItem = iota
+	richSpaceItem
+	richNewlineItem
+)
+
+type richItem struct {
+	kind  int
+	piece *richPiece
+	space int
+}
+
+// Turns the spans into pieces, spaces and newlines
+func (rl *RichLabel) buildItems(engine *Engine, spans []richSpan) []richItem {
+	items := make([]richItem, 0)
+	char := 0
+
+	addPiece := func(style richStyle, text string) {
+		run := rl.run(engine, style, text)
+		if run == nil {
+			return
+		}
+		chars := utf8.RuneCountInString(text)
+		items = append(items, richItem{kind: richPieceItem, piece: &richPiece{
+			text:   text,
+			run:    run,
+			style:  style,
+			w:      run.w,
+			h:      run.h,
+			ascent: run.ascent,
+			char:   char,
+			chars:  chars,
+		}})
+		char += chars
+	}
+
+	addWord := func(style richStyle, word string) {
+		if word == "" {
+			return
+		}
+		// Moving characters need to be drawn one by one
+		if style.wave || style.shake {
+			for _, r := range word {
+				addPiece(style, string(r))
+			}
+			return
+		}
+		addPiece(style, word)
+	}
+
+	for _, span := range spans {
+		if span.icon != "" {
+			texture := engine.GetTexture(span.icon)
+			space := rl.run(engine, span.style, " ")
+			if texture == nil || space == nil {
+				continue
+			}
+			_, _, tw, th, _ := texture.Query()
+			if th == 0 {
+				continue
+			}
+			items = append(items, richItem{kind: richPieceItem, piece: &richPiece{
+				icon:   texture,
+				style:  span.style,
+				w:      tw * space.h / th,
+				h:      space.h,
+				ascent: space.ascent,
+				char:   char,
+				chars:  1,
+			}})
+			char++
+			continue
+		}
+
+		start := 0
+		for k, r := range span.text {
+			if r != ' ' && r != '\n' {
+				continue
+			}
+			addWord(span.style, span.text[start:k])
+			start = k + 1
+
+			if r == '\n' {
+				items = append(items, richItem{kind: richNewlineItem})
+			} else {
+				space := rl.run(engine, span.style, " ")
+				if space != nil {
+					items = append(items, richItem{kind: richSpaceItem, space: space.w})
+				}
+			}
+			char++
+		}
+		addWord(span.style, span.text[start:])
+	}
+
+	rl.chars = char
+	return items
+}
+
+type richLine struct {
+	pieces          []*richPiece
+	width           float64
+	ascent, descent int
+}
+
+func (rl *RichLabel) SetText(text string) {
+	engine := rl.Parent.GetEngine()
+	rl.Text = text
+
+	for _, run := range rl.runs {
+		run.used = false
+	}
+
+	spans := parseRichText(text, richStyle{color: rl.Color})
+	items := rl.buildItems(engine, spans)
+
+	// Break into lines
+	lines := make([]*richLine, 0)
+	line := &richLine{}
+	x := 0.0
+	finish := func() {
+		lines = append(lines, line)
+		line = &richLine{}
+		x = 0
+	}
+
+	for i := 0; i < len(items); {
+		switch items[i].kind {
+		case richNewlineItem:
+			finish()
+			i++
+		case richSpaceItem:
+			if x > 0 {
+				x += float64(items[i].space)
+			}
+			i++
+		default:
+			// Pieces not separated by spaces make up a word that's kept together
+			end := i
+			width := 0.0
+			for end < len(items) && items[end].kind == richPieceItem {
+				width += float64(items[end].piece.w)
+				end++
+			}
+			if rl.MaxWidth > 0 && x > 0 && x+width > float64(rl.MaxWidth) {
+				finish()
+			}
+
+			for _, item := range items[i:end] {
+				piece := item.piece
+				piece.x = x
+				x += float64(piece.w)
+				line.pieces = append(line.pieces, piece)
+				line.width = x
+				if piece.ascent > line.ascent {
+					line.ascent = piece.ascent
+				}
+				if piece.h-piece.ascent > line.descent {
+					line.descent = piece.h - piece.ascent
+				}
+			}
+			i = end
+		}
+	}
+	finish()
+
+	blockWidth := float64(rl.MaxWidth)
+	if rl.MaxWidth <= 0 {
+		blockWidth = 0
+		for _, line := range lines {
+			blockWidth = math.Max(blockWidth, line.width)
+		}
+	}
+
+	emptyHeight := 0
+	if space := rl.run(engine, richStyle{color: rl.Color}, " "); space != nil {
+		emptyHeight = space.h
+	}
+
+	rl.pieces = rl.pieces[:0]
+	top := 0
+	for _, line := range lines {
+		offset := 0.0
+		switch rl.Align {
+		case ALIGNCENTER:
+			offset = math.Floor((blockWidth - line.width) / 2)
+		case ALIGNRIGHT:
+			offset = blockWidth - line.width
+		}
+
+		for _, piece := range line.pieces {
+			piece.x += offset
+			piece.y = float64(top + line.ascent - piece.ascent)
+			rl.pieces = append(rl.pieces, piece)
+		}
+
+		height := line.ascent + line.descent
+		if len(line.pieces) < 1 {
+			height = emptyHeight
+		}
+		top += height + rl.LineSpacing
+	}
+
+	rl.Width = int(blockWidth)
+	rl.Height = top - rl.LineSpacing
+
+	// Get rid of the runs that are no longer used
+	for key, run := range rl.runs {
+		if !run.used {
+			if run.texture != nil {
+				run.texture.Destroy()
+			}
+			delete(rl.runs, key)
+		}
+	}
+
+	if rl.TypewriterSpeed > 0 {
+		rl.revealed = 0
+	}
+
+	if engine.DrawSystem != nil {
+		engine.DrawSystem.UpdateBounds(rl)
+	}
+}
+
+func (rl *RichLabel) Update(dt float64) {
+	rl.time += dt
+
+	if rl.TypewriterSpeed > 0 && rl.revealed < float64(rl.chars) {
+		rl.revealed += rl.TypewriterSpeed * dt
+		if rl.revealed >= float64(rl.chars) {
+			rl.SkipTypewriter()
+		}
+	}
+}
+
+// True when all the text is shown
+func (rl *RichLabel) Revealed() bool {
+	return rl.TypewriterSpeed <= 0 || rl.revealed >= float64(rl.chars)
+}
+
+// Shows all the text right away
+func (rl *RichLabel) SkipTypewriter() {
+	rl.revealed = float64(rl.chars)
+	if rl.OnRevealed != nil {
+		rl.OnRevealed()
+	}
+}
+
+// Starts revealing the text from the beginning again
+func (rl *RichLabel) RestartTypewriter() {
+	rl.revealed = 0
+}
+
+func (rl *RichLabel) origin() (box2dlite.Vec2, bool) {
+	transformComp := rl.GetComponent("Transform")
+	if transformComp == nil {
+		return box2dlite.Vec2{}, false
+	}
+	transform, ok := transformComp.(*Transform)
+	if !ok {
+		return box2dlite.Vec2{}, false
+	}
+
+	position := transform.WorldPosition()
+	if rl.CenterHor {
+		position.X -= float64(rl.Width / 2)
+	}
+	if rl.CenterVert {
+		position.Y -= float64(rl.Height / 2)
+	}
+	return position, true
+}
+
+// Size of the laid out text in pixels
+func (rl *RichLabel) Size() (int, int) {
+	return rl.Width, rl.Height
+}
+
+// Area covered by the text in world space (or screen space if IgnoreCamera is set)
+func (rl *RichLabel) Bounds() (Rect, bool) {
+	origin, ok := rl.origin()
+	if !ok {
+		return Rect{}, rl.IgnoreCamera
+	}
+	bounds := Rect{origin.X, origin.Y, float64(rl.Width), float64(rl.Height)}
+	return bounds.Grow(rl.WaveHeight + rl.ShakeAmount + 1), rl.IgnoreCamera
+}
+
+func (rl *RichLabel) Draw(renderer *sdl.Renderer) {
+	origin, ok := rl.origin()
+	if !ok {
+		return
+	}
+
+	engine := rl.Parent.GetEngine()
+	if !rl.IgnoreCamera {
+		origin = origin.Sub(engine.Camera)
+	}
+
+	visible := -1
+	if !rl.Revealed() {
+		visible = int(rl.revealed)
+	}
+
+	for _, piece := range rl.pieces {
+		if visible >= 0 && piece.char >= visible {
+			break
+		}
+
+		x := origin.X + piece.x
+		y := origin.Y + piece.y
+		if piece.style.wave {
+			y += math.Sin(rl.time*rl.WaveSpeed-float64(piece.char)*0.5) * rl.WaveHeight
+		}
+		if piece.style.shake {
+			x += (rand.Float64()*2 - 1) * rl.ShakeAmount
+			y += (rand.Float64()*2 - 1) * rl.ShakeAmount
+		}
+
+		texture := piece.icon
+		if texture == nil {
+			texture = piece.run.texture
+			if texture == nil {
+				continue
+			}
+			color := piece.style.color
+			texture.SetColorMod(color.R, color.G, color.B)
+			texture.SetAlphaMod(color.A)
+		}
+
+		// Partly revealed by the typewriter
+		var src *sdl.Rect
+		w := piece.w
+		if visible >= 0 && piece.icon == nil && piece.char+piece.chars > visible {
+			shown := piece.text
+			for k := range piece.text {
+				if utf8.RuneCountInString(piece.text[:k]) == visible-piece.char {
+					shown = piece.text[:k]
+					break
+				}
+			}
+			w = rl.measure(engine, piece.style, shown)
+			src = &sdl.Rect{W: int32(w), H: int32(piece.h)}
+		}
+
+		dst := &sdl.Rect{X: int32(x), Y: int32(y), W: int32(w), H: int32(piece.h)}
+		renderer.Copy(texture, src, dst)
+	}
+}
+
+func (rl *RichLabel) Destroy() {
+	for _, run := range rl.runs {
+		if run.texture != nil {
+			run.texture.Destroy()
+		}
+	}
+	rl.runs = nil
+	rl.pieces = nil
+}
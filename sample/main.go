@@ -6,6 +6,8 @@ import (
 	"github.com/veandco/go-sdl2/sdl"
 	"github.com/veandco/go-sdl2/sdl_mixer"
 	"math"
+	"os"
+	"time"
 )
 
 var Engine *vroom.Engine
@@ -13,12 +15,16 @@ var Engine *vroom.Engine
 func main() {
 	Engine = &vroom.Engine{}
 	Engine.InitCoreSystems()
+	Engine.PhysicsDebug.ToggleKey = sdl.K_F1
+	Engine.Display.Resizable = true
+	Engine.Display.FullscreenKey = sdl.K_F11
 
 	fmt.Println("Initializing SDL")
 	err := Engine.InitSDL(640, 400, "Sample game for vroom")
 	if err != nil {
 		panic(err)
 	}
+	Engine.SetLogicalSize(640, 400, vroom.SCALEASPECT)
 	fmt.Println("Loading Assets")
 	loadAssets()
 
@@ -84,17 +90,25 @@ func loadAssets() {
 		}
 	}
 	mix.Volume(-1, sdl.MIX_MAXVOLUME/5)
+
+	err := Engine.LoadParticleEffect("assets/sparks.json", "sparks")
+	if err != nil {
+		fmt.Println(err)
+		panic(err)
+	}
+
+	err = Engine.Localization.LoadStrings("assets/strings.csv", "")
+	if err != nil {
+		fmt.Println(err)
+		panic(err)
+	}
+	Engine.Localization.Fallback = "en"
+	Engine.Localization.SetLocale("en")
 }
 
 func initScene() {
-	button := &SimpleButton{
-		Text: "BATTANN",
-		X:    100,
-		Y:    100,
-		W:    150,
-		H:    40,
-	}
-	Engine.AddEntity(button)
+	initMenu()
+	initControls()
 
 	gorund := &Box{
 		X:       320,
@@ -124,55 +138,130 @@ func initScene() {
 		Static:  false,
 		Texture: "box",
 		Mass:    100000,
+
+		Controlled: true,
 	}
 	Engine.AddEntity(falling)
+
+	sparks := vroom.NewEntity(500, 280)
+	sparks.AddComponent(Engine.NewParticleEmitter("sparks"))
+	Engine.AddEntity(sparks)
 }
 
-type SimpleButton struct {
-	vroom.BaseEntity
-	Text       string
-	X, Y, W, H int
+// Menu in the top left corner of the screen
+func initMenu() {
+	for _, path := range []string{"assets/theme_textured.json", "assets/theme_flat.json"} {
+		err := Engine.UI.LoadTheme(path)
+		if err != nil {
+			fmt.Println(err)
+			panic(err)
+		}
+	}
+	Engine.UI.SetTheme("textured")
+
+	Engine.UI.Bind("magic", func(event vroom.UIEvent) {
+		Engine.UI.Confirm("Magic", "Do you really want to do magic?", func(ok bool) {
+			if ok {
+				fmt.Println(Engine.Localization.Text("button_pressed"))
+			}
+		})
+	})
+	Engine.UI.Bind("physics_debug", func(event vroom.UIEvent) {
+		Engine.PhysicsDebug.Enabled = event.Checked
+	})
+	Engine.UI.Bind("volume", func(event vroom.UIEvent) {
+		mix.Volume(-1, int(event.Value/100*sdl.MIX_MAXVOLUME))
+	})
+	Engine.UI.Bind("language", func(event vroom.UIEvent) {
+		Engine.Localization.SetLocale(event.Text)
+	})
+	Engine.UI.Bind("flat_theme", func(event vroom.UIEvent) {
+		if event.Checked {
+			Engine.UI.SetTheme("flat")
+		} else {
+			Engine.UI.SetTheme("textured")
+		}
+	})
+	Engine.UI.Bind("greet", func(event vroom.UIEvent) {
+		fmt.Println("Hello", event.Text)
+	})
+
+	// Edit assets/menu.json while it's running to see the changes
+	Engine.UI.HotReload = true
+	menu, err := Engine.LoadUI("assets/menu.json")
+	if err != nil {
+		fmt.Println(err)
+		panic(err)
+	}
+	Engine.AddEntity(menu.Root)
+
+	// Context menus aren't in the file so they're set again after reloading
+	menu.OnReload = addMagicMenu
+	addMagicMenu(menu)
 }
 
-func (sb *SimpleButton) Init() {
-	fmt.Println("Init Called")
-	transform := vroom.NewTransform(float64(sb.X), float64(sb.Y), 0)
-	sb.AddComponent(transform)
-
-	mbox := &vroom.MouseBox{
-		W: sb.W,
-		H: sb.H,
-	}
-	sb.AddComponent(mbox)
-
-	// Label has a different position so has to be in its own enity (but is a child of this)
-	label := vroom.NewLabel(sb.Text, true, "mainfont", "mainfont_outline")
-	lEntity := vroom.NewEntity(0, 0)
-	lEntity.AddComponent(label)
-
-	sb.AddChild(lEntity, true)
-
-	hs := Engine.NewSprite(sb.W, sb.H, true, "button_hover")
-	is := Engine.NewSprite(sb.W, sb.H, true, "button_idle")
-	cs := Engine.NewSprite(sb.W, sb.H, true, "button_pressed")
-
-	sb.AddComponent(hs)
-	sb.AddComponent(is)
-	sb.AddComponent(cs)
-
-	buttonComp := &vroom.Button{
-		HoverSprite: hs,
-		IdleSprite:  is,
-		ClickSprite: cs,
-		Width:       sb.W,
-		Height:      sb.H,
-		ClickSound:  "click",
-		HoverSound:  "hover",
-		OnClick: func() {
-			fmt.Println("Magic button pressed! Such press!")
-		},
-	}
-	sb.AddComponent(buttonComp)
+func addMagicMenu(menu *vroom.UIScreen) {
+	if magic, ok := menu.Get("magic").(*vroom.Button); ok {
+		magic.ContextMenu = []vroom.MenuItem{
+			{Text: "Do magic", Action: func() { fmt.Println(Engine.Localization.Text("button_pressed")) }},
+			{Separator: true},
+			{Text: "Rebind jump", Action: rebindJump},
+			{Text: "About", Action: func() { Engine.UI.Alert("About", "Vroom sample", nil) }},
+		}
+	}
+}
+
+// Default controls, changed ones are loaded from bindings.json
+func initControls() {
+	Engine.Actions.AddAction("jump", vroom.KeyBinding(sdl.K_w), vroom.KeyBinding(sdl.K_UP), vroom.GamepadButtonBinding(sdl.CONTROLLER_BUTTON_A))
+	Engine.Actions.AddAxis("move_x",
+		[]vroom.InputBinding{vroom.KeyBinding(sdl.K_a), vroom.KeyBinding(sdl.K_LEFT)},
+		[]vroom.InputBinding{vroom.KeyBinding(sdl.K_d), vroom.KeyBinding(sdl.K_RIGHT), vroom.GamepadAxisBinding(sdl.CONTROLLER_AXIS_LEFTX, 0)})
+
+	err := Engine.Actions.LoadBindings("bindings.json")
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Println(err)
+	}
+
+	Engine.Gamepads.OnConnected = func(pad *vroom.Gamepad) {
+		fmt.Println("Gamepad connected:", pad.Name, "player", pad.Player)
+	}
+}
+
+// Jump gets the next key or button pressed instead of the first one
+func rebindJump() {
+	fmt.Println("Press a key or button to jump with")
+	Engine.Actions.ListenForInput(func(binding vroom.InputBinding) {
+		Engine.Actions.Unbind(binding)
+		Engine.Actions.Rebind("jump", 0, binding)
+		fmt.Println("Jump is now", binding)
+		if err := Engine.Actions.SaveBindings("bindings.json"); err != nil {
+			fmt.Println(err)
+		}
+	})
+}
+
+// Moves the box sideways with the move_x axis and jumps with the jump action
+type BoxControls struct {
+	vroom.BaseComponent
+}
+
+func (bc *BoxControls) Name() string {
+	return "BoxControls"
+}
+
+func (bc *BoxControls) Update(dt float64) {
+	physComp, ok := bc.GetComponent("PhysBodyComp").(*vroom.PhysBodyComp)
+	if !ok {
+		return
+	}
+	physComp.Body.Velocity.X = Engine.Actions.Axis("move_x") * 5
+	if Engine.Actions.JustPressed("jump") {
+		physComp.Body.Velocity.Y = -8
+		if pad := Engine.Gamepads.Gamepad(0); pad != nil {
+			pad.Rumble(0.3, 100*time.Millisecond) // Not every gamepad can rumble, that's fine
+		}
+	}
 }
 
 type SimpleSprite struct {
@@ -187,6 +276,7 @@ type Box struct {
 	Static     bool
 	Mass       float64
 	Texture    string
+	Controlled bool // Moved with the actions
 }
 
 func (b *Box) Init() {
@@ -201,4 +291,8 @@ func (b *Box) Init() {
 
 	physComp := Engine.NewPhysBodyComp(b.X, b.Y, b.W, b.H, b.Mass)
 	b.AddComponent(physComp)
+
+	if b.Controlled {
+		b.AddComponent(&BoxControls{})
+	}
 }
@@ -0,0 +1,199 @@
+package vroom
+
+import (
+	"github.com/jonas747/go-box2d-lite/box2dlite"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Common fields for the shape components, points are relative to the transform
+// so they follow its position, rotation and scale
+type Shape struct {
+	BaseComponent
+	Color        sdl.Color
+	Thickness    int // Line thickness of outlines
+	Filled       bool
+	Layer        int
+	IgnoreCamera bool
+}
+
+func (s *Shape) Init() {
+	if s.GetComponent("Transform") == nil {
+		transform := &Transform{}
+		s.AddComponent(transform)
+	}
+}
+
+func (s *Shape) GetLayer() int {
+	return s.Layer
+}
+
+// Transforms local points into screen space
+func (s *Shape) toScreen(points []box2dlite.Vec2) []box2dlite.Vec2 {
+	transformComp := s.GetComponent("Transform")
+	if transformComp == nil {
+		return nil
+	}
+	transform, ok := transformComp.(*Transform)
+	if !ok {
+		return nil
+	}
+
+	matrix := transform.WorldMatrix()
+	camera := box2dlite.Vec2{}
+	if !s.IgnoreCamera {
+		camera = s.Parent.GetEngine().Camera
+	}
+
+	out := make([]box2dlite.Vec2, len(points))
+	for k, p := range points {
+		out[k] = matrix.Apply(p).Sub(camera)
+	}
+	return out
+}
+
+// Bounds of the local points in world space (or screen space if IgnoreCamera is set)
+func (s *Shape) pointsBounds(points []box2dlite.Vec2) (Rect, bool) {
+	transformComp := s.GetComponent("Transform")
+	if transformComp == nil {
+		return Rect{}, s.IgnoreCamera
+	}
+	transform, ok := transformComp.(*Transform)
+	if !ok {
+		return Rect{}, s.IgnoreCamera
+	}
+
+	matrix := transform.WorldMatrix()
+	world := make([]box2dlite.Vec2, len(points))
+	for k, p := range points {
+		world[k] = matrix.Apply(p)
+	}
+	return PointsBounds(world).Grow(float64(s.Thickness)/2 + 1), s.IgnoreCamera
+}
+
+func (s *Shape) drawPoints(renderer *sdl.Renderer, points []box2dlite.Vec2, closed bool) {
+	screen := s.toScreen(points)
+	if len(screen) < 2 {
+		return
+	}
+
+	setDrawColor(renderer, s.Color)
+	if s.Filled && closed {
+		FillPolygon(renderer, screen)
+	} else {
+		DrawPolyline(renderer, screen, closed, s.Thickness)
+	}
+}
+
+// Rectangle centered on the transform
+type RectShape struct {
+	Shape
+	W, H float64
+}
+
+func NewRectShape(w, h float64, color sdl.Color, filled bool) *RectShape {
+	return &RectShape{
+		Shape: Shape{Color: color, Filled: filled, Thickness: 1},
+		W:     w,
+		H:     h,
+	}
+}
+
+func (r *RectShape) Name() string {
+	return "RectShape"
+}
+
+func (r *RectShape) points() []box2dlite.Vec2 {
+	hw := r.W / 2
+	hh := r.H / 2
+	return []box2dlite.Vec2{{-hw, -hh}, {hw, -hh}, {hw, hh}, {-hw, hh}}
+}
+
+func (r *RectShape) Draw(renderer *sdl.Renderer) {
+	r.drawPoints(renderer, r.points(), true)
+}
+
+func (r *RectShape) Bounds() (Rect, bool) {
+	return r.pointsBounds(r.points())
+}
+
+// Circle centered on the transform
+type CircleShape struct {
+	Shape
+	Radius   float64
+	Segments int // 0 picks one based on the radius
+}
+
+func NewCircleShape(radius float64, color sdl.Color, filled bool) *CircleShape {
+	return &CircleShape{
+		Shape:  Shape{Color: color, Filled: filled, Thickness: 1},
+		Radius: radius,
+	}
+}
+
+func (c *CircleShape) Name() string {
+	return "CircleShape"
+}
+
+func (c *CircleShape) Draw(renderer *sdl.Renderer) {
+	segments := c.Segments
+	if segments == 0 {
+		segments = circleSegments(c.Radius)
+	}
+	c.drawPoints(renderer, CirclePoints(box2dlite.Vec2{}, c.Radius, segments), true)
+}
+
+func (c *CircleShape) Bounds() (Rect, bool) {
+	r := c.Radius
+	return c.pointsBounds([]box2dlite.Vec2{{-r, -r}, {r, -r}, {r, r}, {-r, r}})
+}
+
+// Closed polygon
+type PolygonShape struct {
+	Shape
+	Points []box2dlite.Vec2
+}
+
+func NewPolygonShape(points []box2dlite.Vec2, color sdl.Color, filled bool) *PolygonShape {
+	return &PolygonShape{
+		Shape:  Shape{Color: color, Filled: filled, Thickness: 1},
+		Points: points,
+	}
+}
+
+func (p *PolygonShape) Name() string {
+	return "PolygonShape"
+}
+
+func (p *PolygonShape) Draw(renderer *sdl.Renderer) {
+	p.drawPoints(renderer, p.Points, true)
+}
+
+func (p *PolygonShape) Bounds() (Rect, bool) {
+	return p.pointsBounds(p.Points)
+}
+
+// Connected lines, Closed connects the last point with the first
+type LineStripShape struct {
+	Shape
+	Points []box2dlite.Vec2
+	Closed bool
+}
+
+func NewLineStripShape(points []box2dlite.Vec2, color sdl.Color, thickness int) *LineStripShape {
+	return &LineStripShape{
+		Shape:  Shape{Color: color, Thickness: thickness},
+		Points: points,
+	}
+}
+
+func (l *LineStripShape) Name() string {
+	return "LineStripShape"
+}
+
+func (l *LineStripShape) Draw(renderer *sdl.Renderer) {
+	l.drawPoints(renderer, l.Points, l.Closed)
+}
+
+func (l *LineStripShape) Bounds() (Rect, bool) {
+	return l.pointsBounds(l.Points)
+}
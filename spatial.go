@@ -0,0 +1,322 @@
+package vroom
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+	"sort"
+)
+
+// Size of the cells in the grid used to find the drawables inside the view, in pixels
+const GRIDCELLSIZE = 256
+
+// Drawables bigger than this many cells are checked every frame instead of being put in the grid
+const MAXGRIDCELLS = 64
+
+// Drawables that know how much space they cover, the draw system skips them when they're outside the view
+// Drawables that don't implement this are always drawn
+type BoundedDrawAble interface {
+	DrawAble
+	// Axis aligned bounds in world space, or in screen space if the drawable ignores the camera
+	Bounds() (bounds Rect, ignoreCamera bool)
+}
+
+// Drawables that draw a single texture, used for batching
+type TexturedDrawAble interface {
+	DrawAble
+	DrawTexture() *sdl.Texture
+}
+
+// Counters for the current frame, reset in DrawSystem.PreDraw
+type DrawStats struct {
+	Drawn   int // Components drawn
+	Culled  int // Components skipped because they were outside the view
+	Batches int // Runs of draws using the same texture
+}
+
+type gridCell struct {
+	X, Y int
+}
+
+type drawEntry struct {
+	comp      DrawAble
+	bounded   BoundedDrawAble
+	transform *Transform
+	layer     *drawLayer
+
+	bounds           Rect
+	hasBounds        bool
+	screen           bool
+	inGrid           bool
+	dirty            bool   // In the dirty list of the layer
+	version          uint64 // Version of the transform the bounds are from
+	cellMin, cellMax gridCell
+
+	order int // Order added, drawables are drawn in this order
+	group int // Used to group draws by texture
+	visit uint64
+}
+
+func (entry *drawEntry) transformChanged() {
+	entry.layer.markDirty(entry)
+}
+
+// All the drawables in a layer, the ones with world space bounds are put in all the grid cells
+// they overlap so only the cells inside the view have to be looked at when drawing
+// Bounds are only updated for the drawables in the dirty list, which their transforms
+// and UpdateBounds put them in, or whose transform version moved since the last refresh
+type drawLayer struct {
+	entries   []*drawEntry
+	byComp    map[DrawAble]*drawEntry
+	cells     map[gridCell][]*drawEntry
+	loose     []*drawEntry // Not in the grid, always checked
+	dirty     []*drawEntry
+	inGrid    int
+	nextOrder int
+	visit     uint64
+}
+
+func newDrawLayer() *drawLayer {
+	return &drawLayer{
+		byComp: make(map[DrawAble]*drawEntry),
+		cells:  make(map[gridCell][]*drawEntry),
+	}
+}
+
+func (dl *drawLayer) add(comp DrawAble) {
+	if _, ok := dl.byComp[comp]; ok {
+		return
+	}
+
+	entry := &drawEntry{
+		comp:  comp,
+		layer: dl,
+		order: dl.nextOrder,
+	}
+	dl.nextOrder++
+	entry.bounded, _ = comp.(BoundedDrawAble)
+
+	dl.entries = append(dl.entries, entry)
+	dl.byComp[comp] = entry
+	dl.loose = append(dl.loose, entry)
+	dl.markDirty(entry)
+}
+
+// Queues the bounds of the entry to be updated in the next refresh
+func (dl *drawLayer) markDirty(entry *drawEntry) {
+	if entry.bounded == nil || entry.dirty {
+		return
+	}
+	entry.dirty = true
+	dl.dirty = append(dl.dirty, entry)
+}
+
+func (dl *drawLayer) remove(comp DrawAble) {
+	entry, ok := dl.byComp[comp]
+	if !ok {
+		return
+	}
+	delete(dl.byComp, comp)
+	if entry.transform != nil {
+		entry.transform.unwatch(entry)
+	}
+	if entry.dirty {
+		dl.dirty = removeEntry(dl.dirty, entry)
+	}
+
+	dl.entries = removeEntry(dl.entries, entry)
+	if entry.inGrid {
+		dl.removeFromGrid(entry)
+	} else {
+		dl.loose = removeEntry(dl.loose, entry)
+	}
+}
+
+func removeEntry(entries []*drawEntry, entry *drawEntry) []*drawEntry {
+	for k, v := range entries {
+		if v == entry {
+			return append(entries[:k], entries[k+1:]...)
+		}
+	}
+	return entries
+}
+
+func cellsFor(bounds Rect) (gridCell, gridCell) {
+	min := gridCell{int(math.Floor(bounds.X / GRIDCELLSIZE)), int(math.Floor(bounds.Y / GRIDCELLSIZE))}
+	max := gridCell{int(math.Floor(bounds.Right() / GRIDCELLSIZE)), int(math.Floor(bounds.Bottom() / GRIDCELLSIZE))}
+	return min, max
+}
+
+func (dl *drawLayer) addToGrid(entry *drawEntry) {
+	entry.cellMin, entry.cellMax = cellsFor(entry.bounds)
+	for x := entry.cellMin.X; x <= entry.cellMax.X; x++ {
+		for y := entry.cellMin.Y; y <= entry.cellMax.Y; y++ {
+			cell := gridCell{x, y}
+			dl.cells[cell] = append(dl.cells[cell], entry)
+		}
+	}
+	entry.inGrid = true
+	dl.inGrid++
+}
+
+func (dl *drawLayer) removeFromGrid(entry *drawEntry) {
+	for x := entry.cellMin.X; x <= entry.cellMax.X; x++ {
+		for y := entry.cellMin.Y; y <= entry.cellMax.Y; y++ {
+			cell := gridCell{x, y}
+			remaining := removeEntry(dl.cells[cell], entry)
+			if len(remaining) == 0 {
+				delete(dl.cells, cell)
+			} else {
+				dl.cells[cell] = remaining
+			}
+		}
+	}
+	entry.inGrid = false
+	dl.inGrid--
+}
+
+// Updates the bounds of the drawables in the dirty list
+func (dl *drawLayer) refresh() {
+	// Catches fields of the transform written directly, the setters mark them right away
+	for _, entry := range dl.entries {
+		if entry.transform != nil && !entry.dirty && entry.transform.Version() != entry.version {
+			dl.markDirty(entry)
+		}
+	}
+
+	// Getting the bounds can mark more entries, they're handled in the same loop
+	for k := 0; k < len(dl.dirty); k++ {
+		entry := dl.dirty[k]
+		dl.dirty[k] = nil
+		entry.dirty = false
+
+		// Drawables without a transform are always drawn, it's looked for again after UpdateBounds
+		if entry.transform == nil {
+			transform, ok := entry.comp.GetComponent("Transform").(*Transform)
+			if !ok {
+				continue
+			}
+			entry.transform = transform
+			transform.watch(entry)
+		}
+		entry.version = entry.transform.Version()
+		dl.updateBounds(entry)
+	}
+	dl.dirty = dl.dirty[:0]
+}
+
+func (dl *drawLayer) updateBounds(entry *drawEntry) {
+	bounds, screen := entry.bounded.Bounds()
+	if entry.inGrid && !screen && bounds == entry.bounds {
+		return
+	}
+	entry.bounds = bounds
+	entry.screen = screen
+	entry.hasBounds = true
+
+	wasInGrid := entry.inGrid
+	if entry.inGrid {
+		min, max := cellsFor(bounds)
+		if min == entry.cellMin && max == entry.cellMax && !screen {
+			return
+		}
+		dl.removeFromGrid(entry)
+	}
+
+	min, max := cellsFor(bounds)
+	numCells := (max.X - min.X + 1) * (max.Y - min.Y + 1)
+	if screen || numCells > MAXGRIDCELLS {
+		if wasInGrid {
+			dl.loose = append(dl.loose, entry)
+		}
+		return
+	}
+
+	if !wasInGrid {
+		dl.loose = removeEntry(dl.loose, entry)
+	}
+	dl.addToGrid(entry)
+}
+
+// Appends the drawables visible in view (world space) or screenView (screen space) to out
+// in the order they were added, also returns how many were culled
+func (dl *drawLayer) query(view, screenView Rect, out []*drawEntry) ([]*drawEntry, int) {
+	dl.visit++
+	culled := 0
+	found := 0
+
+	for _, entry := range dl.loose {
+		if entry.hasBounds {
+			bounds := view
+			if entry.screen {
+				bounds = screenView
+			}
+			if !entry.bounds.Intersects(bounds) {
+				culled++
+				continue
+			}
+		}
+		out = append(out, entry)
+	}
+
+	min, max := cellsFor(view)
+	for x := min.X; x <= max.X; x++ {
+		for y := min.Y; y <= max.Y; y++ {
+			for _, entry := range dl.cells[gridCell{x, y}] {
+				if entry.visit == dl.visit {
+					continue
+				}
+				entry.visit = dl.visit
+				if entry.bounds.Intersects(view) {
+					out = append(out, entry)
+					found++
+				}
+			}
+		}
+	}
+	culled += dl.inGrid - found
+
+	sort.Sort(drawOrder(out))
+	return out, culled
+}
+
+func (dl *drawLayer) all(out []*drawEntry) []*drawEntry {
+	return append(out, dl.entries...)
+}
+
+type drawOrder []*drawEntry
+
+func (d drawOrder) Len() int      { return len(d) }
+func (d drawOrder) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+func (d drawOrder) Less(i, j int) bool {
+	if d[i].group != d[j].group {
+		return d[i].group < d[j].group
+	}
+	return d[i].order < d[j].order
+}
+
+// Moves draws using the same texture next to the first draw using it so the renderer can batch them,
+// draws without a texture stay where they are
+func groupByTexture(entries []*drawEntry) {
+	groups := make(map[*sdl.Texture]int)
+	for k, entry := range entries {
+		entry.group = k
+		textured, ok := entry.comp.(TexturedDrawAble)
+		if !ok {
+			continue
+		}
+		texture := textured.DrawTexture()
+		if texture == nil {
+			continue
+		}
+		if group, ok := groups[texture]; ok {
+			entry.group = group
+		} else {
+			groups[texture] = k
+		}
+	}
+	sort.Sort(drawOrder(entries))
+
+	for _, entry := range entries {
+		entry.group = 0
+	}
+}
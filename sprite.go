@@ -3,6 +3,7 @@ package vroom
 import (
 	"fmt"
 	"github.com/veandco/go-sdl2/sdl"
+	"math"
 )
 
 // Simple sprite component for drawing sprites
@@ -66,20 +67,55 @@ func (s *Sprite) Draw(renderer *sdl.Renderer) {
 		return
 	}
 
-	position := casted.CalcPos()
+	position := casted.WorldPosition()
 	if !s.IgnoreCamera {
-		position.Sub(s.Parent.GetEngine().Camera)
+		position = position.Sub(s.Parent.GetEngine().Camera)
 	}
 
-	angle := casted.CalcAngle()
+	angle := casted.WorldAngle()
+	scale := casted.WorldScale()
 
-	//center := &sdl.Point{X: int32(position.X + vect.Float(s.Width/2)), Y: int32(position.Y + vect.Float(s.Height/2))}
-	center := &sdl.Point{X: int32(s.Width / 2), Y: int32(s.Height / 2)}
-	//center := &sdl.Point{X: int32(s.Width), Y: int32(s.Height)}
-	//center := &sdl.Point{}
+	var flip sdl.RendererFlip = sdl.FLIP_NONE
+	if scale.X < 0 {
+		flip |= sdl.FLIP_HORIZONTAL
+	}
+	if scale.Y < 0 {
+		flip |= sdl.FLIP_VERTICAL
+	}
+
+	w := int32(float64(s.Width) * math.Abs(scale.X))
+	h := int32(float64(s.Height) * math.Abs(scale.Y))
+
+	center := &sdl.Point{X: w / 2, Y: h / 2}
+	dstRect := &sdl.Rect{X: int32(position.X) - w/2, Y: int32(position.Y) - h/2, W: w, H: h}
+	renderer.CopyEx(s.Texture, nil, dstRect, angle, center, flip)
+}
+
+func (s *Sprite) Bounds() (Rect, bool) {
+	return spriteBounds(s, s.Width, s.Height), s.IgnoreCamera
+}
+
+func (s *Sprite) DrawTexture() *sdl.Texture {
+	return s.Texture
+}
+
+// Bounds of a w by h sprite centered on the transform
+func spriteBounds(comp Component, w, h int) Rect {
+	transformComp := comp.GetComponent("Transform")
+	if transformComp == nil {
+		return Rect{}
+	}
+	transform, ok := transformComp.(*Transform)
+	if !ok {
+		return Rect{}
+	}
+
+	local := Rect{-float64(w) / 2, -float64(h) / 2, float64(w), float64(h)}
+	return local.Transform(transform.WorldMatrix()).Grow(1)
+}
 
-	dstRect := &sdl.Rect{X: int32(position.X - float64(s.Width/2)), Y: int32(position.Y - float64(s.Height/2)), W: int32(s.Width), H: int32(s.Height)}
-	renderer.CopyEx(s.Texture, nil, dstRect, float64(angle), center, sdl.FLIP_NONE)
+func (s *Sprite) Size() (int, int) {
+	return s.Width, s.Height
 }
 
 func (s *Sprite) Name() string {
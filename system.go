@@ -87,33 +87,59 @@ func (bs *BaseSystem) LastCleanUp() time.Time {
 	return bs.lastCleanUp
 }
 
+// Range of layers the draw system draws
+const (
+	MINLAYER = -10
+	MAXLAYER = 9
+)
+
 // Some core systems
+// Components in each layer are drawn in the order they were added, components implementing
+// BoundedDrawAble are skipped when they're outside the view
 type DrawSystem struct {
-	components  map[int][]DrawAble
+	layers      map[int]*drawLayer
 	lastCleanUp time.Time
+
+	DisableCulling bool
+	// Puts draws using the same texture within a layer next to each other, which changes the draw order
+	// This only reorders the draws, the renderer merges them with the SDL_RENDER_BATCHING hint (SDL 2.0.10+)
+	Batching bool
+	Stats    DrawStats
+
+	engine  *Engine
+	visible []*drawEntry
+}
+
+func NewDrawSystem(e *Engine) *DrawSystem {
+	return &DrawSystem{
+		layers: make(map[int]*drawLayer),
+		engine: e,
+	}
 }
 
 func (ds *DrawSystem) Clear() {
-	ds.components = make(map[int][]DrawAble)
+	ds.unwatchAll()
+	ds.layers = make(map[int]*drawLayer)
+}
+
+// Stops the transforms from telling the entries of the removed layers
+func (ds *DrawSystem) unwatchAll() {
+	for _, layer := range ds.layers {
+		for _, entry := range layer.entries {
+			if entry.transform != nil {
+				entry.transform.unwatch(entry)
+			}
+		}
+	}
 }
 
 func (ds *DrawSystem) LastCleanUp() time.Time {
 	return ds.lastCleanUp
 }
 
-// cleanup remove nil components, maybe not needed since we do not do any expensive checking here
+// Nothing to clean up since nil components are never added
 func (ds *DrawSystem) CleanUp() {
 	ds.lastCleanUp = time.Now()
-	for k, layer := range ds.components {
-		newSlice := make([]DrawAble, 0)
-		for _, v := range layer {
-			if v == nil {
-				continue
-			}
-			newSlice = append(newSlice, v)
-		}
-		ds.components[k] = newSlice
-	}
 }
 
 func (ds *DrawSystem) AddComponent(component Component) {
@@ -122,15 +148,16 @@ func (ds *DrawSystem) AddComponent(component Component) {
 		return
 	}
 
-	layer := cast.GetLayer()
-
-	if ds.components == nil {
-		ds.components = make(map[int][]DrawAble)
+	if ds.layers == nil {
+		ds.layers = make(map[int]*drawLayer)
 	}
 
-	compSlice := ds.components[layer]
-	compSlice = append(compSlice, cast)
-	ds.components[layer] = compSlice
+	layer := ds.layers[cast.GetLayer()]
+	if layer == nil {
+		layer = newDrawLayer()
+		ds.layers[cast.GetLayer()] = layer
+	}
+	layer.add(cast)
 }
 
 func (ds *DrawSystem) RemoveComponent(component Component) {
@@ -139,48 +166,109 @@ func (ds *DrawSystem) RemoveComponent(component Component) {
 		return
 	}
 
-	layer := cast.GetLayer()
-
-	compSlice := ds.components[layer]
+	layer := ds.layers[cast.GetLayer()]
+	if layer != nil {
+		layer.remove(cast)
+	}
+}
 
-	index := -1
-	for k, v := range compSlice {
-		if v == component {
-			index = k
-			break
-		}
+// Bounds are only updated when the transform changes,
+// call this after changing the size of a component
+// The bounds are updated before the layer is drawn next
+func (ds *DrawSystem) UpdateBounds(component DrawAble) {
+	layer := ds.layers[component.GetLayer()]
+	if layer == nil {
+		return
 	}
-	if index != -1 {
-		if index == 0 {
-			compSlice = compSlice[1:]
-		} else if index == len(compSlice)-1 {
-			compSlice = compSlice[:len(compSlice)-1]
-		} else {
-			compSlice = append(compSlice[:index], compSlice[index+1:]...)
-		}
+	if entry, ok := layer.byComp[component]; ok {
+		layer.markDirty(entry)
 	}
-
-	ds.components[layer] = compSlice
 }
 
 func (ds *DrawSystem) ClearComponents() {
-	ds.components = nil
+	ds.unwatchAll()
+	ds.layers = nil
 }
 
 func (ds *DrawSystem) Draw(renderer *sdl.Renderer) {
-	for i := -10; i < 10; i++ {
-		compSlice := ds.components[i]
-		for _, comp := range compSlice {
-			if comp == nil {
-				ds.RemoveComponent(comp)
+	ds.DrawLayers(renderer, MINLAYER, MAXLAYER)
+}
+
+// Calls PreDraw on all the enabled components that implement PreDrawAble
+func (ds *DrawSystem) PreDraw(renderer *sdl.Renderer) {
+	ds.Stats = DrawStats{}
+
+	for i := MINLAYER; i <= MAXLAYER; i++ {
+		layer := ds.layers[i]
+		if layer == nil {
+			continue
+		}
+		for _, entry := range layer.entries {
+			cast, ok := entry.comp.(PreDrawAble)
+			if !ok {
 				continue
 			}
+			if cast.Enabled() && (cast.GetParent() != nil && cast.GetParent().Enabled()) {
+				cast.PreDraw(renderer)
+			}
+		}
+	}
+}
+
+// Draws the layers from-to (inclusive)
+func (ds *DrawSystem) DrawLayers(renderer *sdl.Renderer, from, to int) {
+	cull := ds.engine != nil && !ds.DisableCulling
+	var view, screenView Rect
+	if cull {
+		view = ds.engine.ViewRect(false)
+		screenView = ds.engine.ViewRect(true)
+	}
+
+	for i := from; i <= to; i++ {
+		layer := ds.layers[i]
+		if layer == nil {
+			continue
+		}
 
-			if comp.Enabled() && (comp.GetParent() != nil && comp.GetParent().Enabled()) {
-				comp.Draw(renderer)
+		if cull {
+			layer.refresh()
+			culled := 0
+			ds.visible, culled = layer.query(view, screenView, ds.visible[:0])
+			ds.Stats.Culled += culled
+		} else {
+			ds.visible = layer.all(ds.visible[:0])
+		}
+
+		if ds.Batching {
+			groupByTexture(ds.visible)
+		}
+
+		var lastTexture *sdl.Texture
+		for _, entry := range ds.visible {
+			comp := entry.comp
+			if !comp.Enabled() || comp.GetParent() == nil || !comp.GetParent().Enabled() {
+				continue
 			}
+
+			if textured, ok := comp.(TexturedDrawAble); ok {
+				texture := textured.DrawTexture()
+				if texture != lastTexture {
+					ds.Stats.Batches++
+					lastTexture = texture
+				}
+			} else {
+				lastTexture = nil
+			}
+
+			comp.Draw(renderer)
+			ds.Stats.Drawn++
 		}
 	}
+
+	// Don't keep components alive through the reused slice
+	for k := range ds.visible {
+		ds.visible[k] = nil
+	}
 }
 
 type UpdateSystem struct {
@@ -211,6 +299,7 @@ func (us *UpdateSystem) Update(dt float64) {
 
 type MouseClickSystem struct {
 	BaseSystem
+	Blocked func(Component) bool // Components it returns true for get no clicks, set to UISystem.Blocked by the engine
 }
 
 func (mc *MouseClickSystem) AddComponent(component Component) {
@@ -223,10 +312,12 @@ func (mc *MouseClickSystem) AddComponent(component Component) {
 	}
 }
 
-func (mc *MouseClickSystem) MouseButtonEvent(x, y, button int, up bool) {
+// Returns true if the click was inside the MouseBox of a widget
+func (mc *MouseClickSystem) MouseButtonEvent(x, y, button int, up bool) bool {
+	onWidget := false
 	mc.ForEachComponent(func(comp Component) bool {
 		cast, ok := comp.(MouseClickListener)
-		if !ok {
+		if !ok || (mc.Blocked != nil && mc.Blocked(comp)) {
 			return false
 		}
 
@@ -236,12 +327,15 @@ func (mc *MouseClickSystem) MouseButtonEvent(x, y, button int, up bool) {
 		if mboxComp != nil && transformComp != nil {
 			transform, ok := transformComp.(*Transform)
 			mbox, ok2 := mboxComp.(*MouseBox)
-			position := transform.Position
-			position.X -= float64(mbox.W / 2)
-			position.Y -= float64(mbox.H / 2)
 			if ok && ok2 {
+				position := transform.WorldPosition()
+				position.X -= float64(mbox.W / 2)
+				position.Y -= float64(mbox.H / 2)
 				if x > int(position.X) && x < int(position.X)+mbox.W &&
 					y > int(position.Y) && y < int(position.Y)+mbox.H {
+					if _, ok := comp.(uiWidget); ok {
+						onWidget = true
+					}
 					if up {
 						cast.MouseUp(x, y, button)
 					} else {
@@ -258,10 +352,12 @@ func (mc *MouseClickSystem) MouseButtonEvent(x, y, button int, up bool) {
 		}
 		return true
 	})
+	return onWidget
 }
 
 type MouseHoverSystem struct {
 	BaseSystem
+	Blocked func(Component) bool // Components it returns true for are left and get no moves
 }
 
 func (mh *MouseHoverSystem) AddComponent(component Component) {
@@ -285,11 +381,12 @@ func (mh *MouseHoverSystem) MouseMove(x, y int) {
 		if mboxComp != nil && transformComp != nil {
 			transform, ok := transformComp.(*Transform)
 			mbox, ok2 := mboxComp.(*MouseBox)
-			position := transform.Position
-			position.X -= float64(mbox.W / 2)
-			position.Y -= float64(mbox.H / 2)
 			if ok && ok2 {
-				if x > int(position.X) && x < int(position.X)+mbox.W &&
+				position := transform.WorldPosition()
+				position.X -= float64(mbox.W / 2)
+				position.Y -= float64(mbox.H / 2)
+				blocked := mh.Blocked != nil && mh.Blocked(comp)
+				if !blocked && x > int(position.X) && x < int(position.X)+mbox.W &&
 					y > int(position.Y) && y < int(position.Y)+mbox.H {
 					if !mbox.Active {
 						cast.MouseEnter()
@@ -303,16 +400,61 @@ func (mh *MouseHoverSystem) MouseMove(x, y int) {
 					}
 				}
 			}
-		} else {
+		} else if mh.Blocked == nil || !mh.Blocked(comp) {
 			cast.MouseMove(x, y)
 		}
 		return true
 	})
 }
 
+type MouseWheelSystem struct {
+	BaseSystem
+	Blocked func(Component) bool // Components it returns true for don't get scrolled
+}
+
+func (mw *MouseWheelSystem) AddComponent(component Component) {
+	_, ok := component.(MouseWheelListener)
+	if ok {
+		if mw.Components == nil {
+			mw.Components = make([]Component, 0)
+		}
+		mw.Components = append(mw.Components, component)
+	}
+}
+
+// x and y is where the mouse is
+func (mw *MouseWheelSystem) MouseWheelEvent(x, y int, dx, dy float64) {
+	mw.ForEachComponent(func(comp Component) bool {
+		cast, ok := comp.(MouseWheelListener)
+		if !ok || (mw.Blocked != nil && mw.Blocked(comp)) {
+			return false
+		}
+
+		mboxComp := cast.GetComponent("MouseBox")
+		transformComp := cast.GetComponent("Transform")
+		if mboxComp != nil && transformComp != nil {
+			transform, ok := transformComp.(*Transform)
+			mbox, ok2 := mboxComp.(*MouseBox)
+			if ok && ok2 {
+				position := transform.WorldPosition()
+				position.X -= float64(mbox.W / 2)
+				position.Y -= float64(mbox.H / 2)
+				if x > int(position.X) && x < int(position.X)+mbox.W &&
+					y > int(position.Y) && y < int(position.Y)+mbox.H {
+					cast.MouseWheel(x, y, dx, dy)
+				}
+			}
+		} else {
+			cast.MouseWheel(x, y, dx, dy)
+		}
+		return true
+	})
+}
+
 type KeyboardSystem struct {
 	BaseSystem
-	Keys map[sdl.Keycode]bool
+	Keys    map[sdl.Keycode]bool
+	Blocked func(Component) bool // Components it returns true for get no key downs, key ups always go through
 }
 
 func (kb *KeyboardSystem) AddComponent(component Component) {
@@ -340,7 +482,7 @@ func (kb *KeyboardSystem) KeyboardEvent(key sdl.Keycode, up bool) {
 
 		if up {
 			casted.KeyUp(key)
-		} else {
+		} else if kb.Blocked == nil || !kb.Blocked(comp) {
 			casted.KeyDown(key)
 		}
 		return true
@@ -0,0 +1,465 @@
+package vroom
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Seconds the caret is shown and then hidden
+const CARETBLINK = 0.5
+
+// Single line text field, clicking it gives it the focus which starts SDL text input
+// Supports selecting with the mouse or shift, ctrl for whole words, ctrl+a/c/x/v for
+// select all and the clipboard, and composition text from input methods
+type TextInput struct {
+	Widget
+	Placeholder string // Shown while the text is empty
+	MaxLength   int    // In characters, 0 for no limit
+	Password    bool   // Shows every character as a *
+
+	// Only characters it returns true for can be typed or pasted, nil allows everything
+	Filter func(r rune) bool
+	// Called with the new text before every change, returning false rejects it
+	Validate func(text string) bool
+
+	OnChanged func(text string)
+	OnSubmit  func(text string) // Enter was pressed
+
+	Caret     int // Position in characters
+	Selection int // Other end of the selection, same as Caret when nothing is selected
+
+	composition      string
+	compositionCaret int
+	selecting        bool
+	scroll           float64
+	blink            float64
+}
+
+func NewTextInput(text, placeholder string, onChanged func(text string)) *TextInput {
+	input := &TextInput{
+		Widget:      Widget{Text: text, Layer: UILAYER},
+		Placeholder: placeholder,
+		OnChanged:   onChanged,
+	}
+	input.Caret = len([]rune(text))
+	input.Selection = input.Caret
+	return input
+}
+
+// Only digits
+func FilterDigits(r rune) bool {
+	return unicode.IsDigit(r)
+}
+
+// Letters and digits
+func FilterAlphaNumeric(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (t *TextInput) Init() {
+	t.initWidget(t)
+}
+
+func (t *TextInput) Name() string {
+	return "TextInput"
+}
+
+func (t *TextInput) FocusGained() {
+	t.Widget.FocusGained()
+	t.blink = 0
+	t.engine().UI.SetTextInputRect(t.Rect())
+}
+
+func (t *TextInput) FocusLost() {
+	t.Widget.FocusLost()
+	t.composition = ""
+	t.Selection = t.Caret
+}
+
+// Replaces the text, the caret is moved to the end
+func (t *TextInput) SetText(text string) {
+	t.Text = text
+	t.Caret = len([]rune(text))
+	t.Selection = t.Caret
+}
+
+// Shows the text for key like Widget.Relocalize, moving the caret to the end like SetText
+func (t *TextInput) Relocalize() {
+	if t.Key != "" && t.Parent != nil && t.Parent.GetEngine() != nil {
+		t.SetText(t.localize(t.Parent.GetEngine()))
+	}
+}
+
+// Keeps the caret and selection inside the text, Text can be changed without them
+func (t *TextInput) clampCaret() {
+	length := len([]rune(t.Text))
+	if t.Caret < 0 {
+		t.Caret = 0
+	} else if t.Caret > length {
+		t.Caret = length
+	}
+	if t.Selection < 0 {
+		t.Selection = 0
+	} else if t.Selection > length {
+		t.Selection = length
+	}
+}
+
+// Start and end of the selection
+func (t *TextInput) SelectionRange() (int, int) {
+	if t.Selection < t.Caret {
+		return t.Selection, t.Caret
+	}
+	return t.Caret, t.Selection
+}
+
+func (t *TextInput) SelectedText() string {
+	t.clampCaret()
+	start, end := t.SelectionRange()
+	return string([]rune(t.Text)[start:end])
+}
+
+func (t *TextInput) SelectAll() {
+	t.Selection = 0
+	t.Caret = len([]rune(t.Text))
+}
+
+// Replaces the selection with text, after running it through Filter, MaxLength and Validate
+func (t *TextInput) Insert(text string) {
+	t.clampCaret()
+	runes := []rune(t.Text)
+	start, end := t.SelectionRange()
+
+	insert := make([]rune, 0, len(text))
+	for _, r := range text {
+		if r == '\n' || r == '\r' || (t.Filter != nil && !t.Filter(r)) {
+			continue
+		}
+		insert = append(insert, r)
+	}
+	if t.MaxLength > 0 {
+		room := t.MaxLength - (len(runes) - (end - start))
+		if room < 0 {
+			room = 0
+		}
+		if len(insert) > room {
+			insert = insert[:room]
+		}
+	}
+	if len(insert) < 1 && start == end {
+		return
+	}
+
+	newRunes := make([]rune, 0, len(runes)+len(insert))
+	newRunes = append(newRunes, runes[:start]...)
+	newRunes = append(newRunes, insert...)
+	newRunes = append(newRunes, runes[end:]...)
+	t.change(string(newRunes), start+len(insert))
+}
+
+// Deletes the selection, or from the caret to the position if nothing is selected
+func (t *TextInput) deleteTo(position int) {
+	t.clampCaret()
+	start, end := t.SelectionRange()
+	if start == end {
+		start, end = t.Caret, position
+		if end < start {
+			start, end = end, start
+		}
+	}
+	runes := []rune(t.Text)
+	if start < 0 || end > len(runes) || start == end {
+		return
+	}
+	t.change(string(runes[:start])+string(runes[end:]), start)
+}
+
+func (t *TextInput) change(text string, caret int) {
+	if t.Validate != nil && !t.Validate(text) {
+		return
+	}
+	t.Text = text
+	t.Caret = caret
+	t.Selection = caret
+	t.blink = 0
+	if t.OnChanged != nil {
+		t.OnChanged(text)
+	}
+}
+
+// Moves the caret, extending the selection if extend is set
+func (t *TextInput) moveCaret(position int, extend bool) {
+	length := len([]rune(t.Text))
+	if position < 0 {
+		position = 0
+	} else if position > length {
+		position = length
+	}
+	t.Caret = position
+	if !extend {
+		t.Selection = position
+	}
+	t.blink = 0
+}
+
+// Position of the start of the next word in dir (-1 or 1)
+func (t *TextInput) wordBoundary(dir int) int {
+	runes := []rune(t.Text)
+	i := t.Caret
+	if dir < 0 {
+		for i > 0 && unicode.IsSpace(runes[i-1]) {
+			i--
+		}
+		for i > 0 && !unicode.IsSpace(runes[i-1]) {
+			i--
+		}
+		return i
+	}
+	for i < len(runes) && !unicode.IsSpace(runes[i]) {
+		i++
+	}
+	for i < len(runes) && unicode.IsSpace(runes[i]) {
+		i++
+	}
+	return i
+}
+
+func (t *TextInput) TextInput(text string) {
+	t.composition = ""
+	t.Insert(text)
+}
+
+func (t *TextInput) TextEditing(text string, start, length int) {
+	t.composition = text
+	t.compositionCaret = start
+	t.blink = 0
+}
+
+func (t *TextInput) KeyDown(key sdl.Keycode) {
+	if !t.focused || t.Disabled || t.composition != "" {
+		return
+	}
+	t.clampCaret()
+
+	mod := sdl.GetModState()
+	shift := mod&sdl.KMOD_SHIFT != 0
+	ctrl := mod&(sdl.KMOD_CTRL|sdl.KMOD_GUI) != 0
+	start, end := t.SelectionRange()
+
+	switch key {
+	case sdl.K_LEFT:
+		switch {
+		case ctrl:
+			t.moveCaret(t.wordBoundary(-1), shift)
+		case start != end && !shift:
+			t.moveCaret(start, false)
+		default:
+			t.moveCaret(t.Caret-1, shift)
+		}
+	case sdl.K_RIGHT:
+		switch {
+		case ctrl:
+			t.moveCaret(t.wordBoundary(1), shift)
+		case start != end && !shift:
+			t.moveCaret(end, false)
+		default:
+			t.moveCaret(t.Caret+1, shift)
+		}
+	case sdl.K_HOME:
+		t.moveCaret(0, shift)
+	case sdl.K_END:
+		t.moveCaret(len([]rune(t.Text)), shift)
+	case sdl.K_BACKSPACE:
+		if ctrl {
+			t.deleteTo(t.wordBoundary(-1))
+		} else {
+			t.deleteTo(t.Caret - 1)
+		}
+	case sdl.K_DELETE:
+		if ctrl {
+			t.deleteTo(t.wordBoundary(1))
+		} else {
+			t.deleteTo(t.Caret + 1)
+		}
+	case sdl.K_RETURN, sdl.K_KP_ENTER:
+		if t.OnSubmit != nil {
+			t.OnSubmit(t.Text)
+		}
+	case sdl.K_ESCAPE:
+		t.engine().UI.ClearFocus()
+	case sdl.K_a:
+		if ctrl {
+			t.SelectAll()
+		}
+	case sdl.K_c:
+		if ctrl && start != end && !t.Password {
+			sdl.SetClipboardText(t.SelectedText())
+		}
+	case sdl.K_x:
+		if ctrl && start != end && !t.Password {
+			sdl.SetClipboardText(t.SelectedText())
+			t.deleteTo(t.Caret)
+		}
+	case sdl.K_v:
+		if ctrl {
+			text, err := sdl.GetClipboardText()
+			if err == nil {
+				t.Insert(text)
+			}
+		}
+	}
+}
+
+func (t *TextInput) KeyUp(key sdl.Keycode) {}
+
+// What's drawn, the text or stars for passwords
+func (t *TextInput) displayText() string {
+	if t.Password {
+		return strings.Repeat("*", len([]rune(t.Text)))
+	}
+	return t.Text
+}
+
+// X offset of the character position from the start of the text
+func (t *TextInput) offsetOf(position int) float64 {
+	font := t.font()
+	if font == nil {
+		return 0
+	}
+	runes := []rune(t.displayText())
+	if position > len(runes) {
+		position = len(runes)
+	}
+	return float64(font.Measure(string(runes[:position])))
+}
+
+// Character position closest to the screen x coordinate
+func (t *TextInput) positionAt(x int) int {
+	local := float64(x) - t.contentRect().X + t.scroll
+	runes := []rune(t.displayText())
+	for i := range runes {
+		left := t.offsetOf(i)
+		right := t.offsetOf(i + 1)
+		if local < (left+right)/2 {
+			return i
+		}
+	}
+	return len(runes)
+}
+
+func (t *TextInput) press(x, y int) {
+	if !t.focused {
+		t.Focus()
+	}
+	t.moveCaret(t.positionAt(x), sdl.GetModState()&sdl.KMOD_SHIFT != 0)
+	t.selecting = true
+	t.engine().UI.CaptureMouse(t)
+}
+
+func (t *TextInput) CapturedMouseMove(x, y int) {
+	if t.selecting {
+		t.moveCaret(t.positionAt(x), true)
+	}
+}
+
+func (t *TextInput) CapturedMouseButton(x, y, button int, up bool) {
+	if up && button == sdl.BUTTON_LEFT {
+		t.selecting = false
+		t.engine().UI.ReleaseMouse(t)
+		t.IsHover = t.Rect().Contains(float64(x), float64(y))
+		t.release()
+	}
+}
+
+func (t *TextInput) Update(dt float64) {
+	t.blink += dt
+	if t.blink >= CARETBLINK*2 {
+		t.blink -= CARETBLINK * 2
+	}
+}
+
+// Keeps the caret inside the visible part of the text
+func (t *TextInput) updateScroll(width float64) {
+	caretX := t.offsetOf(t.Caret)
+	if t.composition != "" {
+		if font := t.font(); font != nil {
+			caretX += float64(font.Measure(t.composition))
+		}
+	}
+	if caretX-t.scroll > width-1 {
+		t.scroll = caretX - width + 1
+	}
+	if caretX-t.scroll < 0 {
+		t.scroll = caretX
+	}
+	textWidth := t.offsetOf(len([]rune(t.Text)))
+	t.scroll = math.Max(0, math.Min(t.scroll, math.Max(0, textWidth-width+1)))
+}
+
+func (t *TextInput) Size() (int, int) {
+	style := t.style()
+	return 200 + style.Padding.Horizontal(), t.lineHeight() + style.Padding.Vertical()
+}
+
+func (t *TextInput) Draw(renderer *sdl.Renderer) {
+	state := t.State()
+	style := t.style()
+	stateStyle := t.stateStyle(state)
+
+	rect := t.Rect()
+	t.drawFrame(renderer, rect, state)
+	if t.focused {
+		outlineRect(renderer, rect, style.Accent)
+	}
+
+	font := t.font()
+	if font == nil {
+		return
+	}
+	content := t.contentRect()
+	t.clampCaret()
+	t.updateScroll(content.W)
+
+	defer clipRect(renderer, content)()
+
+	textY := content.Y + math.Floor((content.H-float64(font.LineHeight))/2)
+	originX := content.X - t.scroll
+
+	if t.Text == "" && t.composition == "" {
+		font.DrawLine(renderer, t.Placeholder, content.X, textY, 1, style.States[WIDGETDISABLED].TextColor)
+	}
+
+	start, end := t.SelectionRange()
+	if t.focused && start != end {
+		selection := style.Accent
+		selection.A = 128
+		x1, x2 := t.offsetOf(start), t.offsetOf(end)
+		fillRect(renderer, Rect{originX + x1, textY, x2 - x1, float64(font.LineHeight)}, selection)
+	}
+
+	// The composition text is shown at the caret, underlined, and pushes the rest of the text to the right
+	runes := []rune(t.displayText())
+	before := string(runes[:t.Caret])
+	after := string(runes[t.Caret:])
+	font.DrawLine(renderer, before, originX, textY, 1, stateStyle.TextColor)
+	x := originX + float64(font.Measure(before))
+	caretX := x
+	if t.composition != "" {
+		compositionWidth := float64(font.Measure(t.composition))
+		font.DrawLine(renderer, t.composition, x, textY, 1, stateStyle.TextColor)
+		fillRect(renderer, Rect{x, textY + float64(font.LineHeight) - 1, compositionWidth, 1}, stateStyle.TextColor)
+
+		compositionRunes := []rune(t.composition)
+		if t.compositionCaret >= 0 && t.compositionCaret <= len(compositionRunes) {
+			caretX = x + float64(font.Measure(string(compositionRunes[:t.compositionCaret])))
+		}
+		x += compositionWidth
+	}
+	font.DrawLine(renderer, after, x, textY, 1, stateStyle.TextColor)
+
+	if t.focused && t.blink < CARETBLINK {
+		fillRect(renderer, Rect{math.Floor(caretX), textY, 1, float64(font.LineHeight)}, stateStyle.TextColor)
+	}
+}
@@ -0,0 +1,335 @@
+package vroom
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/veandco/go-sdl2/sdl"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Name of the style every other style in a theme is based on
+const THEMEDEFAULT = "default"
+
+// Named widget styles, widgets use the style named by their StyleName or their Name (Button, Checkbox...)
+// and fall back to the default style of the theme
+type Theme struct {
+	Name   string
+	Styles map[string]*WidgetStyle
+}
+
+func NewTheme(name string) *Theme {
+	return &Theme{
+		Name:   name,
+		Styles: make(map[string]*WidgetStyle),
+	}
+}
+
+// Returns the first of the names the theme has a style for, or the default style (nil if there's none)
+func (t *Theme) Style(names ...string) *WidgetStyle {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if style, ok := t.Styles[name]; ok {
+			return style
+		}
+	}
+	return t.Styles[THEMEDEFAULT]
+}
+
+// Implemented by components that change with the theme, see UISystem.SetTheme
+type Themeable interface {
+	Component
+	ApplyTheme()
+}
+
+var widgetStateNames = map[string]int{
+	"idle":     WIDGETIDLE,
+	"hover":    WIDGETHOVER,
+	"pressed":  WIDGETPRESSED,
+	"disabled": WIDGETDISABLED,
+	"focused":  WIDGETFOCUSED,
+}
+
+// Colors in themes are strings like ParseColor takes or {"r": 255, "g": 0, "b": 0, "a": 255}
+type themeColor struct {
+	*sdl.Color
+}
+
+func (c *themeColor) UnmarshalJSON(raw []byte) error {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		color, err := ParseColor(s)
+		c.Color = &color
+		return err
+	}
+	c.Color = &sdl.Color{A: 255}
+	return json.Unmarshal(raw, c.Color)
+}
+
+// Insets in themes are a single number or [left, top, right, bottom]
+type themeInsets struct {
+	*Insets
+}
+
+func (i *themeInsets) UnmarshalJSON(raw []byte) error {
+	var uniform int
+	if err := json.Unmarshal(raw, &uniform); err == nil {
+		insets := UniformInsets(uniform)
+		i.Insets = &insets
+		return nil
+	}
+
+	var values []int
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return err
+	}
+	if len(values) != 4 {
+		return fmt.Errorf("Insets need 4 values, got %d", len(values))
+	}
+	i.Insets = &Insets{values[0], values[1], values[2], values[3]}
+	return nil
+}
+
+type themeStateJSON struct {
+	Texture *string    `json:"texture"`
+	Fill    themeColor `json:"fill"`
+	Border  themeColor `json:"border"`
+	Text    themeColor `json:"text"`
+}
+
+// Everything is optional, missing values are taken from the style it extends
+type themeStyleJSON struct {
+	Extends      string                    `json:"extends"`
+	Font         *string                   `json:"font"`
+	Outline      *string                   `json:"outline"`
+	OutlineColor themeColor                `json:"outline_color"`
+	Padding      themeInsets               `json:"padding"`
+	Slice        themeInsets               `json:"slice"`
+	Accent       themeColor                `json:"accent"`
+	ClickSound   *string                   `json:"click_sound"`
+	HoverSound   *string                   `json:"hover_sound"`
+	States       map[string]themeStateJSON `json:"states"`
+}
+
+type themeJSON struct {
+	Name   string                    `json:"name"`
+	Styles map[string]themeStyleJSON `json:"styles"`
+}
+
+// Loads a theme from a json file:
+// {"name": "dark", "styles": {"default": {"font": "mainfont", "padding": [8, 4, 8, 4], "accent": "#5aa0e6",
+// "states": {"idle": {"fill": "#3c3c46", "border": "#6e6e7d", "text": "white"}}}, "title": {"extends": "Label", ...}}}
+// Styles extend the default style unless extends names another one, the default style
+// extends DefaultWidgetStyle. The name is taken from the file name if it's not set
+func LoadTheme(path string) (*Theme, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded themeJSON
+	err = json.Unmarshal(raw, &decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	name := decoded.Name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return buildTheme(name, decoded.Styles, nil)
+}
+
+// Resolves the styles from a theme file, also used for the styles in ui files
+// If inherit is set styles that don't extend one from the file build on inherit(name),
+// and extends can name styles that aren't in the file
+func buildTheme(name string, styles map[string]themeStyleJSON, inherit func(name string) *WidgetStyle) (*Theme, error) {
+	theme := NewTheme(name)
+
+	resolving := make(map[string]bool)
+	var resolve func(name string) (*WidgetStyle, error)
+	resolve = func(name string) (*WidgetStyle, error) {
+		if style, ok := theme.Styles[name]; ok {
+			return style, nil
+		}
+		raw, ok := styles[name]
+		if !ok {
+			if inherit != nil {
+				return inherit(name), nil
+			}
+			if name == THEMEDEFAULT {
+				theme.Styles[name] = DefaultWidgetStyle()
+				return theme.Styles[name], nil
+			}
+			return nil, fmt.Errorf("Unknown style %q", name)
+		}
+		if resolving[name] {
+			return nil, fmt.Errorf("Style %q extends itself", name)
+		}
+		resolving[name] = true
+
+		var base *WidgetStyle
+		var err error
+		switch {
+		case raw.Extends != "":
+			base, err = resolve(raw.Extends)
+		case inherit != nil:
+			base = inherit(name)
+		case name != THEMEDEFAULT:
+			base, err = resolve(THEMEDEFAULT)
+		default:
+			base = DefaultWidgetStyle()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		style, err := raw.apply(*base)
+		if err != nil {
+			return nil, fmt.Errorf("Style %q: %s", name, err)
+		}
+		theme.Styles[name] = style
+		return style, nil
+	}
+
+	// Sorted so errors are the same every time
+	names := make([]string, 0, len(styles))
+	for name := range styles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := resolve(name); err != nil {
+			return nil, err
+		}
+	}
+	return theme, nil
+}
+
+// Returns a copy of base with the values that are set
+func (s themeStyleJSON) apply(base WidgetStyle) (*WidgetStyle, error) {
+	style := base
+	if s.Font != nil {
+		style.Font = *s.Font
+	}
+	if s.Outline != nil {
+		style.Outline = *s.Outline
+	}
+	if s.OutlineColor.Color != nil {
+		style.OutlineColor = *s.OutlineColor.Color
+	}
+	if s.Padding.Insets != nil {
+		style.Padding = *s.Padding.Insets
+	}
+	if s.Slice.Insets != nil {
+		style.Slice = *s.Slice.Insets
+	}
+	if s.Accent.Color != nil {
+		style.Accent = *s.Accent.Color
+	}
+	if s.ClickSound != nil {
+		style.ClickSound = *s.ClickSound
+	}
+	if s.HoverSound != nil {
+		style.HoverSound = *s.HoverSound
+	}
+
+	for name, raw := range s.States {
+		state, ok := widgetStateNames[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown state %q", name)
+		}
+		stateStyle := &style.States[state]
+		if raw.Texture != nil {
+			stateStyle.Texture = *raw.Texture
+		}
+		if raw.Fill.Color != nil {
+			stateStyle.Fill = *raw.Fill.Color
+		}
+		if raw.Border.Color != nil {
+			stateStyle.Border = *raw.Border.Color
+		}
+		if raw.Text.Color != nil {
+			stateStyle.TextColor = *raw.Text.Color
+		}
+	}
+	return &style, nil
+}
+
+// Adds a theme that can be switched to with SetTheme, replacing one with the same name
+// If it replaces the current theme the widgets are updated right away
+func (ui *UISystem) AddTheme(theme *Theme) {
+	if ui.themes == nil {
+		ui.themes = make(map[string]*Theme)
+	}
+	ui.themes[theme.Name] = theme
+	if ui.theme != nil && ui.theme.Name == theme.Name {
+		ui.theme = theme
+		ui.restyleScreens()
+		ui.ApplyTheme()
+	}
+}
+
+// Loads a theme file with LoadTheme and adds it
+func (ui *UISystem) LoadTheme(path string) error {
+	theme, err := LoadTheme(path)
+	if err != nil {
+		return err
+	}
+	ui.AddTheme(theme)
+	return nil
+}
+
+// Switches to a theme added with AddTheme, "" switches back to DefaultStyle only
+// Returns false if there's no theme with that name
+func (ui *UISystem) SetTheme(name string) bool {
+	if name == "" {
+		ui.theme = nil
+	} else {
+		theme, ok := ui.themes[name]
+		if !ok {
+			return false
+		}
+		ui.theme = theme
+	}
+	ui.restyleScreens()
+	ui.ApplyTheme()
+	if ui.OnThemeChanged != nil {
+		ui.OnThemeChanged(name)
+	}
+	return true
+}
+
+func (ui *UISystem) Theme() *Theme {
+	return ui.theme
+}
+
+// Names of the added themes, sorted
+func (ui *UISystem) Themes() []string {
+	names := make([]string, 0, len(ui.themes))
+	for name := range ui.themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Style for the names from the current theme, nil if there's no theme
+func (ui *UISystem) ThemeStyle(names ...string) *WidgetStyle {
+	if ui.theme == nil {
+		return nil
+	}
+	return ui.theme.Style(names...)
+}
+
+// Lets every themeable component pick up the current theme, call it after changing
+// a style in a way that changes sizes or label fonts, colors are picked up without it
+func (ui *UISystem) ApplyTheme() {
+	for _, themeable := range ui.themeables {
+		themeable.ApplyTheme()
+	}
+}
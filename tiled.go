@@ -0,0 +1,773 @@
+package vroom
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/jonas747/go-box2d-lite/box2dlite"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Object from a object layer in a Tiled map, positions are in pixels relative to the map
+type TiledObject struct {
+	ID            int
+	Name          string
+	Type          string
+	X, Y          float64
+	Width, Height float64
+	Rotation      float64
+	GID           uint32 // Set for tile objects
+	Visible       bool
+	Properties    map[string]string
+	Polygon       []box2dlite.Vec2 // Points relative to X, Y
+	Polyline      []box2dlite.Vec2
+	Ellipse       bool
+	Point         bool
+	Layer         *ObjectLayer
+}
+
+// Center of the object, tile objects are positioned by their bottom left corner in Tiled
+func (o *TiledObject) Center() box2dlite.Vec2 {
+	if o.GID != 0 {
+		return box2dlite.Vec2{o.X + o.Width/2, o.Y - o.Height/2}
+	}
+	return box2dlite.Vec2{o.X + o.Width/2, o.Y + o.Height/2}
+}
+
+type ObjectLayer struct {
+	Name       string
+	Visible    bool
+	Objects    []*TiledObject
+	Properties map[string]string
+}
+
+// Creates a entity from a object in a Tiled map, returning nil skips the object
+type ObjectFactory func(obj *TiledObject) Entity
+
+// Registers a factory for objects with this type (or class) in Tiled maps
+func (e *Engine) RegisterObjectType(name string, factory ObjectFactory) {
+	if e.ObjectTypes == nil {
+		e.ObjectTypes = make(map[string]ObjectFactory)
+	}
+	e.ObjectTypes[name] = factory
+}
+
+// Loads a map made with Tiled, both the .tmx and .json formats are supported
+// Returns a entity with the Tilemap component, objects with a registered type are added as children
+func (e *Engine) LoadTiledMap(path string) (Entity, *Tilemap, error) {
+	var tilemap *Tilemap
+	var err error
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tmx":
+		tilemap, err = e.loadTMX(path)
+	default:
+		tilemap, err = e.loadTiledJSON(path)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ent := NewEntity(0, 0)
+	ent.AddComponent(tilemap)
+
+	for _, layer := range tilemap.ObjectLayers {
+		for _, obj := range layer.Objects {
+			factory := e.ObjectTypes[obj.Type]
+			if factory == nil {
+				continue
+			}
+			child := factory(obj)
+			if child != nil {
+				ent.AddChild(child, false)
+			}
+		}
+	}
+
+	return ent, tilemap, nil
+}
+
+// Loads the tileset image as a texture, reusing it if its allready loaded
+func (e *Engine) loadTilesetTexture(ts *Tileset, dir, image string, imageWidth int) error {
+	if image == "" {
+		return errors.New("Tileset " + ts.Name + " has no image, image collection tilesets are not supported")
+	}
+
+	path := filepath.Join(dir, image)
+	texture := e.GetTexture(path)
+	if texture == nil {
+		err := e.LoadTexture(path, path)
+		if err != nil {
+			return err
+		}
+		texture = e.GetTexture(path)
+	}
+	ts.Texture = texture
+
+	if ts.Columns == 0 && ts.TileWidth > 0 {
+		if imageWidth == 0 {
+			_, _, imageWidth, _, _ = texture.Query()
+		}
+		ts.Columns = (imageWidth - ts.Margin*2 + ts.Spacing) / (ts.TileWidth + ts.Spacing)
+	}
+	return nil
+}
+
+// Decodes csv or base64 (optionally zlib or gzip compressed) tile data
+func decodeTileData(data, encoding, compression string, count int) ([]uint32, error) {
+	switch encoding {
+	case "csv":
+		tiles := make([]uint32, 0, count)
+		for _, field := range strings.Split(data, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			gid, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			tiles = append(tiles, uint32(gid))
+		}
+		return tiles, nil
+	case "base64":
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+		if err != nil {
+			return nil, err
+		}
+
+		var reader io.Reader = bytes.NewReader(raw)
+		switch compression {
+		case "zlib":
+			reader, err = zlib.NewReader(reader)
+		case "gzip":
+			reader, err = gzip.NewReader(reader)
+		case "":
+		default:
+			return nil, errors.New("Unsupported tile data compression: " + compression)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		tiles := make([]uint32, count)
+		err = binary.Read(reader, binary.LittleEndian, tiles)
+		if err != nil {
+			return nil, err
+		}
+		return tiles, nil
+	}
+	return nil, errors.New("Unsupported tile data encoding: " + encoding)
+}
+
+func newTileLayer(name string, w, h int, visible bool, opacity float64, tiles []uint32, props map[string]string) (*TileLayer, error) {
+	if len(tiles) != w*h {
+		return nil, fmt.Errorf("Tile layer %s has %d tiles, expected %d", name, len(tiles), w*h)
+	}
+
+	layer := NewTileLayer(name, w, h)
+	layer.Tiles = tiles
+	layer.Visible = visible
+	layer.Opacity = opacity
+	if props != nil {
+		layer.Properties = props
+	}
+	return layer, nil
+}
+
+// Tiled JSON format
+
+type tiledJSONProperty struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type tiledJSONPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type tiledJSONObject struct {
+	ID         int              `json:"id"`
+	Name       string           `json:"name"`
+	Type       string           `json:"type"`
+	Class      string           `json:"class"`
+	X          float64          `json:"x"`
+	Y          float64          `json:"y"`
+	Width      float64          `json:"width"`
+	Height     float64          `json:"height"`
+	Rotation   float64          `json:"rotation"`
+	GID        uint32           `json:"gid"`
+	Visible    bool             `json:"visible"`
+	Ellipse    bool             `json:"ellipse"`
+	Point      bool             `json:"point"`
+	Polygon    []tiledJSONPoint `json:"polygon"`
+	Polyline   []tiledJSONPoint `json:"polyline"`
+	Properties json.RawMessage  `json:"properties"`
+}
+
+type tiledJSONLayer struct {
+	Type        string            `json:"type"`
+	Name        string            `json:"name"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	Data        json.RawMessage   `json:"data"`
+	Encoding    string            `json:"encoding"`
+	Compression string            `json:"compression"`
+	Visible     bool              `json:"visible"`
+	Opacity     float64           `json:"opacity"`
+	Properties  json.RawMessage   `json:"properties"`
+	Objects     []tiledJSONObject `json:"objects"`
+	Layers      []tiledJSONLayer  `json:"layers"`
+}
+
+type tiledJSONTile struct {
+	ID         int             `json:"id"`
+	Properties json.RawMessage `json:"properties"`
+}
+
+type tiledJSONTileset struct {
+	FirstGID       uint32                            `json:"firstgid"`
+	Source         string                            `json:"source"`
+	Name           string                            `json:"name"`
+	Image          string                            `json:"image"`
+	ImageWidth     int                               `json:"imagewidth"`
+	TileWidth      int                               `json:"tilewidth"`
+	TileHeight     int                               `json:"tileheight"`
+	Margin         int                               `json:"margin"`
+	Spacing        int                               `json:"spacing"`
+	Columns        int                               `json:"columns"`
+	TileCount      int                               `json:"tilecount"`
+	Tiles          []tiledJSONTile                   `json:"tiles"`
+	TileProperties map[string]map[string]interface{} `json:"tileproperties"` // Old format
+}
+
+type tiledJSONMap struct {
+	Width      int                `json:"width"`
+	Height     int                `json:"height"`
+	TileWidth  int                `json:"tilewidth"`
+	TileHeight int                `json:"tileheight"`
+	Properties json.RawMessage    `json:"properties"`
+	Layers     []tiledJSONLayer   `json:"layers"`
+	Tilesets   []tiledJSONTileset `json:"tilesets"`
+}
+
+// Properties are either a list of name/type/value or in older versions a plain object
+func parseJSONProperties(raw json.RawMessage) map[string]string {
+	props := make(map[string]string)
+	if len(raw) == 0 {
+		return props
+	}
+
+	var list []tiledJSONProperty
+	if json.Unmarshal(raw, &list) == nil {
+		for _, p := range list {
+			props[p.Name] = fmt.Sprint(p.Value)
+		}
+		return props
+	}
+
+	var obj map[string]interface{}
+	if json.Unmarshal(raw, &obj) == nil {
+		for k, v := range obj {
+			props[k] = fmt.Sprint(v)
+		}
+	}
+	return props
+}
+
+func jsonPoints(points []tiledJSONPoint) []box2dlite.Vec2 {
+	if len(points) < 1 {
+		return nil
+	}
+	out := make([]box2dlite.Vec2, len(points))
+	for k, p := range points {
+		out[k] = box2dlite.Vec2{p.X, p.Y}
+	}
+	return out
+}
+
+func (e *Engine) loadTiledJSON(path string) (*Tilemap, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m tiledJSONMap
+	err = json.Unmarshal(raw, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(path)
+	tilemap := NewTilemap(m.Width, m.Height, m.TileWidth, m.TileHeight)
+	tilemap.Properties = parseJSONProperties(m.Properties)
+
+	for _, jts := range m.Tilesets {
+		ts, err := e.loadJSONTileset(jts, dir)
+		if err != nil {
+			return nil, err
+		}
+		tilemap.Tilesets = append(tilemap.Tilesets, ts)
+	}
+
+	err = e.addJSONLayers(tilemap, m.Layers)
+	if err != nil {
+		return nil, err
+	}
+	return tilemap, nil
+}
+
+// Adds the layers, layers in groups are flattened
+func (e *Engine) addJSONLayers(tilemap *Tilemap, layers []tiledJSONLayer) error {
+	for _, jl := range layers {
+		switch jl.Type {
+		case "tilelayer":
+			var tiles []uint32
+			if jl.Encoding == "base64" {
+				var data string
+				err := json.Unmarshal(jl.Data, &data)
+				if err != nil {
+					return err
+				}
+				tiles, err = decodeTileData(data, jl.Encoding, jl.Compression, jl.Width*jl.Height)
+				if err != nil {
+					return err
+				}
+			} else {
+				err := json.Unmarshal(jl.Data, &tiles)
+				if err != nil {
+					return err
+				}
+			}
+
+			layer, err := newTileLayer(jl.Name, jl.Width, jl.Height, jl.Visible, jl.Opacity, tiles, parseJSONProperties(jl.Properties))
+			if err != nil {
+				return err
+			}
+			tilemap.Layers = append(tilemap.Layers, layer)
+		case "objectgroup":
+			layer := &ObjectLayer{
+				Name:       jl.Name,
+				Visible:    jl.Visible,
+				Properties: parseJSONProperties(jl.Properties),
+			}
+			for _, jo := range jl.Objects {
+				objType := jo.Type
+				if objType == "" {
+					objType = jo.Class
+				}
+				layer.Objects = append(layer.Objects, &TiledObject{
+					ID:         jo.ID,
+					Name:       jo.Name,
+					Type:       objType,
+					X:          jo.X,
+					Y:          jo.Y,
+					Width:      jo.Width,
+					Height:     jo.Height,
+					Rotation:   jo.Rotation,
+					GID:        jo.GID,
+					Visible:    jo.Visible,
+					Ellipse:    jo.Ellipse,
+					Point:      jo.Point,
+					Polygon:    jsonPoints(jo.Polygon),
+					Polyline:   jsonPoints(jo.Polyline),
+					Properties: parseJSONProperties(jo.Properties),
+					Layer:      layer,
+				})
+			}
+			tilemap.ObjectLayers = append(tilemap.ObjectLayers, layer)
+		case "group":
+			err := e.addJSONLayers(tilemap, jl.Layers)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *Engine) loadJSONTileset(jts tiledJSONTileset, dir string) (*Tileset, error) {
+	if jts.Source != "" {
+		path := filepath.Join(dir, jts.Source)
+		if strings.ToLower(filepath.Ext(path)) == ".tsx" {
+			return e.loadTSX(path, jts.FirstGID)
+		}
+
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		firstGID := jts.FirstGID
+		jts = tiledJSONTileset{}
+		err = json.Unmarshal(raw, &jts)
+		if err != nil {
+			return nil, err
+		}
+		jts.FirstGID = firstGID
+		dir = filepath.Dir(path)
+	}
+
+	ts := &Tileset{
+		Name:           jts.Name,
+		FirstGID:       jts.FirstGID,
+		TileWidth:      jts.TileWidth,
+		TileHeight:     jts.TileHeight,
+		Margin:         jts.Margin,
+		Spacing:        jts.Spacing,
+		Columns:        jts.Columns,
+		TileCount:      jts.TileCount,
+		TileProperties: make(map[int]map[string]string),
+	}
+
+	for _, tile := range jts.Tiles {
+		if len(tile.Properties) > 0 {
+			ts.TileProperties[tile.ID] = parseJSONProperties(tile.Properties)
+		}
+	}
+	for id, props := range jts.TileProperties {
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			continue
+		}
+		converted := make(map[string]string)
+		for k, v := range props {
+			converted[k] = fmt.Sprint(v)
+		}
+		ts.TileProperties[n] = converted
+	}
+
+	err := e.loadTilesetTexture(ts, dir, jts.Image, jts.ImageWidth)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// Tiled TMX (xml) format
+
+type tmxProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+	Text  string `xml:",chardata"` // Multiline strings are stored as text
+}
+
+type tmxProperties struct {
+	Properties []tmxProperty `xml:"property"`
+}
+
+func (tp tmxProperties) toMap() map[string]string {
+	props := make(map[string]string)
+	for _, p := range tp.Properties {
+		if p.Value == "" {
+			props[p.Name] = p.Text
+		} else {
+			props[p.Name] = p.Value
+		}
+	}
+	return props
+}
+
+type tmxImage struct {
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+}
+
+type tmxTile struct {
+	ID         int           `xml:"id,attr"`
+	Properties tmxProperties `xml:"properties"`
+}
+
+type tmxTileset struct {
+	FirstGID   uint32    `xml:"firstgid,attr"`
+	Source     string    `xml:"source,attr"`
+	Name       string    `xml:"name,attr"`
+	TileWidth  int       `xml:"tilewidth,attr"`
+	TileHeight int       `xml:"tileheight,attr"`
+	Margin     int       `xml:"margin,attr"`
+	Spacing    int       `xml:"spacing,attr"`
+	Columns    int       `xml:"columns,attr"`
+	TileCount  int       `xml:"tilecount,attr"`
+	Image      tmxImage  `xml:"image"`
+	Tiles      []tmxTile `xml:"tile"`
+}
+
+type tmxDataTile struct {
+	GID uint32 `xml:"gid,attr"`
+}
+
+type tmxData struct {
+	Encoding    string        `xml:"encoding,attr"`
+	Compression string        `xml:"compression,attr"`
+	Text        string        `xml:",chardata"`
+	Tiles       []tmxDataTile `xml:"tile"`
+}
+
+type tmxLayer struct {
+	Name       string        `xml:"name,attr"`
+	Width      int           `xml:"width,attr"`
+	Height     int           `xml:"height,attr"`
+	Visible    *int          `xml:"visible,attr"`
+	Opacity    *float64      `xml:"opacity,attr"`
+	Properties tmxProperties `xml:"properties"`
+	Data       tmxData       `xml:"data"`
+}
+
+type tmxPoints struct {
+	Points string `xml:"points,attr"`
+}
+
+type tmxObject struct {
+	ID         int           `xml:"id,attr"`
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	X          float64       `xml:"x,attr"`
+	Y          float64       `xml:"y,attr"`
+	Width      float64       `xml:"width,attr"`
+	Height     float64       `xml:"height,attr"`
+	Rotation   float64       `xml:"rotation,attr"`
+	GID        uint32        `xml:"gid,attr"`
+	Visible    *int          `xml:"visible,attr"`
+	Properties tmxProperties `xml:"properties"`
+	Ellipse    *struct{}     `xml:"ellipse"`
+	Point      *struct{}     `xml:"point"`
+	Polygon    *tmxPoints    `xml:"polygon"`
+	Polyline   *tmxPoints    `xml:"polyline"`
+}
+
+type tmxObjectGroup struct {
+	Name       string        `xml:"name,attr"`
+	Visible    *int          `xml:"visible,attr"`
+	Properties tmxProperties `xml:"properties"`
+	Objects    []tmxObject   `xml:"object"`
+}
+
+// Layers, object groups and groups are kept in one list so they stay in the order of the file
+type tmxGroup struct {
+	Children []tmxChild `xml:",any"`
+}
+
+// One of the children of a map or group, elements that aren't layers are left empty
+type tmxChild struct {
+	Layer       *tmxLayer
+	ObjectGroup *tmxObjectGroup
+	Group       *tmxGroup
+}
+
+func (c *tmxChild) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	switch start.Name.Local {
+	case "layer":
+		c.Layer = &tmxLayer{}
+		return d.DecodeElement(c.Layer, &start)
+	case "objectgroup":
+		c.ObjectGroup = &tmxObjectGroup{}
+		return d.DecodeElement(c.ObjectGroup, &start)
+	case "group":
+		c.Group = &tmxGroup{}
+		return d.DecodeElement(c.Group, &start)
+	}
+	return d.Skip()
+}
+
+type tmxMap struct {
+	Width      int           `xml:"width,attr"`
+	Height     int           `xml:"height,attr"`
+	TileWidth  int           `xml:"tilewidth,attr"`
+	TileHeight int           `xml:"tileheight,attr"`
+	Properties tmxProperties `xml:"properties"`
+	Tilesets   []tmxTileset  `xml:"tileset"`
+	tmxGroup
+}
+
+// Visible defaults to true when not set
+func tmxVisible(v *int) bool {
+	return v == nil || *v != 0
+}
+
+// Parses "x,y x,y ..." point lists
+func tmxParsePoints(p *tmxPoints) []box2dlite.Vec2 {
+	if p == nil {
+		return nil
+	}
+
+	var points []box2dlite.Vec2
+	for _, pair := range strings.Fields(p.Points) {
+		xy := strings.Split(pair, ",")
+		if len(xy) != 2 {
+			continue
+		}
+		x, _ := strconv.ParseFloat(xy[0], 64)
+		y, _ := strconv.ParseFloat(xy[1], 64)
+		points = append(points, box2dlite.Vec2{x, y})
+	}
+	return points
+}
+
+func (e *Engine) loadTMX(path string) (*Tilemap, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m tmxMap
+	err = xml.Unmarshal(raw, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(path)
+	tilemap := NewTilemap(m.Width, m.Height, m.TileWidth, m.TileHeight)
+	tilemap.Properties = m.Properties.toMap()
+
+	for _, t := range m.Tilesets {
+		var ts *Tileset
+		if t.Source != "" {
+			ts, err = e.loadTSX(filepath.Join(dir, t.Source), t.FirstGID)
+		} else {
+			ts, err = e.newTMXTileset(t, dir)
+		}
+		if err != nil {
+			return nil, err
+		}
+		tilemap.Tilesets = append(tilemap.Tilesets, ts)
+	}
+
+	err = addTMXGroup(tilemap, m.tmxGroup)
+	if err != nil {
+		return nil, err
+	}
+	return tilemap, nil
+}
+
+func addTMXGroup(tilemap *Tilemap, group tmxGroup) error {
+	for _, child := range group.Children {
+		var err error
+		switch {
+		case child.Layer != nil:
+			err = addTMXLayer(tilemap, *child.Layer)
+		case child.ObjectGroup != nil:
+			addTMXObjectGroup(tilemap, *child.ObjectGroup)
+		case child.Group != nil:
+			err = addTMXGroup(tilemap, *child.Group)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addTMXLayer(tilemap *Tilemap, l tmxLayer) error {
+	var tiles []uint32
+	if l.Data.Encoding == "" {
+		tiles = make([]uint32, len(l.Data.Tiles))
+		for k, t := range l.Data.Tiles {
+			tiles[k] = t.GID
+		}
+	} else {
+		var err error
+		tiles, err = decodeTileData(l.Data.Text, l.Data.Encoding, l.Data.Compression, l.Width*l.Height)
+		if err != nil {
+			return err
+		}
+	}
+
+	opacity := 1.0
+	if l.Opacity != nil {
+		opacity = *l.Opacity
+	}
+
+	layer, err := newTileLayer(l.Name, l.Width, l.Height, tmxVisible(l.Visible), opacity, tiles, l.Properties.toMap())
+	if err != nil {
+		return err
+	}
+	tilemap.Layers = append(tilemap.Layers, layer)
+	return nil
+}
+
+func addTMXObjectGroup(tilemap *Tilemap, og tmxObjectGroup) {
+	layer := &ObjectLayer{
+		Name:       og.Name,
+		Visible:    tmxVisible(og.Visible),
+		Properties: og.Properties.toMap(),
+	}
+	for _, o := range og.Objects {
+		objType := o.Type
+		if objType == "" {
+			objType = o.Class
+		}
+		layer.Objects = append(layer.Objects, &TiledObject{
+			ID:         o.ID,
+			Name:       o.Name,
+			Type:       objType,
+			X:          o.X,
+			Y:          o.Y,
+			Width:      o.Width,
+			Height:     o.Height,
+			Rotation:   o.Rotation,
+			GID:        o.GID,
+			Visible:    tmxVisible(o.Visible),
+			Ellipse:    o.Ellipse != nil,
+			Point:      o.Point != nil,
+			Polygon:    tmxParsePoints(o.Polygon),
+			Polyline:   tmxParsePoints(o.Polyline),
+			Properties: o.Properties.toMap(),
+			Layer:      layer,
+		})
+	}
+	tilemap.ObjectLayers = append(tilemap.ObjectLayers, layer)
+}
+
+// Loads a external tileset
+func (e *Engine) loadTSX(path string, firstGID uint32) (*Tileset, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var t tmxTileset
+	err = xml.Unmarshal(raw, &t)
+	if err != nil {
+		return nil, err
+	}
+	t.FirstGID = firstGID
+	return e.newTMXTileset(t, filepath.Dir(path))
+}
+
+func (e *Engine) newTMXTileset(t tmxTileset, dir string) (*Tileset, error) {
+	ts := &Tileset{
+		Name:           t.Name,
+		FirstGID:       t.FirstGID,
+		TileWidth:      t.TileWidth,
+		TileHeight:     t.TileHeight,
+		Margin:         t.Margin,
+		Spacing:        t.Spacing,
+		Columns:        t.Columns,
+		TileCount:      t.TileCount,
+		TileProperties: make(map[int]map[string]string),
+	}
+
+	for _, tile := range t.Tiles {
+		if len(tile.Properties.Properties) > 0 {
+			ts.TileProperties[tile.ID] = tile.Properties.toMap()
+		}
+	}
+
+	err := e.loadTilesetTexture(ts, dir, t.Image.Source, t.Image.Width)
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
@@ -0,0 +1,513 @@
+package vroom
+
+import (
+	"fmt"
+	"github.com/jonas747/go-box2d-lite/box2dlite"
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+)
+
+// Flags stored in the upper bits of a tile id
+const (
+	TILEFLIPPEDHORIZONTAL = 0x80000000
+	TILEFLIPPEDVERTICAL   = 0x40000000
+	TILEFLIPPEDDIAGONAL   = 0x20000000
+	TILEFLAGS             = TILEFLIPPEDHORIZONTAL | TILEFLIPPEDVERTICAL | TILEFLIPPEDDIAGONAL
+)
+
+// A image split up into tiles, tile ids (gids) from FirstGID and up belong to this tileset
+type Tileset struct {
+	Name                  string
+	Texture               *sdl.Texture
+	FirstGID              uint32
+	TileWidth, TileHeight int
+	Columns               int
+	TileCount             int
+	Margin, Spacing       int
+
+	// Properties of individual tiles, by id local to this tileset
+	TileProperties map[int]map[string]string
+}
+
+// Returns the source rect in the tileset texture for the local tile id
+func (ts *Tileset) TileRect(id int) sdl.Rect {
+	columns := ts.Columns
+	if columns < 1 {
+		columns = 1
+	}
+	x := ts.Margin + (id%columns)*(ts.TileWidth+ts.Spacing)
+	y := ts.Margin + (id/columns)*(ts.TileHeight+ts.Spacing)
+	return sdl.Rect{X: int32(x), Y: int32(y), W: int32(ts.TileWidth), H: int32(ts.TileHeight)}
+}
+
+// A grid of tile ids, 0 is empty
+type TileLayer struct {
+	Name          string
+	Width, Height int
+	Tiles         []uint32
+	Visible       bool
+	Opacity       float64
+	Properties    map[string]string
+}
+
+func NewTileLayer(name string, w, h int) *TileLayer {
+	return &TileLayer{
+		Name:       name,
+		Width:      w,
+		Height:     h,
+		Tiles:      make([]uint32, w*h),
+		Visible:    true,
+		Opacity:    1,
+		Properties: make(map[string]string),
+	}
+}
+
+// Returns the tile id including the flip flags, 0 if outside the layer
+func (tl *TileLayer) Get(x, y int) uint32 {
+	if x < 0 || y < 0 || x >= tl.Width || y >= tl.Height {
+		return 0
+	}
+	return tl.Tiles[y*tl.Width+x]
+}
+
+type tileChunk struct {
+	target *RenderTarget
+	dirty  bool
+	empty  bool
+}
+
+// Draws tile layers, the transform position is the top left corner of the map
+// The map is split up in chunks that are rendered into textures when they change,
+// only the chunks inside the view are drawn
+type Tilemap struct {
+	BaseComponent
+	Width, Height         int // In tiles
+	TileWidth, TileHeight int
+	Tilesets              []*Tileset
+	Layers                []*TileLayer
+	ObjectLayers          []*ObjectLayer
+	Properties            map[string]string
+
+	Layer        int
+	IgnoreCamera bool
+	ChunkSize    int // Chunk size in tiles, 0 for 16
+
+	// Tile layers with the property collision=true, or tiles with the property collision=true
+	// get static physics bodies generated when the tilemap is initialized
+	GenerateBodies bool
+	Bodies         []*box2dlite.Body
+
+	chunks           []*tileChunk
+	chunksX, chunksY int
+}
+
+func NewTilemap(w, h, tileWidth, tileHeight int) *Tilemap {
+	return &Tilemap{
+		Width:          w,
+		Height:         h,
+		TileWidth:      tileWidth,
+		TileHeight:     tileHeight,
+		Properties:     make(map[string]string),
+		GenerateBodies: true,
+	}
+}
+
+func (tm *Tilemap) Name() string {
+	return "Tilemap"
+}
+
+func (tm *Tilemap) GetLayer() int {
+	return tm.Layer
+}
+
+func (tm *Tilemap) Init() {
+	if tm.GetComponent("Transform") == nil {
+		transform := &Transform{}
+		tm.AddComponent(transform)
+	}
+
+	if tm.GenerateBodies {
+		tm.CreateBodies()
+	}
+}
+
+func (tm *Tilemap) Destroy() {
+	tm.RemoveBodies()
+	for _, chunk := range tm.chunks {
+		if chunk != nil && chunk.target != nil {
+			chunk.target.Destroy()
+		}
+	}
+	tm.chunks = nil
+}
+
+func (tm *Tilemap) GetTileLayer(name string) *TileLayer {
+	for _, layer := range tm.Layers {
+		if layer.Name == name {
+			return layer
+		}
+	}
+	return nil
+}
+
+// Returns the tileset the tile id belongs to
+func (tm *Tilemap) TilesetFor(gid uint32) *Tileset {
+	gid &^= TILEFLAGS
+	if gid == 0 {
+		return nil
+	}
+
+	var found *Tileset
+	for _, ts := range tm.Tilesets {
+		if ts.FirstGID <= gid && (found == nil || ts.FirstGID > found.FirstGID) {
+			found = ts
+		}
+	}
+	return found
+}
+
+// Returns the properties set on the tile in the tileset
+func (tm *Tilemap) TileProperties(gid uint32) map[string]string {
+	ts := tm.TilesetFor(gid)
+	if ts == nil || ts.TileProperties == nil {
+		return nil
+	}
+	return ts.TileProperties[int((gid&^TILEFLAGS)-ts.FirstGID)]
+}
+
+// Returns a property of the tile at x, y in the layer, empty if not set
+func (tm *Tilemap) GetTileProperty(layer *TileLayer, x, y int, key string) string {
+	props := tm.TileProperties(layer.Get(x, y))
+	if props == nil {
+		return ""
+	}
+	return props[key]
+}
+
+func (tm *Tilemap) SetTile(layer *TileLayer, x, y int, gid uint32) {
+	if x < 0 || y < 0 || x >= layer.Width || y >= layer.Height {
+		return
+	}
+	layer.Tiles[y*layer.Width+x] = gid
+	tm.markDirty(x, y)
+}
+
+func (tm *Tilemap) chunkSize() int {
+	if tm.ChunkSize <= 0 {
+		return 16
+	}
+	return tm.ChunkSize
+}
+
+func (tm *Tilemap) markDirty(x, y int) {
+	size := tm.chunkSize()
+	index := (y/size)*tm.chunksX + x/size
+	if index >= 0 && index < len(tm.chunks) && tm.chunks[index] != nil {
+		tm.chunks[index].dirty = true
+	}
+}
+
+// Marks all chunks as changed, call after modifying layers or tilesets directly
+func (tm *Tilemap) Refresh() {
+	for _, chunk := range tm.chunks {
+		if chunk != nil {
+			chunk.dirty = true
+		}
+	}
+}
+
+// World space position of the top left corner of the map
+func (tm *Tilemap) origin() box2dlite.Vec2 {
+	transformComp := tm.GetComponent("Transform")
+	if transformComp == nil {
+		return box2dlite.Vec2{}
+	}
+	transform, ok := transformComp.(*Transform)
+	if !ok {
+		return box2dlite.Vec2{}
+	}
+	return transform.WorldPosition()
+}
+
+// Converts a world position to tile coordinates
+func (tm *Tilemap) WorldToTile(pos box2dlite.Vec2) (int, int) {
+	local := pos.Sub(tm.origin())
+	return int(math.Floor(local.X / float64(tm.TileWidth))), int(math.Floor(local.Y / float64(tm.TileHeight)))
+}
+
+// Returns the world position of the top left corner of the tile
+func (tm *Tilemap) TileToWorld(x, y int) box2dlite.Vec2 {
+	return tm.origin().Add(box2dlite.Vec2{float64(x * tm.TileWidth), float64(y * tm.TileHeight)})
+}
+
+func (tm *Tilemap) ensureChunks() {
+	size := tm.chunkSize()
+	chunksX := (tm.Width + size - 1) / size
+	chunksY := (tm.Height + size - 1) / size
+	if chunksX == tm.chunksX && chunksY == tm.chunksY && tm.chunks != nil {
+		return
+	}
+
+	for _, chunk := range tm.chunks {
+		if chunk != nil && chunk.target != nil {
+			chunk.target.Destroy()
+		}
+	}
+
+	tm.chunksX = chunksX
+	tm.chunksY = chunksY
+	tm.chunks = make([]*tileChunk, chunksX*chunksY)
+	for k := range tm.chunks {
+		tm.chunks[k] = &tileChunk{dirty: true}
+	}
+}
+
+// Returns the range of chunks that are inside the view
+func (tm *Tilemap) visibleChunks() (minX, minY, maxX, maxY int) {
+	view := tm.Parent.GetEngine().ViewRect(tm.IgnoreCamera)
+	origin := tm.origin()
+
+	size := tm.chunkSize()
+	chunkW := float64(size * tm.TileWidth)
+	chunkH := float64(size * tm.TileHeight)
+
+	minX = int(math.Floor((view.X - origin.X) / chunkW))
+	minY = int(math.Floor((view.Y - origin.Y) / chunkH))
+	maxX = int(math.Floor((view.Right() - origin.X) / chunkW))
+	maxY = int(math.Floor((view.Bottom() - origin.Y) / chunkH))
+
+	if minX < 0 {
+		minX = 0
+	}
+	if minY < 0 {
+		minY = 0
+	}
+	if maxX >= tm.chunksX {
+		maxX = tm.chunksX - 1
+	}
+	if maxY >= tm.chunksY {
+		maxY = tm.chunksY - 1
+	}
+	return
+}
+
+// Renders the visible chunks that changed, chunks only seen by other views are rendered in Draw
+func (tm *Tilemap) PreDraw(renderer *sdl.Renderer) {
+	if tm.TileWidth <= 0 || tm.TileHeight <= 0 {
+		return
+	}
+	tm.ensureChunks()
+
+	minX, minY, maxX, maxY := tm.visibleChunks()
+	for cy := minY; cy <= maxY; cy++ {
+		for cx := minX; cx <= maxX; cx++ {
+			chunk := tm.chunks[cy*tm.chunksX+cx]
+			if chunk.dirty {
+				tm.renderChunk(renderer, chunk, cx, cy)
+			}
+		}
+	}
+}
+
+func (tm *Tilemap) renderChunk(renderer *sdl.Renderer, chunk *tileChunk, cx, cy int) {
+	chunk.dirty = false
+
+	size := tm.chunkSize()
+	if chunk.target == nil {
+		target, err := tm.Parent.GetEngine().NewRenderTarget(size*tm.TileWidth, size*tm.TileHeight)
+		if err != nil {
+			fmt.Println("Failed creating tilemap chunk: ", err)
+			return
+		}
+		chunk.target = target
+	}
+
+	chunk.target.Begin(renderer)
+	chunk.empty = true
+
+	startX := cx * size
+	startY := cy * size
+	for _, layer := range tm.Layers {
+		if !layer.Visible {
+			continue
+		}
+
+		alpha := uint8(layer.Opacity * 255)
+		for y := startY; y < startY+size && y < layer.Height; y++ {
+			for x := startX; x < startX+size && x < layer.Width; x++ {
+				gid := layer.Tiles[y*layer.Width+x]
+				ts := tm.TilesetFor(gid)
+				if ts == nil || ts.Texture == nil {
+					continue
+				}
+
+				src := ts.TileRect(int((gid &^ TILEFLAGS) - ts.FirstGID))
+				// Tiles bigger than the grid are aligned to the bottom left like in Tiled
+				dst := sdl.Rect{
+					X: int32((x - startX) * tm.TileWidth),
+					Y: int32((y-startY+1)*tm.TileHeight - ts.TileHeight),
+					W: int32(ts.TileWidth),
+					H: int32(ts.TileHeight),
+				}
+
+				angle := 0.0
+				var flip sdl.RendererFlip = sdl.FLIP_NONE
+				var flipH sdl.RendererFlip = sdl.FLIP_HORIZONTAL
+				var flipV sdl.RendererFlip = sdl.FLIP_VERTICAL
+				if gid&TILEFLIPPEDDIAGONAL != 0 {
+					// SDL flips before rotating, so a diagonal flip is a vertical flip rotated 90 degrees
+					// and the horizontal and vertical flips swap places
+					angle = 90
+					flip = sdl.FLIP_VERTICAL
+					flipH, flipV = flipV, flipH
+				}
+				if gid&TILEFLIPPEDHORIZONTAL != 0 {
+					flip ^= flipH
+				}
+				if gid&TILEFLIPPEDVERTICAL != 0 {
+					flip ^= flipV
+				}
+
+				ts.Texture.SetAlphaMod(alpha)
+				renderer.CopyEx(ts.Texture, &src, &dst, angle, nil, flip)
+				ts.Texture.SetAlphaMod(255)
+				chunk.empty = false
+			}
+		}
+	}
+
+	chunk.target.End(renderer)
+}
+
+// Renders a chunk in the middle of drawing, for the views PreDraw doesn't know about like render
+// targets with their own camera. The render target and scale are put back after
+func (tm *Tilemap) renderChunkNow(renderer *sdl.Renderer, chunk *tileChunk, cx, cy int) {
+	previous := renderer.GetRenderTarget()
+	renderer.SetScale(1, 1)
+	tm.renderChunk(renderer, chunk, cx, cy)
+	renderer.SetRenderTarget(previous)
+
+	if target := tm.Parent.GetEngine().viewTarget; target != nil && target.Scale != 0 && target.Scale != 1 {
+		renderer.SetScale(float32(target.Scale), float32(target.Scale))
+	}
+}
+
+func (tm *Tilemap) Draw(renderer *sdl.Renderer) {
+	if tm.chunks == nil {
+		return
+	}
+
+	origin := tm.origin()
+	if !tm.IgnoreCamera {
+		origin = origin.Sub(tm.Parent.GetEngine().Camera)
+	}
+
+	size := tm.chunkSize()
+	chunkW := size * tm.TileWidth
+	chunkH := size * tm.TileHeight
+
+	minX, minY, maxX, maxY := tm.visibleChunks()
+	for cy := minY; cy <= maxY; cy++ {
+		for cx := minX; cx <= maxX; cx++ {
+			chunk := tm.chunks[cy*tm.chunksX+cx]
+			if chunk.dirty {
+				tm.renderChunkNow(renderer, chunk, cx, cy)
+			}
+			if chunk.target == nil || chunk.empty {
+				continue
+			}
+
+			dst := &sdl.Rect{
+				X: int32(origin.X) + int32(cx*chunkW),
+				Y: int32(origin.Y) + int32(cy*chunkH),
+				W: int32(chunkW),
+				H: int32(chunkH),
+			}
+			renderer.Copy(chunk.target.Texture, nil, dst)
+		}
+	}
+}
+
+// Returns true if the tile should get a physics body
+func (tm *Tilemap) isSolid(layer *TileLayer, x, y int) bool {
+	gid := layer.Get(x, y)
+	if gid == 0 {
+		return false
+	}
+	if layer.Properties["collision"] == "true" {
+		return true
+	}
+	return tm.GetTileProperty(layer, x, y, "collision") == "true"
+}
+
+// Creates static bodies for the solid tiles, neighbouring tiles are merged into bigger boxes
+func (tm *Tilemap) CreateBodies() {
+	tm.RemoveBodies()
+
+	engine := tm.Parent.GetEngine()
+	solid := make([]bool, tm.Width*tm.Height)
+	for _, layer := range tm.Layers {
+		for y := 0; y < tm.Height; y++ {
+			for x := 0; x < tm.Width; x++ {
+				if tm.isSolid(layer, x, y) {
+					solid[y*tm.Width+x] = true
+				}
+			}
+		}
+	}
+
+	// Greedy merge, first horizontally then grow the run downwards as long as the row below matches
+	used := make([]bool, len(solid))
+	origin := tm.origin()
+	for y := 0; y < tm.Height; y++ {
+		for x := 0; x < tm.Width; x++ {
+			if !solid[y*tm.Width+x] || used[y*tm.Width+x] {
+				continue
+			}
+
+			w := 1
+			for x+w < tm.Width && solid[y*tm.Width+x+w] && !used[y*tm.Width+x+w] {
+				w++
+			}
+
+			h := 1
+		grow:
+			for y+h < tm.Height {
+				for i := x; i < x+w; i++ {
+					if !solid[(y+h)*tm.Width+i] || used[(y+h)*tm.Width+i] {
+						break grow
+					}
+				}
+				h++
+			}
+
+			for j := y; j < y+h; j++ {
+				for i := x; i < x+w; i++ {
+					used[j*tm.Width+i] = true
+				}
+			}
+
+			pw := float64(w * tm.TileWidth)
+			ph := float64(h * tm.TileHeight)
+			cx := origin.X + float64(x*tm.TileWidth) + pw/2
+			cy := origin.Y + float64(y*tm.TileHeight) + ph/2
+
+			body := &box2dlite.Body{}
+			body.Set(&box2dlite.Vec2{pw / engine.PhysicsScale, ph / engine.PhysicsScale}, math.MaxFloat64)
+			body.Position = box2dlite.Vec2{cx / engine.PhysicsScale, cy / engine.PhysicsScale}
+			engine.World.AddBody(body)
+			tm.Bodies = append(tm.Bodies, body)
+		}
+	}
+}
+
+func (tm *Tilemap) RemoveBodies() {
+	if len(tm.Bodies) < 1 {
+		return
+	}
+	world := tm.Parent.GetEngine().World
+	for _, body := range tm.Bodies {
+		world.RemoveBody(body)
+	}
+	tm.Bodies = nil
+}
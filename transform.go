@@ -0,0 +1,376 @@
+package vroom
+
+import (
+	"github.com/jonas747/go-box2d-lite/box2dlite"
+	"math"
+)
+
+// 2D affine transformation matrix
+// | A C TX |
+// | B D TY |
+// | 0 0 1  |
+type Matrix struct {
+	A, B, C, D float64
+	TX, TY     float64
+}
+
+func IdentityMatrix() Matrix {
+	return Matrix{A: 1, D: 1}
+}
+
+// Creates a matrix that first scales, then rotates (angle in degrees) and then translates
+func NewMatrix(position box2dlite.Vec2, angle float64, scale box2dlite.Vec2) Matrix {
+	rad := DegreesToRadians(angle)
+	cos := math.Cos(rad)
+	sin := math.Sin(rad)
+	return Matrix{
+		A:  cos * scale.X,
+		B:  sin * scale.X,
+		C:  -sin * scale.Y,
+		D:  cos * scale.Y,
+		TX: position.X,
+		TY: position.Y,
+	}
+}
+
+// Returns m * o, meaning o is applied first and then m
+func (m Matrix) Mul(o Matrix) Matrix {
+	return Matrix{
+		A:  m.A*o.A + m.C*o.B,
+		B:  m.B*o.A + m.D*o.B,
+		C:  m.A*o.C + m.C*o.D,
+		D:  m.B*o.C + m.D*o.D,
+		TX: m.A*o.TX + m.C*o.TY + m.TX,
+		TY: m.B*o.TX + m.D*o.TY + m.TY,
+	}
+}
+
+// Transforms a point
+func (m Matrix) Apply(v box2dlite.Vec2) box2dlite.Vec2 {
+	return box2dlite.Vec2{
+		X: m.A*v.X + m.C*v.Y + m.TX,
+		Y: m.B*v.X + m.D*v.Y + m.TY,
+	}
+}
+
+// Transforms a direction, ignoring the translation
+func (m Matrix) ApplyVector(v box2dlite.Vec2) box2dlite.Vec2 {
+	return box2dlite.Vec2{
+		X: m.A*v.X + m.C*v.Y,
+		Y: m.B*v.X + m.D*v.Y,
+	}
+}
+
+// Returns the inverse, if the matrix cannot be inverted (zero scale) identity is returned
+func (m Matrix) Inverse() Matrix {
+	det := m.A*m.D - m.B*m.C
+	if det == 0 {
+		return IdentityMatrix()
+	}
+
+	inv := 1 / det
+	a := m.D * inv
+	b := -m.B * inv
+	c := -m.C * inv
+	d := m.A * inv
+	return Matrix{
+		A:  a,
+		B:  b,
+		C:  c,
+		D:  d,
+		TX: -(a*m.TX + c*m.TY),
+		TY: -(b*m.TX + d*m.TY),
+	}
+}
+
+func (m Matrix) Position() box2dlite.Vec2 {
+	return box2dlite.Vec2{X: m.TX, Y: m.TY}
+}
+
+// Rotation in degrees
+func (m Matrix) Angle() float64 {
+	return RadiansToDeDegrees(math.Atan2(m.B, m.A))
+}
+
+// A mirrored matrix ends up with a negative Y scale
+func (m Matrix) Scale() box2dlite.Vec2 {
+	sx := math.Hypot(m.A, m.B)
+	if sx == 0 {
+		return box2dlite.Vec2{}
+	}
+	return box2dlite.Vec2{X: sx, Y: (m.A*m.D - m.B*m.C) / sx}
+}
+
+// Transform component, position and angle (in degrees) are relative to the parent entity's transform
+// The world matrix is cached and only recalculated when this or a parent transform changes
+type Transform struct {
+	BaseComponent
+	Position box2dlite.Vec2
+	Angle    float64
+	Scale    box2dlite.Vec2 // A zero scale is treated as 1, 1
+
+	dirty bool
+
+	// Local values the cached matrices were built from, so that changes made directly
+	// to the fields are picked up aswell, the draw system checks for them every frame
+	lastPosition box2dlite.Vec2
+	lastAngle    float64
+	lastScale    box2dlite.Vec2
+
+	local        Matrix
+	world        Matrix
+	worldInverse Matrix
+	inverseDirty bool
+
+	// Bumped every time the world matrix changes so children know when to recalculate
+	version       uint64
+	parent        *Transform
+	parentVersion uint64
+
+	watchers []transformWatcher
+}
+
+// Told when the world matrix of a transform may have changed, so the draw system only has to
+// update the bounds of drawables that moved
+type transformWatcher interface {
+	transformChanged()
+}
+
+func NewTransform(x, y, angle float64) *Transform {
+	return &Transform{
+		Position: box2dlite.Vec2{x, y},
+		Angle:    angle,
+		Scale:    box2dlite.Vec2{1, 1},
+	}
+}
+
+func (t *Transform) Name() string {
+	return "Transform"
+}
+
+func (t *Transform) SetPosition(x, y float64) {
+	t.Position = box2dlite.Vec2{x, y}
+	t.SetDirty()
+}
+
+func (t *Transform) SetAngle(angle float64) {
+	t.Angle = angle
+	t.SetDirty()
+}
+
+func (t *Transform) SetScale(x, y float64) {
+	t.Scale = box2dlite.Vec2{x, y}
+	t.SetDirty()
+}
+
+// Marks the cached matrices as outdated, changes made directly to Position, Angle or Scale
+// are found without it
+func (t *Transform) SetDirty() {
+	t.dirty = true
+	t.changed()
+}
+
+func (t *Transform) watch(watcher transformWatcher) {
+	t.watchers = append(t.watchers, watcher)
+}
+
+func (t *Transform) unwatch(watcher transformWatcher) {
+	for k, v := range t.watchers {
+		if v == watcher {
+			t.watchers = append(t.watchers[:k], t.watchers[k+1:]...)
+			return
+		}
+	}
+}
+
+// Tells the watchers of this transform and of the transforms of the child entities
+func (t *Transform) changed() {
+	for _, watcher := range t.watchers {
+		watcher.transformChanged()
+	}
+	if t.Parent == nil {
+		return
+	}
+	for _, child := range t.Parent.GetChildren(false) {
+		if transform, ok := child.GetComponent("Transform").(*Transform); ok && transform != t {
+			transform.changed()
+		}
+	}
+}
+
+func (t *Transform) localScale() box2dlite.Vec2 {
+	if t.Scale.X == 0 && t.Scale.Y == 0 {
+		return box2dlite.Vec2{1, 1}
+	}
+	return t.Scale
+}
+
+// Returns the transform of the parent entity, if any
+func (t *Transform) ParentTransform() *Transform {
+	if t.Parent == nil {
+		return nil
+	}
+
+	parentEntity := t.Parent.GetParent()
+	if parentEntity == nil {
+		return nil
+	}
+
+	transformComp := parentEntity.GetComponent("Transform")
+	if transformComp == nil {
+		return nil
+	}
+
+	casted, _ := transformComp.(*Transform)
+	return casted
+}
+
+func (t *Transform) physBody() *box2dlite.Body {
+	if t.Parent == nil {
+		return nil
+	}
+
+	physComp := t.GetComponent("PhysBodyComp")
+	if physComp == nil {
+		return nil
+	}
+
+	casted, ok := physComp.(*PhysBodyComp)
+	if !ok {
+		return nil
+	}
+	return casted.Body
+}
+
+// Recalculates the cached matrices if needed
+func (t *Transform) update() {
+	// Physics bodies live in world space, so they override the world matrix directly
+	body := t.physBody()
+	if body != nil {
+		pos := body.Position.Mul(t.Parent.GetEngine().PhysicsScale)
+		world := NewMatrix(pos, RadiansToDeDegrees(body.Rotation), t.localScale())
+		if world != t.world || t.version == 0 {
+			t.world = world
+			t.inverseDirty = true
+			t.version++
+
+			parentInv := IdentityMatrix()
+			if parent := t.ParentTransform(); parent != nil {
+				parentInv = parent.WorldInverse()
+			}
+			t.local = parentInv.Mul(world)
+		}
+		return
+	}
+
+	scale := t.localScale()
+	if t.Position != t.lastPosition || t.Angle != t.lastAngle || scale != t.lastScale {
+		t.dirty = true
+		t.changed()
+	}
+
+	if t.dirty || t.version == 0 {
+		t.local = NewMatrix(t.Position, t.Angle, scale)
+		t.lastPosition = t.Position
+		t.lastAngle = t.Angle
+		t.lastScale = scale
+		t.dirty = true
+	}
+
+	parent := t.ParentTransform()
+	parentVersion := uint64(0)
+	if parent != nil {
+		parent.update()
+		parentVersion = parent.version
+	}
+
+	if parent != t.parent || parentVersion != t.parentVersion {
+		t.dirty = true
+	}
+
+	if !t.dirty {
+		return
+	}
+
+	t.parent = parent
+	t.parentVersion = parentVersion
+	if parent != nil {
+		t.world = parent.world.Mul(t.local)
+	} else {
+		t.world = t.local
+	}
+
+	t.dirty = false
+	t.inverseDirty = true
+	t.version++
+}
+
+func (t *Transform) LocalMatrix() Matrix {
+	t.update()
+	return t.local
+}
+
+func (t *Transform) WorldMatrix() Matrix {
+	t.update()
+	return t.world
+}
+
+// Changes every time the world matrix changes
+func (t *Transform) Version() uint64 {
+	t.update()
+	return t.version
+}
+
+func (t *Transform) WorldInverse() Matrix {
+	t.update()
+	if t.inverseDirty {
+		t.worldInverse = t.world.Inverse()
+		t.inverseDirty = false
+	}
+	return t.worldInverse
+}
+
+func (t *Transform) WorldPosition() box2dlite.Vec2 {
+	return t.WorldMatrix().Position()
+}
+
+// World angle in degrees
+func (t *Transform) WorldAngle() float64 {
+	return t.WorldMatrix().Angle()
+}
+
+func (t *Transform) WorldScale() box2dlite.Vec2 {
+	return t.WorldMatrix().Scale()
+}
+
+// Moves the transform so it ends up at the world position
+func (t *Transform) SetWorldPosition(pos box2dlite.Vec2) {
+	if parent := t.ParentTransform(); parent != nil {
+		pos = parent.WorldToLocal(pos)
+	}
+	t.SetPosition(pos.X, pos.Y)
+}
+
+// Converts a point in this transforms local space to world space
+func (t *Transform) LocalToWorld(point box2dlite.Vec2) box2dlite.Vec2 {
+	return t.WorldMatrix().Apply(point)
+}
+
+// Converts a point in world space to this transforms local space
+func (t *Transform) WorldToLocal(point box2dlite.Vec2) box2dlite.Vec2 {
+	return t.WorldInverse().Apply(point)
+}
+
+// Same as WorldPosition
+func (t *Transform) CalcPos() box2dlite.Vec2 {
+	return t.WorldPosition()
+}
+
+// Same as WorldAngle
+func (t *Transform) CalcAngle() float64 {
+	return t.WorldAngle()
+}
+
+func (t *Transform) GetScreenPos() box2dlite.Vec2 {
+	return t.WorldPosition().Sub(t.Parent.GetEngine().Camera)
+}
@@ -0,0 +1,721 @@
+package vroom
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+)
+
+// How a UIElement places its child elements
+const (
+	LAYOUTNONE       = iota // Children are placed by their own anchors inside the content rect
+	LAYOUTHORIZONTAL        // Children are placed next to each other from left to right
+	LAYOUTVERTICAL          // Children are placed below each other from top to bottom
+	LAYOUTGRID              // Children are placed in equally sized cells, Columns per row
+)
+
+// Space around the edges of a rect
+type Insets struct {
+	Left, Top, Right, Bottom int
+}
+
+// Same inset on every side
+func UniformInsets(v int) Insets {
+	return Insets{v, v, v, v}
+}
+
+func (in Insets) Horizontal() int {
+	return in.Left + in.Right
+}
+
+func (in Insets) Vertical() int {
+	return in.Top + in.Bottom
+}
+
+// Implemented by components with a size of their own, auto sized elements grow to fit them
+type Sizer interface {
+	Component
+	Size() (int, int)
+}
+
+// Implemented by components that are resized to fill their element, like panel backgrounds and mouse boxes
+type SizeSetter interface {
+	Component
+	SetSize(w, h int)
+}
+
+// Places the entity on the screen relative to the parent element or the screen if the parent entity
+// has no element. The transform is moved to the center of the resulting rect, since that's where
+// sprites, nine slices, mouse boxes and centered labels are drawn from
+// Children with elements are placed inside the content rect (Rect minus Padding) using Layout
+type UIElement struct {
+	BaseComponent
+
+	// Where in the parent rect the element is attached, 0, 0 is the top left and 1, 1 the bottom right
+	AnchorX, AnchorY float64
+	// Point of the element that is put on the anchor, same range as the anchor
+	PivotX, PivotY   float64
+	OffsetX, OffsetY float64
+
+	Width, Height         int  // Used unless the axis is auto sized or stretched
+	MinWidth, MinHeight   int  // Auto sized and stretched elements never get smaller than this
+	AutoWidth, AutoHeight bool // Size to fit the content and child elements
+	StretchX, StretchY    bool // Fill the parent rect (minus margins), in stacks stretching along the stack shares the space left
+
+	Margin  Insets // Space kept free around the element
+	Padding Insets // Space between the edges and the child elements
+
+	Layout  int // LAYOUTNONE, LAYOUTHORIZONTAL, LAYOUTVERTICAL or LAYOUTGRID
+	Spacing int // Space between children in stacks and grids
+	Columns int // Cells per row for LAYOUTGRID
+
+	Rect Rect // Where the element ended up last layout, in screen coordinates
+
+	measuredW, measuredH float64
+}
+
+// Element of a fixed size placed at the anchor
+func NewUIElement(w, h int, anchorX, anchorY float64) *UIElement {
+	el := &UIElement{
+		Width:  w,
+		Height: h,
+	}
+	el.SetAnchor(anchorX, anchorY)
+	return el
+}
+
+// Element sized to its content
+func NewAutoUIElement(layout, spacing int) *UIElement {
+	return &UIElement{
+		AutoWidth:  true,
+		AutoHeight: true,
+		Layout:     layout,
+		Spacing:    spacing,
+	}
+}
+
+func (el *UIElement) Init() {
+	if el.GetComponent("Transform") == nil {
+		transform := &Transform{}
+		el.AddComponent(transform)
+	}
+}
+
+func (el *UIElement) Name() string {
+	return "UIElement"
+}
+
+// Sets the anchor and the pivot to the same point, so 1, 0 puts the element in the top right corner
+func (el *UIElement) SetAnchor(x, y float64) {
+	el.AnchorX, el.AnchorY = x, y
+	el.PivotX, el.PivotY = x, y
+}
+
+// Rect minus the padding, where the children are placed
+func (el *UIElement) ContentRect() Rect {
+	return Rect{
+		X: el.Rect.X + float64(el.Padding.Left),
+		Y: el.Rect.Y + float64(el.Padding.Top),
+		W: math.Max(0, el.Rect.W-float64(el.Padding.Horizontal())),
+		H: math.Max(0, el.Rect.H-float64(el.Padding.Vertical())),
+	}
+}
+
+// Returns the element of the parent entity, nil for root elements
+func (el *UIElement) ParentElement() *UIElement {
+	if el.Parent == nil || el.Parent.GetParent() == nil {
+		return nil
+	}
+	return elementOf(el.Parent.GetParent())
+}
+
+// Child elements that take part in the layout, disabled entities are left out
+func (el *UIElement) ChildElements() []*UIElement {
+	children := make([]*UIElement, 0)
+	for _, child := range el.Parent.GetChildren(false) {
+		if !child.Enabled() {
+			continue
+		}
+		childEl := elementOf(child)
+		if childEl != nil && childEl.Enabled() {
+			children = append(children, childEl)
+		}
+	}
+	return children
+}
+
+func elementOf(entity Entity) *UIElement {
+	comp := entity.GetComponent("UIElement")
+	if comp == nil {
+		return nil
+	}
+	casted, _ := comp.(*UIElement)
+	return casted
+}
+
+// Outer size including the margins
+func (el *UIElement) outerSize() (float64, float64) {
+	return el.measuredW + float64(el.Margin.Horizontal()), el.measuredH + float64(el.Margin.Vertical())
+}
+
+// Calculates the preferred size, children first
+func (el *UIElement) measure() {
+	children := el.ChildElements()
+	for _, child := range children {
+		child.measure()
+	}
+
+	contentW, contentH := el.childrenSize(children)
+	for _, compSlice := range el.Parent.GetComponents() {
+		for _, comp := range compSlice {
+			sizer, ok := comp.(Sizer)
+			if !ok || !comp.Enabled() {
+				continue
+			}
+			w, h := sizer.Size()
+			contentW = math.Max(contentW, float64(w))
+			contentH = math.Max(contentH, float64(h))
+		}
+	}
+
+	el.measuredW = float64(el.Width)
+	if el.AutoWidth {
+		el.measuredW = contentW + float64(el.Padding.Horizontal())
+	}
+	el.measuredW = math.Max(el.measuredW, float64(el.MinWidth))
+
+	el.measuredH = float64(el.Height)
+	if el.AutoHeight {
+		el.measuredH = contentH + float64(el.Padding.Vertical())
+	}
+	el.measuredH = math.Max(el.measuredH, float64(el.MinHeight))
+}
+
+// Size the children need with the current layout
+func (el *UIElement) childrenSize(children []*UIElement) (float64, float64) {
+	if len(children) < 1 {
+		return 0, 0
+	}
+
+	w, h := 0.0, 0.0
+	spacing := float64(el.Spacing * (len(children) - 1))
+	switch el.Layout {
+	case LAYOUTHORIZONTAL:
+		for _, child := range children {
+			cw, ch := child.outerSize()
+			w += cw
+			h = math.Max(h, ch)
+		}
+		w += spacing
+	case LAYOUTVERTICAL:
+		for _, child := range children {
+			cw, ch := child.outerSize()
+			w = math.Max(w, cw)
+			h += ch
+		}
+		h += spacing
+	case LAYOUTGRID:
+		cellW, cellH := maxOuterSize(children)
+		columns, rows := el.gridSize(len(children))
+		w = cellW*float64(columns) + float64(el.Spacing*(columns-1))
+		h = cellH*float64(rows) + float64(el.Spacing*(rows-1))
+	default:
+		w, h = maxOuterSize(children)
+	}
+	return w, h
+}
+
+func maxOuterSize(elements []*UIElement) (float64, float64) {
+	w, h := 0.0, 0.0
+	for _, el := range elements {
+		ow, oh := el.outerSize()
+		w = math.Max(w, ow)
+		h = math.Max(h, oh)
+	}
+	return w, h
+}
+
+func (el *UIElement) gridSize(count int) (int, int) {
+	columns := el.Columns
+	if columns < 1 {
+		columns = 1
+	}
+	if count < columns {
+		columns = count
+	}
+	return columns, (count + columns - 1) / columns
+}
+
+// Places the element along one axis inside the area, returns the position and size
+func placeAxis(start, length, anchor, pivot, offset, size float64, marginStart, marginEnd int, stretch bool, min int) (float64, float64) {
+	start += float64(marginStart)
+	length -= float64(marginStart + marginEnd)
+	if stretch {
+		return start + offset, math.Max(length, float64(min))
+	}
+	return start + length*anchor - size*pivot + offset, size
+}
+
+// Places the element inside area using its anchors
+func (el *UIElement) place(area Rect) {
+	x, w := placeAxis(area.X, area.W, el.AnchorX, el.PivotX, el.OffsetX, el.measuredW, el.Margin.Left, el.Margin.Right, el.StretchX, el.MinWidth)
+	y, h := placeAxis(area.Y, area.H, el.AnchorY, el.PivotY, el.OffsetY, el.measuredH, el.Margin.Top, el.Margin.Bottom, el.StretchY, el.MinHeight)
+	el.setRect(Rect{x, y, w, h})
+}
+
+// Applies the rect to the transform and the components, then lays out the children
+func (el *UIElement) setRect(rect Rect) {
+	rect.X = math.Floor(rect.X)
+	rect.Y = math.Floor(rect.Y)
+	rect.W = math.Floor(rect.W)
+	rect.H = math.Floor(rect.H)
+	el.Rect = rect
+
+	transformComp := el.GetComponent("Transform")
+	if transform, ok := transformComp.(*Transform); ok {
+		center := rect.Center()
+		if transform.WorldPosition() != center {
+			transform.SetWorldPosition(center)
+		}
+	}
+
+	for _, compSlice := range el.Parent.GetComponents() {
+		for _, comp := range compSlice {
+			if setter, ok := comp.(SizeSetter); ok {
+				setter.SetSize(int(rect.W), int(rect.H))
+			}
+		}
+	}
+
+	el.arrange()
+}
+
+// Places the children inside the content rect
+func (el *UIElement) arrange() {
+	children := el.ChildElements()
+	if len(children) < 1 {
+		return
+	}
+	content := el.ContentRect()
+
+	switch el.Layout {
+	case LAYOUTHORIZONTAL, LAYOUTVERTICAL:
+		el.arrangeStack(children, content, el.Layout == LAYOUTHORIZONTAL)
+	case LAYOUTGRID:
+		columns, rows := el.gridSize(len(children))
+		cellW, cellH := maxOuterSize(children)
+		if !el.AutoWidth {
+			cellW = math.Max(0, (content.W-float64(el.Spacing*(columns-1)))/float64(columns))
+		}
+		if !el.AutoHeight {
+			cellH = math.Max(0, (content.H-float64(el.Spacing*(rows-1)))/float64(rows))
+		}
+		for k, child := range children {
+			column, row := k%columns, k/columns
+			child.place(Rect{
+				X: content.X + float64(column)*(cellW+float64(el.Spacing)),
+				Y: content.Y + float64(row)*(cellH+float64(el.Spacing)),
+				W: cellW,
+				H: cellH,
+			})
+		}
+	default:
+		for _, child := range children {
+			child.place(content)
+		}
+	}
+}
+
+// Children are put after each other along the main axis, on the other axis they're placed
+// by their anchors. Children stretching along the main axis share the space that's left
+func (el *UIElement) arrangeStack(children []*UIElement, content Rect, horizontal bool) {
+	mainLength := content.H
+	if horizontal {
+		mainLength = content.W
+	}
+
+	used := float64(el.Spacing * (len(children) - 1))
+	stretched := 0
+	for _, child := range children {
+		w, h := child.outerSize()
+		if horizontal && child.StretchX {
+			stretched++
+			used += float64(child.Margin.Horizontal())
+		} else if !horizontal && child.StretchY {
+			stretched++
+			used += float64(child.Margin.Vertical())
+		} else if horizontal {
+			used += w
+		} else {
+			used += h
+		}
+	}
+
+	share := 0.0
+	if stretched > 0 {
+		share = math.Max(0, (mainLength-used)/float64(stretched))
+	}
+
+	cursor := content.X
+	if !horizontal {
+		cursor = content.Y
+	}
+	for _, child := range children {
+		if horizontal {
+			w := child.measuredW
+			if child.StretchX {
+				w = math.Max(share, float64(child.MinWidth))
+			}
+			y, h := placeAxis(content.Y, content.H, child.AnchorY, child.PivotY, child.OffsetY, child.measuredH, child.Margin.Top, child.Margin.Bottom, child.StretchY, child.MinHeight)
+			x := cursor + float64(child.Margin.Left)
+			child.setRect(Rect{x + child.OffsetX, y, w, h})
+			cursor = x + w + float64(child.Margin.Right+el.Spacing)
+		} else {
+			h := child.measuredH
+			if child.StretchY {
+				h = math.Max(share, float64(child.MinHeight))
+			}
+			x, w := placeAxis(content.X, content.W, child.AnchorX, child.PivotX, child.OffsetX, child.measuredW, child.Margin.Left, child.Margin.Right, child.StretchX, child.MinWidth)
+			y := cursor + float64(child.Margin.Top)
+			child.setRect(Rect{x, y + child.OffsetY, w, h})
+			cursor = y + h + float64(child.Margin.Bottom+el.Spacing)
+		}
+	}
+}
+
+// Gets all the mouse events while it has captured the mouse, used for dragging and open popups
+type MouseCapturer interface {
+	Component
+	CapturedMouseMove(x, y int)
+	CapturedMouseButton(x, y, button int, up bool)
+}
+
+// Implemented by components that can have the keyboard focus, see UISystem.SetFocus
+type Focusable interface {
+	Component
+	FocusGained()
+	FocusLost()
+}
+
+// Gets the text typed while focused, SDL text input is started while one of these has the focus
+// TextEditing gets the composition text of input methods (IME) before it's committed with TextInput
+type TextInputListener interface {
+	Focusable
+	TextInput(text string)
+	TextEditing(text string, start, length int)
+}
+
+// Drawn on top of everything else while added with UISystem.AddOverlay
+type OverlayDrawAble interface {
+	Component
+	DrawOverlay(renderer *sdl.Renderer)
+}
+
+// Keeps track of the ui elements and lays them out every frame before drawing,
+// also handles mouse capturing and overlays for the widgets
+type UISystem struct {
+	BaseSystem
+	DefaultStyle *WidgetStyle // Used by widgets without a style
+
+	// Moving the focus with tab, arrows, enter and escape or the d-pad, A and B, see focus.go
+	KeyboardNavigation   bool
+	ControllerNavigation bool
+
+	OnThemeChanged func(name string)
+
+	HotReload bool // Reloads the screens loaded with Engine.LoadUI when their files change
+
+	// Tooltips, context menus and modals, see popups.go
+	TooltipDelay float64
+	ModalShade   sdl.Color // Drawn over everything behind a modal
+	OKText       string    // Button texts of Confirm and Alert
+	CancelText   string
+
+	engine     *Engine
+	capture    MouseCapturer
+	overlays   []OverlayDrawAble
+	focused    Focusable
+	navigables []Navigable
+	scopes     []focusScope
+	themes     map[string]*Theme
+	theme      *Theme
+	themeables []Themeable
+
+	bindings    map[string]func(UIEvent)
+	screens     []*UIScreen
+	reloadTimer float64
+
+	mouseX, mouseY int
+	tooltip        tooltip
+	menu           *contextMenu
+	modals         []modal
+	destroyed      []Entity
+}
+
+func NewUISystem(e *Engine) *UISystem {
+	return &UISystem{
+		DefaultStyle:         DefaultWidgetStyle(),
+		KeyboardNavigation:   true,
+		ControllerNavigation: true,
+		TooltipDelay:         TOOLTIPDELAY,
+		ModalShade:           sdl.Color{0, 0, 0, 140},
+		OKText:               "OK",
+		CancelText:           "Cancel",
+		engine:               e,
+	}
+}
+
+func (ui *UISystem) AddComponent(component Component) {
+	if _, ok := component.(*UIElement); ok {
+		ui.Components = append(ui.Components, component)
+	}
+	if navigable, ok := component.(Navigable); ok {
+		ui.navigables = append(ui.navigables, navigable)
+	}
+	if themeable, ok := component.(Themeable); ok {
+		ui.themeables = append(ui.themeables, themeable)
+	}
+}
+
+func (ui *UISystem) RemoveComponent(component Component) {
+	ui.BaseSystem.RemoveComponent(component)
+	if ui.capture != nil && Component(ui.capture) == component {
+		ui.capture = nil
+	}
+	if overlay, ok := component.(OverlayDrawAble); ok {
+		ui.RemoveOverlay(overlay)
+	}
+	if ui.focused != nil && Component(ui.focused) == component {
+		ui.ClearFocus()
+	}
+	for k, v := range ui.navigables {
+		if Component(v) == component {
+			ui.navigables = append(ui.navigables[:k], ui.navigables[k+1:]...)
+			break
+		}
+	}
+	for k, v := range ui.themeables {
+		if Component(v) == component {
+			ui.themeables = append(ui.themeables[:k], ui.themeables[k+1:]...)
+			break
+		}
+	}
+	for k := len(ui.scopes) - 1; k >= 0; k-- {
+		if ui.scopes[k].previous != nil && Component(ui.scopes[k].previous) == component {
+			ui.scopes[k].previous = nil
+		}
+	}
+	if ui.tooltip.owner != nil && ui.tooltip.owner == component {
+		ui.HideTooltip()
+	}
+	// Modals removed from the engine without CloseModal
+	for k := len(ui.modals) - 1; k >= 0; k-- {
+		if root := ui.modals[k].root; component.GetParent() == root && !root.Added() {
+			ui.modals = append(ui.modals[:k], ui.modals[k+1:]...)
+			ui.PopFocusScope(root)
+		}
+	}
+}
+
+func (ui *UISystem) Clear() {
+	ui.BaseSystem.Clear()
+	ui.capture = nil
+	ui.overlays = nil
+	ui.navigables = nil
+	ui.themeables = nil
+	ui.scopes = nil
+	ui.modals = nil
+	ui.menu = nil
+	ui.HideTooltip()
+	ui.ClearFocus()
+}
+
+// Gives f the keyboard focus, nil clears it. Ignored if f is outside the modal focus scope
+// SDL text input is started and stopped depending on whether f is a TextInputListener
+func (ui *UISystem) SetFocus(f Focusable) {
+	if f == ui.focused || (f != nil && ui.outsideScope(f)) {
+		return
+	}
+
+	old := ui.focused
+	ui.focused = f
+	if old != nil {
+		old.FocusLost()
+	}
+
+	_, wasText := old.(TextInputListener)
+	_, isText := f.(TextInputListener)
+	if isText && !wasText {
+		sdl.StartTextInput()
+	} else if wasText && !isText {
+		sdl.StopTextInput()
+	}
+
+	if f != nil {
+		f.FocusGained()
+	}
+}
+
+func (ui *UISystem) ClearFocus() {
+	ui.SetFocus(nil)
+}
+
+func (ui *UISystem) Focused() Focusable {
+	return ui.focused
+}
+
+// Tells SDL where the text is being typed so input method windows can be placed next to it, in screen coordinates
+func (ui *UISystem) SetTextInputRect(rect Rect) {
+	x, y := ui.engine.Display.LogicalToWindow(int(rect.X), int(rect.Y))
+	right, bottom := ui.engine.Display.LogicalToWindow(int(rect.Right()), int(rect.Bottom()))
+	sdl.SetTextInputRect(&sdl.Rect{X: int32(x), Y: int32(y), W: int32(right - x), H: int32(bottom - y)})
+}
+
+// Text typed by the user, goes to the focused component
+func (ui *UISystem) textInput(text string) {
+	if listener, ok := ui.focused.(TextInputListener); ok {
+		listener.TextInput(text)
+	}
+}
+
+func (ui *UISystem) textEditing(text string, start, length int) {
+	if listener, ok := ui.focused.(TextInputListener); ok {
+		listener.TextEditing(text, start, length)
+	}
+}
+
+// Keys go only to the focused component while it takes text input, otherwise they're used for
+// navigation if they can be, returns true if the key was used. An open context menu gets them first
+func (ui *UISystem) keyEvent(key sdl.Keycode, up bool) bool {
+	if ui.menu != nil && !up {
+		ui.menuKey(key)
+		return true
+	}
+	listener, ok := ui.focused.(TextInputListener)
+	if !ok {
+		return !up && ui.navigationKey(key)
+	}
+	// Tab and up/down still move out of text fields
+	if !up && (key == sdl.K_TAB || key == sdl.K_UP || key == sdl.K_DOWN) && ui.navigationKey(key) {
+		return true
+	}
+	if keyboard, ok := listener.(KeyboardListener); ok {
+		if up {
+			keyboard.KeyUp(key)
+		} else {
+			keyboard.KeyDown(key)
+		}
+	}
+	return true
+}
+
+// Sends all mouse events to c until it's released, other components get nothing in the meantime
+func (ui *UISystem) CaptureMouse(c MouseCapturer) {
+	ui.capture = c
+}
+
+// Releases the mouse if c has it captured
+func (ui *UISystem) ReleaseMouse(c MouseCapturer) {
+	if ui.capture == c {
+		ui.capture = nil
+	}
+}
+
+func (ui *UISystem) MouseCapturedBy() MouseCapturer {
+	return ui.capture
+}
+
+// Returns true if the event went to the capturer or the context menu
+func (ui *UISystem) captureMouseMove(x, y int) bool {
+	ui.mouseX, ui.mouseY = x, y
+	if ui.menu != nil {
+		ui.menuMouseMove(x, y)
+		return true
+	}
+	if ui.capture == nil {
+		return false
+	}
+	ui.capture.CapturedMouseMove(x, y)
+	return true
+}
+
+// Clicking outside the focused component clears the focus, then it's sent to the capturer if any
+// Clicks hide the tooltip and go to the context menu while it's open
+func (ui *UISystem) mouseButton(x, y, button int, up bool) bool {
+	if !up {
+		ui.HideTooltip()
+	}
+	if ui.menu != nil {
+		ui.menuMouseButton(x, y, button, up)
+		return true
+	}
+	if !up && ui.focused != nil && ui.capture == nil {
+		if bounded, ok := ui.focused.(BoundedDrawAble); ok {
+			bounds, _ := bounded.Bounds()
+			if !bounds.Contains(float64(x), float64(y)) {
+				ui.ClearFocus()
+			}
+		}
+	}
+
+	if ui.capture == nil {
+		return false
+	}
+	ui.capture.CapturedMouseButton(x, y, button, up)
+	return true
+}
+
+// Scrolling hides the tooltip, the context menu eats it while it's open
+func (ui *UISystem) mouseWheel() bool {
+	ui.HideTooltip()
+	return ui.menu != nil
+}
+
+func (ui *UISystem) AddOverlay(overlay OverlayDrawAble) {
+	for _, v := range ui.overlays {
+		if v == overlay {
+			return
+		}
+	}
+	ui.overlays = append(ui.overlays, overlay)
+}
+
+func (ui *UISystem) RemoveOverlay(overlay OverlayDrawAble) {
+	for k, v := range ui.overlays {
+		if v == overlay {
+			ui.overlays = append(ui.overlays[:k], ui.overlays[k+1:]...)
+			return
+		}
+	}
+}
+
+// Draws the modals, then the overlays in the order they were added, then the context menu and the tooltip
+func (ui *UISystem) DrawOverlays(renderer *sdl.Renderer) {
+	ui.drawModals(renderer)
+	for _, overlay := range ui.overlays {
+		if overlay.Enabled() && overlay.GetParent() != nil && overlay.GetParent().Enabled() {
+			overlay.DrawOverlay(renderer)
+		}
+	}
+	ui.drawContextMenu(renderer)
+	ui.drawTooltip(renderer)
+}
+
+// Measures and places all the elements, root elements are placed inside the screen
+func (ui *UISystem) Layout() {
+	// Hidden or disabled since it got the focus
+	if navigable, ok := ui.focused.(Navigable); ok && !navigable.CanFocus() {
+		ui.ClearFocus()
+	}
+
+	screen := ui.engine.ViewRect(true)
+	ui.ForEachComponent(func(comp Component) bool {
+		el, ok := comp.(*UIElement)
+		if !ok || !el.Enabled() || el.ParentElement() != nil {
+			return false
+		}
+
+		el.measure()
+		el.place(screen)
+		return true
+	})
+}
@@ -0,0 +1,578 @@
+package vroom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// How often ui files are checked for changes when hot reloading
+const UIRELOADINTERVAL = 0.5
+
+// Passed to the functions bound with UISystem.Bind, only the fields that make sense for the widget are set
+type UIEvent struct {
+	Screen *UIScreen
+	ID     string    // Id of the widget in the file
+	Widget Component // The widget that fired it
+
+	Checked bool    // Checkbox, Toggle
+	Value   float64 // Slider
+	Index   int     // Dropdown, ListBox, RadioButton
+	Text    string  // TextInput, or the selected option/item
+}
+
+// Named points for anchors and pivots
+var uiPoints = map[string][2]float64{
+	"top_left":     {0, 0},
+	"top":          {0.5, 0},
+	"top_right":    {1, 0},
+	"left":         {0, 0.5},
+	"center":       {0.5, 0.5},
+	"right":        {1, 0.5},
+	"bottom_left":  {0, 1},
+	"bottom":       {0.5, 1},
+	"bottom_right": {1, 1},
+}
+
+// Anchors and pivots are a name from uiPoints or [x, y]
+type uiPoint struct {
+	Point *[2]float64
+}
+
+func (p *uiPoint) UnmarshalJSON(raw []byte) error {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		point, ok := uiPoints[name]
+		if !ok {
+			return fmt.Errorf("Unknown point %q", name)
+		}
+		p.Point = &point
+		return nil
+	}
+	var point [2]float64
+	if err := json.Unmarshal(raw, &point); err != nil {
+		return err
+	}
+	p.Point = &point
+	return nil
+}
+
+var uiLayouts = map[string]int{
+	"":           LAYOUTNONE,
+	"none":       LAYOUTNONE,
+	"horizontal": LAYOUTHORIZONTAL,
+	"vertical":   LAYOUTVERTICAL,
+	"grid":       LAYOUTGRID,
+}
+
+type uiNodeJSON struct {
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+	Text     string `json:"text"`
+	Key      string `json:"key"` // Localization key, used instead of text
+	Style    string `json:"style"`
+	Disabled bool   `json:"disabled"`
+	Hidden   bool   `json:"hidden"`
+	TabIndex int    `json:"tab_index"`
+	Tooltip  string `json:"tooltip"`
+
+	// Layout
+	Layout    string      `json:"layout"`
+	Spacing   int         `json:"spacing"`
+	Columns   int         `json:"columns"`
+	Anchor    uiPoint     `json:"anchor"`
+	Pivot     uiPoint     `json:"pivot"`
+	Offset    [2]float64  `json:"offset"`
+	Width     int         `json:"width"`
+	Height    int         `json:"height"`
+	MinWidth  int         `json:"min_width"`
+	MinHeight int         `json:"min_height"`
+	StretchX  bool        `json:"stretch_x"`
+	StretchY  bool        `json:"stretch_y"`
+	Margin    themeInsets `json:"margin"`
+	Padding   themeInsets `json:"padding"`
+
+	// Bindings, names of functions registered with UISystem.Bind
+	OnClick  string `json:"on_click"`
+	OnChange string `json:"on_change"`
+	OnSubmit string `json:"on_submit"`
+	OnClose  string `json:"on_close"`
+
+	// Widget values
+	Checked     bool     `json:"checked"`
+	Min         float64  `json:"min"`
+	Max         float64  `json:"max"`
+	Value       float64  `json:"value"`
+	Step        float64  `json:"step"`
+	ShowValue   bool     `json:"show_value"`
+	Format      string   `json:"format"`
+	Options     []string `json:"options"`
+	Selected    *int     `json:"selected"`
+	Items       []string `json:"items"`
+	Rows        int      `json:"rows"`
+	Placeholder string   `json:"placeholder"`
+	MaxLength   int      `json:"max_length"`
+	Password    bool     `json:"password"`
+	Closable    bool     `json:"closable"`
+	Group       string   `json:"group"` // Radio buttons with the same group name are in one RadioGroup
+
+	Children []uiNodeJSON `json:"children"`
+}
+
+type uiFileJSON struct {
+	Styles map[string]themeStyleJSON `json:"styles"`
+	Root   uiNodeJSON                `json:"root"`
+}
+
+// Entity hierarchy built from a ui file with Engine.LoadUI
+type UIScreen struct {
+	Root   Entity
+	Path   string
+	Styles *Theme // Styles defined in the file, built on the current theme and rebuilt when it changes
+
+	// Called after the file was loaded again, to set up what the file can't
+	OnReload func(screen *UIScreen)
+
+	engine    *Engine
+	rawStyles map[string]themeStyleJSON
+	entities  map[string]Entity
+	widgets   map[string]Component
+	groups    map[string]*RadioGroup
+	labels    map[*Label]string // Labels using a style from the file, by style name
+	modTime   time.Time
+}
+
+// Loads a ui file and builds the entities, add Root to the engine to show it:
+// {"styles": {...}, "root": {"type": "panel", "layout": "vertical", "anchor": "center", "padding": 8,
+// "children": [{"type": "button", "id": "start", "text": "Start", "on_click": "start_game"}]}}
+// Types are panel, window, button, label, checkbox, toggle, radio, slider, progress, dropdown, list,
+// textinput and box (just an element for layout). Styles are in the same format as in theme files,
+// they build on the style with the same name in the current theme (or its default) unless they extend
+// another one, style names that aren't in the file are looked up in the current theme
+// Loaded screens are reloaded when the file changes while Engine.UI.HotReload is set
+func (e *Engine) LoadUI(path string) (*UIScreen, error) {
+	screen := &UIScreen{
+		Path:   path,
+		engine: e,
+	}
+	err := screen.load()
+	if err != nil {
+		return nil, err
+	}
+	e.UI.screens = append(e.UI.screens, screen)
+	return screen, nil
+}
+
+func (s *UIScreen) load() error {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return err
+	}
+	raw, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return err
+	}
+
+	var decoded uiFileJSON
+	err = json.Unmarshal(raw, &decoded)
+	if err != nil {
+		return fmt.Errorf("%s: %s", s.Path, err)
+	}
+
+	styles, err := s.buildStyles(decoded.Styles)
+	if err != nil {
+		return fmt.Errorf("%s: %s", s.Path, err)
+	}
+
+	// The old screen is kept if the new one fails to build
+	oldStyles, oldRaw, oldEntities, oldWidgets, oldLabels := s.Styles, s.rawStyles, s.entities, s.widgets, s.labels
+	s.Styles, s.rawStyles = styles, decoded.Styles
+	s.entities = make(map[string]Entity)
+	s.widgets = make(map[string]Component)
+	s.groups = make(map[string]*RadioGroup)
+	s.labels = make(map[*Label]string)
+	root, err := s.build(decoded.Root)
+	if err != nil {
+		s.Styles, s.rawStyles, s.entities, s.widgets, s.labels = oldStyles, oldRaw, oldEntities, oldWidgets, oldLabels
+		return fmt.Errorf("%s: %s", s.Path, err)
+	}
+	s.Root = root
+	s.modTime = info.ModTime()
+	return nil
+}
+
+func (s *UIScreen) buildStyles(styles map[string]themeStyleJSON) (*Theme, error) {
+	return buildTheme(strings.TrimSuffix(filepath.Base(s.Path), filepath.Ext(s.Path)), styles, s.themeStyle)
+}
+
+// Style the styles in the file build on, DefaultStyle if there's no theme
+func (s *UIScreen) themeStyle(name string) *WidgetStyle {
+	if style := s.engine.UI.ThemeStyle(name); style != nil {
+		return style
+	}
+	return s.engine.UI.DefaultStyle
+}
+
+// Builds the styles from the file again on the current theme, the new values are copied
+// into the old styles so the widgets using them keep them
+func (s *UIScreen) restyle() {
+	if s.rawStyles == nil {
+		return
+	}
+	styles, err := s.buildStyles(s.rawStyles)
+	if err != nil {
+		fmt.Println("Error restyling", s.Path, err)
+		return
+	}
+	for name, style := range styles.Styles {
+		if old, ok := s.Styles.Styles[name]; ok {
+			*old = *style
+		} else {
+			s.Styles.Styles[name] = style
+		}
+	}
+	for label, name := range s.labels {
+		if style, ok := s.Styles.Styles[name]; ok {
+			styleLabel(label, style)
+			if label.Texture != nil {
+				label.SetText(label.Text)
+			}
+		}
+	}
+}
+
+func (ui *UISystem) restyleScreens() {
+	for _, screen := range ui.screens {
+		screen.restyle()
+	}
+}
+
+// Loads the file again and replaces the entities, keeping the root where it was in the scene
+// The widgets are new so any state not in the file is lost, OnReload can restore it
+func (s *UIScreen) Reload() error {
+	old := s.Root
+	err := s.load()
+	if err != nil {
+		return err
+	}
+
+	parent := old.GetParent()
+	added := old.Added()
+	if parent != nil {
+		parent.RemoveChild(old, added)
+		parent.AddChild(s.Root, added)
+	} else if added {
+		s.engine.RemoveEntity(old)
+		s.engine.AddEntity(s.Root)
+	}
+	old.Destroy()
+
+	if s.OnReload != nil {
+		s.OnReload(s)
+	}
+	return nil
+}
+
+// Entity of the node with the id, nil if there's none
+func (s *UIScreen) Entity(id string) Entity {
+	return s.entities[id]
+}
+
+// Widget (or label) of the node with the id, nil if there's none
+func (s *UIScreen) Get(id string) Component {
+	return s.widgets[id]
+}
+
+// Removes the screen from the engine and stops watching the file
+func (s *UIScreen) Destroy() {
+	if s.Root != nil {
+		s.engine.DestroyEntity(s.Root)
+	}
+	ui := s.engine.UI
+	for k, v := range ui.screens {
+		if v == s {
+			ui.screens = append(ui.screens[:k], ui.screens[k+1:]...)
+			break
+		}
+	}
+}
+
+// Returns a function calling the bound function with the screen, id and widget filled in, nil if
+// there's no name. The function is looked up on every call so it can be bound after loading
+func (s *UIScreen) bind(name, id string, widget Component) func(UIEvent) {
+	if name == "" {
+		return nil
+	}
+	return func(event UIEvent) {
+		event.Screen, event.ID = s, id
+		if event.Widget == nil {
+			event.Widget = widget
+		}
+		s.engine.UI.Call(name, event)
+	}
+}
+
+func (s *UIScreen) applyStyle(widget *Widget, name string) {
+	if name == "" {
+		return
+	}
+	if style, ok := s.Styles.Styles[name]; ok {
+		widget.Style = style
+	} else {
+		widget.StyleName = name
+	}
+}
+
+func (s *UIScreen) build(node uiNodeJSON) (Entity, error) {
+	layout, ok := uiLayouts[node.Layout]
+	if !ok {
+		return nil, fmt.Errorf("Unknown layout %q", node.Layout)
+	}
+
+	el := NewAutoUIElement(layout, node.Spacing)
+	el.Columns = node.Columns
+	if node.Width > 0 {
+		el.AutoWidth = false
+		el.Width = node.Width
+	}
+	if node.Height > 0 {
+		el.AutoHeight = false
+		el.Height = node.Height
+	}
+	if node.Anchor.Point != nil {
+		el.SetAnchor(node.Anchor.Point[0], node.Anchor.Point[1])
+	}
+	if node.Pivot.Point != nil {
+		el.PivotX, el.PivotY = node.Pivot.Point[0], node.Pivot.Point[1]
+	}
+	el.OffsetX, el.OffsetY = node.Offset[0], node.Offset[1]
+	el.MinWidth, el.MinHeight = node.MinWidth, node.MinHeight
+	el.StretchX, el.StretchY = node.StretchX, node.StretchY
+	if node.Margin.Insets != nil {
+		el.Margin = *node.Margin.Insets
+	}
+	if node.Padding.Insets != nil {
+		el.Padding = *node.Padding.Insets
+	}
+
+	component, widget, err := s.buildWidget(node)
+	if err != nil {
+		return nil, err
+	}
+
+	var ent Entity
+	if component != nil {
+		ent = NewWidgetEntity(component, el)
+	} else {
+		ent = NewEntity(0, 0)
+		ent.AddComponent(el)
+	}
+
+	if widget != nil {
+		widget.Text = node.Text
+		if node.Key != "" {
+			widget.Key = node.Key
+		}
+		widget.Disabled = node.Disabled
+		widget.TabIndex = node.TabIndex
+		widget.Tooltip = node.Tooltip
+		s.applyStyle(widget, node.Style)
+	}
+	if node.ID != "" {
+		if _, exists := s.entities[node.ID]; exists {
+			return nil, fmt.Errorf("Duplicate id %q", node.ID)
+		}
+		s.entities[node.ID] = ent
+		if component != nil {
+			s.widgets[node.ID] = component
+		}
+	}
+
+	for _, childNode := range node.Children {
+		child, err := s.build(childNode)
+		if err != nil {
+			return nil, err
+		}
+		ent.AddChild(child, false)
+	}
+
+	if node.Hidden {
+		SetEntityEnabled(ent, false)
+	}
+	return ent, nil
+}
+
+// Creates the component for the node type, with its Widget if it has one
+func (s *UIScreen) buildWidget(node uiNodeJSON) (Component, *Widget, error) {
+	switch node.Type {
+	case "", "box":
+		return nil, nil, nil
+	case "panel":
+		panel := NewPanel()
+		return panel, &panel.Widget, nil
+	case "window":
+		window := NewWindow(node.Text, node.Closable)
+		if call := s.bind(node.OnClose, node.ID, window); call != nil {
+			window.OnClose = func() { call(UIEvent{}) }
+		}
+		return window, &window.Widget, nil
+	case "button":
+		button := NewButton(node.Text, nil)
+		if call := s.bind(node.OnClick, node.ID, button); call != nil {
+			button.OnClick = func() { call(UIEvent{}) }
+		}
+		return button, &button.Widget, nil
+	case "label":
+		return s.buildLabel(node), nil, nil
+	case "checkbox":
+		checkbox := NewCheckbox(node.Text, node.Checked, nil)
+		if call := s.bind(node.OnChange, node.ID, checkbox); call != nil {
+			checkbox.OnChanged = func(checked bool) { call(UIEvent{Checked: checked}) }
+		}
+		return checkbox, &checkbox.Widget, nil
+	case "toggle":
+		toggle := NewToggle(node.Text, node.Checked, nil)
+		if call := s.bind(node.OnChange, node.ID, toggle); call != nil {
+			toggle.OnChanged = func(on bool) { call(UIEvent{Checked: on}) }
+		}
+		return toggle, &toggle.Widget, nil
+	case "radio":
+		group, ok := s.groups[node.Group]
+		if !ok {
+			group = NewRadioGroup(-1, nil)
+			s.groups[node.Group] = group
+		}
+		radio := group.NewButton(node.Text)
+		if node.Checked {
+			group.Selected = radio.Index
+		}
+		// Any button in the group can bind it, the event has the selected one
+		if call := s.bind(node.OnChange, node.ID, radio); call != nil {
+			group.OnChanged = func(index int) {
+				selected := group.Buttons[index]
+				call(UIEvent{Widget: selected, Index: index, Text: selected.Text})
+			}
+		}
+		return radio, &radio.Widget, nil
+	case "slider":
+		slider := NewSlider(node.Min, node.Max, node.Value, nil)
+		slider.Step = node.Step
+		slider.ShowValue = node.ShowValue
+		slider.ValueFormat = node.Format
+		if call := s.bind(node.OnChange, node.ID, slider); call != nil {
+			slider.OnChanged = func(value float64) { call(UIEvent{Value: value}) }
+		}
+		return slider, &slider.Widget, nil
+	case "progress":
+		progress := NewProgressBar(node.Value, node.Max)
+		return progress, &progress.Widget, nil
+	case "dropdown":
+		selected := 0
+		if node.Selected != nil {
+			selected = *node.Selected
+		}
+		dropdown := NewDropdown(node.Options, selected, nil)
+		if call := s.bind(node.OnChange, node.ID, dropdown); call != nil {
+			dropdown.OnChanged = func(index int, option string) { call(UIEvent{Index: index, Text: option}) }
+		}
+		return dropdown, &dropdown.Widget, nil
+	case "list":
+		list := NewListBox(node.Items, node.Rows, nil)
+		if node.Selected != nil {
+			list.Selected = *node.Selected
+		}
+		if call := s.bind(node.OnChange, node.ID, list); call != nil {
+			list.OnSelect = func(index int, item string) { call(UIEvent{Index: index, Text: item}) }
+		}
+		return list, &list.Widget, nil
+	case "textinput":
+		input := NewTextInput(node.Text, node.Placeholder, nil)
+		input.MaxLength = node.MaxLength
+		input.Password = node.Password
+		if call := s.bind(node.OnChange, node.ID, input); call != nil {
+			input.OnChanged = func(text string) { call(UIEvent{Text: text}) }
+		}
+		if call := s.bind(node.OnSubmit, node.ID, input); call != nil {
+			input.OnSubmit = func(text string) { call(UIEvent{Text: text}) }
+		}
+		return input, &input.Widget, nil
+	}
+	return nil, nil, fmt.Errorf("Unknown widget type %q", node.Type)
+}
+
+// Labels with a style from the file get its font and colors, otherwise they follow the theme
+func (s *UIScreen) buildLabel(node uiNodeJSON) *Label {
+	label := NewThemedLabel(node.Text, true, node.Style)
+	label.IgnoreCamera = true
+	label.Key = node.Key
+	if label.StyleName == "" {
+		label.StyleName = "Label"
+	}
+
+	style, ok := s.Styles.Styles[node.Style]
+	if !ok {
+		// Used if there's no theme
+		label.Font = s.engine.UI.DefaultStyle.Font
+		label.Color = s.engine.UI.DefaultStyle.States[WIDGETIDLE].TextColor
+		return label
+	}
+	label.StyleName = ""
+	styleLabel(label, style)
+	s.labels[label] = node.Style
+	return label
+}
+
+func styleLabel(label *Label, style *WidgetStyle) {
+	label.Font, label.FontOutline = style.Font, style.Outline
+	label.Color = style.States[WIDGETIDLE].TextColor
+	if style.OutlineColor.A > 0 {
+		label.ColorOutline = style.OutlineColor
+	}
+}
+
+// Registers a function that ui files can bind to widgets by name
+func (ui *UISystem) Bind(name string, fn func(event UIEvent)) {
+	if ui.bindings == nil {
+		ui.bindings = make(map[string]func(UIEvent))
+	}
+	ui.bindings[name] = fn
+}
+
+// Calls the function bound to name
+func (ui *UISystem) Call(name string, event UIEvent) {
+	fn, ok := ui.bindings[name]
+	if !ok {
+		fmt.Println("UI binding not found:", name)
+		return
+	}
+	fn(event)
+}
+
+// Reloads the screens whose files changed, every UIRELOADINTERVAL seconds while HotReload is set
+func (ui *UISystem) checkReload(dt float64) {
+	if !ui.HotReload {
+		return
+	}
+	ui.reloadTimer += dt
+	if ui.reloadTimer < UIRELOADINTERVAL {
+		return
+	}
+	ui.reloadTimer = 0
+
+	for _, screen := range ui.screens {
+		info, err := os.Stat(screen.Path)
+		if err != nil || !info.ModTime().After(screen.modTime) {
+			continue
+		}
+		err = screen.Reload()
+		if err != nil {
+			// Not retried until the file changes again
+			screen.modTime = info.ModTime()
+			fmt.Println("Failed reloading ui:", err)
+		}
+	}
+}
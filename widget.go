@@ -0,0 +1,674 @@
+package vroom
+
+import (
+	"github.com/jonas747/go-box2d-lite/box2dlite"
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+)
+
+// Widget states, also used to index WidgetStyle.States
+const (
+	WIDGETIDLE = iota
+	WIDGETHOVER
+	WIDGETPRESSED
+	WIDGETDISABLED
+	WIDGETFOCUSED // Has the focus, styles without it use the hover style
+	WIDGETSTATES  // Number of states
+)
+
+// Default layers, panels are drawn below the widgets since children are drawn before their parents
+const (
+	UIPANELLAYER = 4
+	UILAYER      = 5
+)
+
+// How a widget looks in one state
+type StateStyle struct {
+	Texture   string // Nine sliced background, if empty it's filled with Fill and outlined with Border
+	Fill      sdl.Color
+	Border    sdl.Color
+	TextColor sdl.Color
+}
+
+// How widgets look and sound
+type WidgetStyle struct {
+	Font    string // Name of a ttf font or BMFont, drawn through GetGlyphFont
+	Padding Insets
+	Slice   Insets    // Nine slice borders of the state textures
+	Accent  sdl.Color // Checkmarks, slider fills, progress and selections
+
+	// Widgets draw their text with a 1 pixel outline in OutlineColor if it's set,
+	// themed labels use the Outline font with it instead
+	Outline      string
+	OutlineColor sdl.Color
+
+	ClickSound string
+	HoverSound string
+
+	States [WIDGETSTATES]StateStyle
+}
+
+// Plain flat style that works without any textures, the font still has to be set
+func DefaultWidgetStyle() *WidgetStyle {
+	return &WidgetStyle{
+		Padding: Insets{8, 4, 8, 4},
+		Accent:  sdl.Color{90, 160, 230, 255},
+		States: [WIDGETSTATES]StateStyle{
+			WIDGETIDLE:     {Fill: sdl.Color{60, 60, 70, 255}, Border: sdl.Color{110, 110, 125, 255}, TextColor: sdl.Color{230, 230, 230, 255}},
+			WIDGETHOVER:    {Fill: sdl.Color{80, 80, 95, 255}, Border: sdl.Color{150, 150, 170, 255}, TextColor: sdl.Color{255, 255, 255, 255}},
+			WIDGETPRESSED:  {Fill: sdl.Color{45, 45, 55, 255}, Border: sdl.Color{150, 150, 170, 255}, TextColor: sdl.Color{200, 200, 200, 255}},
+			WIDGETDISABLED: {Fill: sdl.Color{50, 50, 55, 255}, Border: sdl.Color{70, 70, 75, 255}, TextColor: sdl.Color{120, 120, 120, 255}},
+			WIDGETFOCUSED:  {Fill: sdl.Color{70, 70, 85, 255}, Border: sdl.Color{90, 160, 230, 255}, TextColor: sdl.Color{255, 255, 255, 255}},
+		},
+	}
+}
+
+// Implemented by everything embedding Widget, clicks on them are the UI's and don't reach the game
+type uiWidget interface {
+	widget() *Widget
+}
+
+func (w *Widget) widget() *Widget {
+	return w
+}
+
+// Hooks the concrete widgets implement, Widget calls them through self
+type widgetClicker interface {
+	click(x, y int)
+}
+
+type widgetPresser interface {
+	press(x, y int)
+}
+
+type widgetHoverer interface {
+	hover(x, y int)
+}
+
+type widgetStateListener interface {
+	stateChanged(state int)
+}
+
+// Navigation hooks, without them activating clicks the center and directions move the focus
+type widgetActivator interface {
+	activate()
+}
+
+type widgetNavigator interface {
+	navigate(dir int) bool
+}
+
+type widgetCanceler interface {
+	cancel() bool
+}
+
+// Common base of the widgets, tracks the hover/press/disabled state, plays the sounds and
+// draws with the style. Widgets are drawn in screen space centered on the transform like sprites,
+// a MouseBox is added automatically and resized with the widget
+type Widget struct {
+	BaseComponent
+	LocalizedText // Set Key to show localized text instead of Text
+
+	Text      string
+	Style     *WidgetStyle // Overrides the theme if set
+	StyleName string       // Style in the current theme, the widget name (Button...) is used if empty or missing
+	Layer     int
+
+	Width, Height int  // Set by the layout if the entity has a UIElement, otherwise the preferred size is used if 0
+	Disabled      bool // Ignores input and uses the disabled style, unlike SetEnabled(false) it's still drawn
+
+	// Override the sounds of the style if set
+	ClickSound string
+	HoverSound string
+
+	IsHover     bool
+	IsMouseDown bool
+
+	Tooltip     string     // Shown after resting the mouse on it for UISystem.TooltipDelay
+	ContextMenu []MenuItem // Opened with the right mouse button
+
+	// Keyboard and controller navigation, see focus.go
+	NoFocus   bool                     // Skipped by navigation
+	TabIndex  int                      // Lower goes first, 0 for screen order after the ones with an index
+	Neighbors [NAVDIRECTIONS]Navigable // Overrides the closest widget in a direction
+
+	OnStateChanged func(state int)
+
+	self      Component
+	lastState int
+	focused   bool
+	autoSize  bool // Sized by Size, so it's resized when the theme changes
+}
+
+// Has to be called from the Init of the widgets embedding this, with the widget itself
+func (w *Widget) initWidget(self Component) {
+	w.self = self
+	if w.GetComponent("Transform") == nil {
+		w.AddComponent(&Transform{})
+	}
+	if w.GetComponent("MouseBox") == nil {
+		w.AddComponent(&MouseBox{})
+	}
+
+	w.Relocalize()
+	if w.Width == 0 && w.Height == 0 {
+		if sizer, ok := self.(Sizer); ok {
+			w.Width, w.Height = sizer.Size()
+			w.autoSize = true
+		}
+	}
+	w.SetSize(w.Width, w.Height)
+	w.lastState = w.State()
+}
+
+func (w *Widget) GetLayer() int {
+	return w.Layer
+}
+
+func (w *Widget) SetSize(width, height int) {
+	changed := width != w.Width || height != w.Height
+	w.Width, w.Height = width, height
+	if comp := w.GetComponent("MouseBox"); comp != nil {
+		if mbox, ok := comp.(*MouseBox); ok {
+			mbox.W, mbox.H = width, height
+		}
+	}
+
+	if e := w.engine(); changed && e != nil && e.DrawSystem != nil {
+		if drawable, ok := w.self.(DrawAble); ok {
+			e.DrawSystem.UpdateBounds(drawable)
+		}
+	}
+}
+
+// Preferred size, the text plus padding, widgets with more parts have their own
+func (w *Widget) Size() (int, int) {
+	style := w.style()
+	tw, th := w.textSize(w.Text)
+	return tw + style.Padding.Horizontal(), th + style.Padding.Vertical()
+}
+
+func (w *Widget) SetText(text string) {
+	w.Text = text
+}
+
+// Shows the text for key in the current locale, see Localization.Text
+func (w *Widget) SetKey(key string, args ...interface{}) {
+	w.LocalizedText = LocalizedText{Key: key, Args: args}
+	w.Relocalize()
+}
+
+func (w *Widget) Relocalize() {
+	if w.Key != "" && w.Parent != nil && w.Parent.GetEngine() != nil {
+		w.Text = w.localize(w.Parent.GetEngine())
+	}
+}
+
+func (w *Widget) SetDisabled(disabled bool) {
+	w.Disabled = disabled
+	if disabled {
+		w.IsMouseDown = false
+		if e := w.engine(); w.focused && e != nil && e.UI != nil {
+			e.UI.ClearFocus()
+		}
+	}
+	w.updateState()
+}
+
+func (w *Widget) State() int {
+	switch {
+	case w.Disabled:
+		return WIDGETDISABLED
+	case w.IsMouseDown:
+		return WIDGETPRESSED
+	case w.focused:
+		return WIDGETFOCUSED
+	case w.IsHover:
+		return WIDGETHOVER
+	}
+	return WIDGETIDLE
+}
+
+func (w *Widget) updateState() {
+	state := w.State()
+	if state == w.lastState {
+		return
+	}
+	w.lastState = state
+
+	if listener, ok := w.self.(widgetStateListener); ok {
+		listener.stateChanged(state)
+	}
+	if w.OnStateChanged != nil {
+		w.OnStateChanged(state)
+	}
+}
+
+func (w *Widget) playSound(sound, styleSound string) {
+	if sound == "" {
+		sound = styleSound
+	}
+	if sound != "" {
+		w.Parent.GetEngine().PlaySound(sound)
+	}
+}
+
+func (w *Widget) MouseEnter() {
+	w.IsHover = true
+	if !w.Disabled {
+		w.playSound(w.HoverSound, w.style().HoverSound)
+	}
+	// Disabled widgets still show it, it may say why they're disabled
+	if e := w.engine(); w.Tooltip != "" && e != nil && e.UI != nil {
+		e.UI.hoverTooltip(w.self, w.Tooltip)
+	}
+	w.updateState()
+}
+
+func (w *Widget) MouseLeave() {
+	w.IsHover = false
+	w.IsMouseDown = false
+	if e := w.engine(); e != nil && e.UI != nil {
+		e.UI.leaveTooltip(w.self)
+	}
+	w.updateState()
+}
+
+func (w *Widget) MouseMove(x, y int) {
+	if hoverer, ok := w.self.(widgetHoverer); ok && !w.Disabled {
+		hoverer.hover(x, y)
+	}
+}
+
+func (w *Widget) MouseDown(x, y, button int) {
+	if button == sdl.BUTTON_RIGHT && len(w.ContextMenu) > 0 && !w.Disabled {
+		w.engine().UI.ShowContextMenu(x, y, w.ContextMenu)
+		return
+	}
+	if w.Disabled || button != sdl.BUTTON_LEFT {
+		return
+	}
+	w.IsMouseDown = true
+	w.updateState()
+	if presser, ok := w.self.(widgetPresser); ok {
+		presser.press(x, y)
+	}
+}
+
+func (w *Widget) MouseUp(x, y, button int) {
+	if button != sdl.BUTTON_LEFT {
+		return
+	}
+	if w.IsMouseDown && !w.Disabled {
+		w.IsMouseDown = false
+		w.updateState()
+		w.playSound(w.ClickSound, w.style().ClickSound)
+		if clicker, ok := w.self.(widgetClicker); ok {
+			clicker.click(x, y)
+		}
+		return
+	}
+	w.IsMouseDown = false
+	w.updateState()
+}
+
+func (w *Widget) CanFocus() bool {
+	return !w.NoFocus && !w.Disabled && w.self != nil && w.Enabled() && w.Parent != nil && w.Parent.Enabled()
+}
+
+func (w *Widget) IsFocused() bool {
+	return w.focused
+}
+
+// Gives this widget the focus through Engine.UI
+func (w *Widget) Focus() {
+	if e := w.engine(); e != nil && e.UI != nil {
+		if focusable, ok := w.self.(Focusable); ok {
+			e.UI.SetFocus(focusable)
+		}
+	}
+}
+
+func (w *Widget) FocusGained() {
+	w.focused = true
+	w.updateState()
+}
+
+func (w *Widget) FocusLost() {
+	w.focused = false
+	w.updateState()
+}
+
+func (w *Widget) TabOrder() int {
+	return w.TabIndex
+}
+
+func (w *Widget) Neighbor(dir int) Navigable {
+	if dir < 0 || dir >= NAVDIRECTIONS {
+		return nil
+	}
+	return w.Neighbors[dir]
+}
+
+// Sets the neighbors for navigating up, down, left and right, nil ones are found automatically
+func (w *Widget) SetNeighbors(up, down, left, right Navigable) {
+	w.Neighbors = [NAVDIRECTIONS]Navigable{up, down, left, right}
+}
+
+func (w *Widget) Navigate(dir int) bool {
+	if navigator, ok := w.self.(widgetNavigator); ok && !w.Disabled {
+		return navigator.navigate(dir)
+	}
+	return false
+}
+
+// Does what clicking it does, with the click sound
+func (w *Widget) Activate() {
+	if w.Disabled {
+		return
+	}
+	if activator, ok := w.self.(widgetActivator); ok {
+		activator.activate()
+		return
+	}
+	if clicker, ok := w.self.(widgetClicker); ok {
+		w.playSound(w.ClickSound, w.style().ClickSound)
+		center := w.Rect().Center()
+		clicker.click(int(center.X), int(center.Y))
+	}
+}
+
+func (w *Widget) Cancel() bool {
+	if canceler, ok := w.self.(widgetCanceler); ok && !w.Disabled {
+		return canceler.cancel()
+	}
+	return false
+}
+
+// Resizes widgets that use their preferred size, ones with a UIElement are resized by the layout
+func (w *Widget) ApplyTheme() {
+	if !w.autoSize || elementOf(w.Parent) != nil {
+		return
+	}
+	if sizer, ok := w.self.(Sizer); ok {
+		w.SetSize(sizer.Size())
+	}
+}
+
+// Releases a press that was captured, for widgets that drag
+func (w *Widget) release() {
+	w.IsMouseDown = false
+	w.updateState()
+}
+
+func (w *Widget) engine() *Engine {
+	if w.Parent == nil {
+		return nil
+	}
+	return w.Parent.GetEngine()
+}
+
+func (w *Widget) style() *WidgetStyle {
+	if w.Style != nil {
+		return w.Style
+	}
+	e := w.engine()
+	if e == nil || e.UI == nil {
+		return DefaultWidgetStyle()
+	}
+	name := ""
+	if w.self != nil {
+		name = w.self.Name()
+	}
+	if style := e.UI.ThemeStyle(w.StyleName, name); style != nil {
+		return style
+	}
+	if e.UI.DefaultStyle != nil {
+		return e.UI.DefaultStyle
+	}
+	return DefaultWidgetStyle()
+}
+
+func (w *Widget) stateStyle(state int) StateStyle {
+	return w.style().State(state)
+}
+
+// Style for the state, the focused state falls back to hover if it isn't set
+func (s *WidgetStyle) State(state int) StateStyle {
+	if state == WIDGETFOCUSED && s.States[state] == (StateStyle{}) {
+		state = WIDGETHOVER
+	}
+	return s.States[state]
+}
+
+func (w *Widget) font() *GlyphFont {
+	e := w.engine()
+	if e == nil {
+		return nil
+	}
+	return e.GetGlyphFont(w.style().Font)
+}
+
+// Size of a single line of text, the height is the line height even for empty text
+func (w *Widget) textSize(text string) (int, int) {
+	font := w.font()
+	if font == nil {
+		return 0, w.lineHeight()
+	}
+	return font.Measure(text), font.LineHeight
+}
+
+func (w *Widget) lineHeight() int {
+	font := w.font()
+	if font == nil {
+		return 16
+	}
+	return font.LineHeight
+}
+
+// The rect the widget covers on the screen
+func (w *Widget) Rect() Rect {
+	transformComp := w.GetComponent("Transform")
+	if transformComp == nil {
+		return Rect{}
+	}
+	transform, ok := transformComp.(*Transform)
+	if !ok {
+		return Rect{}
+	}
+	position := transform.WorldPosition()
+	return Rect{
+		X: math.Floor(position.X - float64(w.Width)/2),
+		Y: math.Floor(position.Y - float64(w.Height)/2),
+		W: float64(w.Width),
+		H: float64(w.Height),
+	}
+}
+
+// Rect minus the padding of the style
+func (w *Widget) contentRect() Rect {
+	rect := w.Rect()
+	padding := w.style().Padding
+	return Rect{
+		X: rect.X + float64(padding.Left),
+		Y: rect.Y + float64(padding.Top),
+		W: math.Max(0, rect.W-float64(padding.Horizontal())),
+		H: math.Max(0, rect.H-float64(padding.Vertical())),
+	}
+}
+
+func (w *Widget) Bounds() (Rect, bool) {
+	return w.Rect(), true
+}
+
+// Draws the background for the state
+func (w *Widget) drawFrame(renderer *sdl.Renderer, rect Rect, state int) {
+	drawStyleFrame(renderer, w.engine(), w.style(), rect, state)
+}
+
+// Draws a line of text vertically centered in rect and aligned with ALIGNLEFT, ALIGNCENTER or ALIGNRIGHT
+func (w *Widget) drawText(renderer *sdl.Renderer, text string, rect Rect, align int, color sdl.Color) {
+	drawStyleText(renderer, w.font(), w.style(), text, rect, align, color)
+}
+
+// Frame drawing for things that aren't widgets themselves, like tooltips and menus
+func drawStyleFrame(renderer *sdl.Renderer, e *Engine, style *WidgetStyle, rect Rect, state int) {
+	stateStyle := style.State(state)
+	if stateStyle.Texture != "" && e != nil {
+		texture := e.GetTexture(stateStyle.Texture)
+		if texture != nil {
+			DrawNineSlice(renderer, texture, nil, rect, style.Slice)
+			return
+		}
+	}
+	fillRect(renderer, rect, stateStyle.Fill)
+	outlineRect(renderer, rect, stateStyle.Border)
+}
+
+func drawStyleText(renderer *sdl.Renderer, font *GlyphFont, style *WidgetStyle, text string, rect Rect, align int, color sdl.Color) {
+	if font == nil || text == "" {
+		return
+	}
+
+	x := rect.X
+	switch align {
+	case ALIGNCENTER:
+		x += math.Floor((rect.W - float64(font.Measure(text))) / 2)
+	case ALIGNRIGHT:
+		x += rect.W - float64(font.Measure(text))
+	}
+	y := rect.Y + math.Floor((rect.H-float64(font.LineHeight))/2)
+	if outline := style.OutlineColor; outline.A > 0 {
+		for _, offset := range [][2]float64{{-1, 0}, {1, 0}, {0, -1}, {0, 1}, {-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+			font.DrawLine(renderer, text, x+offset[0], y+offset[1], 1, outline)
+		}
+	}
+	font.DrawLine(renderer, text, x, y, 1, color)
+}
+
+// Preferred size of a widget with a mark (checkbox, radio button...) followed by the text
+func (w *Widget) markedSize(markW, markH int) (int, int) {
+	style := w.style()
+	tw, th := w.textSize(w.Text)
+	if tw > 0 {
+		tw += style.Padding.Left
+	}
+	return markW + tw + style.Padding.Horizontal(), int(math.Max(float64(markH), float64(th))) + style.Padding.Vertical()
+}
+
+// Draws the text after the mark and returns the rect for the mark
+func (w *Widget) drawMarked(renderer *sdl.Renderer, markW, markH int) Rect {
+	content := w.contentRect()
+	mark := Rect{content.X, content.Y + math.Floor((content.H-float64(markH))/2), float64(markW), float64(markH)}
+
+	padding := float64(w.style().Padding.Left)
+	textRect := Rect{mark.Right() + padding, content.Y, math.Max(0, content.Right()-mark.Right()-padding), content.H}
+	w.drawText(renderer, w.Text, textRect, ALIGNLEFT, w.stateStyle(w.State()).TextColor)
+	return mark
+}
+
+// Clips drawing to rect, the returned function restores the clip rect that was set before
+func clipRect(renderer *sdl.Renderer, rect Rect) func() {
+	var previous sdl.Rect
+	renderer.GetClipRect(&previous)
+	renderer.SetClipRect(&sdl.Rect{X: int32(rect.X), Y: int32(rect.Y), W: int32(rect.W), H: int32(rect.H)})
+	return func() {
+		if previous.W == 0 || previous.H == 0 {
+			renderer.SetClipRect(nil) // Empty means there was none
+		} else {
+			renderer.SetClipRect(&previous)
+		}
+	}
+}
+
+func fillRect(renderer *sdl.Renderer, rect Rect, color sdl.Color) {
+	if color.A == 0 || rect.W <= 0 || rect.H <= 0 {
+		return
+	}
+	setDrawColor(renderer, color)
+	renderer.FillRect(&sdl.Rect{X: int32(rect.X), Y: int32(rect.Y), W: int32(rect.W), H: int32(rect.H)})
+}
+
+func outlineRect(renderer *sdl.Renderer, rect Rect, color sdl.Color) {
+	if color.A == 0 || rect.W <= 0 || rect.H <= 0 {
+		return
+	}
+	setDrawColor(renderer, color)
+	renderer.DrawRect(&sdl.Rect{X: int32(rect.X), Y: int32(rect.Y), W: int32(rect.W), H: int32(rect.H)})
+}
+
+func fillCircle(renderer *sdl.Renderer, center box2dlite.Vec2, radius float64, color sdl.Color) {
+	if color.A == 0 || radius <= 0 {
+		return
+	}
+	setDrawColor(renderer, color)
+	FillPolygon(renderer, CirclePoints(center, radius, circleSegments(radius)))
+}
+
+func outlineCircle(renderer *sdl.Renderer, center box2dlite.Vec2, radius float64, color sdl.Color) {
+	if color.A == 0 || radius <= 0 {
+		return
+	}
+	setDrawColor(renderer, color)
+	DrawPolyline(renderer, CirclePoints(center, radius, circleSegments(radius)), true, 1)
+}
+
+// Draws a texture (or the region of it) stretched over dst with the corners unscaled,
+// like NineSliceSprite but without rotation or tiling
+func DrawNineSlice(renderer *sdl.Renderer, texture *sdl.Texture, region *sdl.Rect, dst Rect, slice Insets) {
+	var src sdl.Rect
+	if region != nil {
+		src = *region
+	} else {
+		_, _, tw, th, _ := texture.Query()
+		src = sdl.Rect{W: int32(tw), H: int32(th)}
+	}
+
+	w, h := int32(dst.W), int32(dst.H)
+	left, right := int32(slice.Left), int32(slice.Right)
+	top, bottom := int32(slice.Top), int32(slice.Bottom)
+	if left+right > w && left+right > 0 {
+		left = w * left / (left + right)
+		right = w - left
+	}
+	if top+bottom > h && top+bottom > 0 {
+		top = h * top / (top + bottom)
+		bottom = h - top
+	}
+
+	srcX := [4]int32{src.X, src.X + int32(slice.Left), src.X + src.W - int32(slice.Right), src.X + src.W}
+	srcY := [4]int32{src.Y, src.Y + int32(slice.Top), src.Y + src.H - int32(slice.Bottom), src.Y + src.H}
+	dstX := [4]int32{int32(dst.X), int32(dst.X) + left, int32(dst.X) + w - right, int32(dst.X) + w}
+	dstY := [4]int32{int32(dst.Y), int32(dst.Y) + top, int32(dst.Y) + h - bottom, int32(dst.Y) + h}
+
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			srcRect := sdl.Rect{X: srcX[col], Y: srcY[row], W: srcX[col+1] - srcX[col], H: srcY[row+1] - srcY[row]}
+			dstRect := sdl.Rect{X: dstX[col], Y: dstY[row], W: dstX[col+1] - dstX[col], H: dstY[row+1] - dstY[row]}
+			if srcRect.W <= 0 || srcRect.H <= 0 || dstRect.W <= 0 || dstRect.H <= 0 {
+				continue
+			}
+			renderer.Copy(texture, &srcRect, &dstRect)
+		}
+	}
+}
+
+// Creates an entity with a transform, the widget and an element, if element is nil
+// a auto sized one is created
+func NewWidgetEntity(widget Component, element *UIElement) Entity {
+	if element == nil {
+		element = NewAutoUIElement(LAYOUTNONE, 0)
+	}
+	ent := NewEntity(0, 0)
+	ent.AddComponent(element)
+	ent.AddComponent(widget)
+	return ent
+}
+
+// Enables or disables the entity and all its children
+func SetEntityEnabled(entity Entity, enabled bool) {
+	entity.SetEnabled(enabled)
+	for _, child := range entity.GetChildren(true) {
+		child.SetEnabled(enabled)
+	}
+}
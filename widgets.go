@@ -0,0 +1,461 @@
+package vroom
+
+import (
+	"fmt"
+	"github.com/jonas747/go-box2d-lite/box2dlite"
+	"github.com/veandco/go-sdl2/sdl"
+	"math"
+)
+
+// Box with a checkmark followed by the text
+type Checkbox struct {
+	Widget
+	Checked   bool
+	OnChanged func(checked bool)
+}
+
+func NewCheckbox(text string, checked bool, onChanged func(checked bool)) *Checkbox {
+	return &Checkbox{
+		Widget:    Widget{Text: text, Layer: UILAYER},
+		Checked:   checked,
+		OnChanged: onChanged,
+	}
+}
+
+func (c *Checkbox) Init() {
+	c.initWidget(c)
+}
+
+func (c *Checkbox) Name() string {
+	return "Checkbox"
+}
+
+func (c *Checkbox) SetChecked(checked bool) {
+	if checked == c.Checked {
+		return
+	}
+	c.Checked = checked
+	if c.OnChanged != nil {
+		c.OnChanged(checked)
+	}
+}
+
+func (c *Checkbox) click(x, y int) {
+	c.SetChecked(!c.Checked)
+}
+
+func (c *Checkbox) Size() (int, int) {
+	box := c.lineHeight()
+	return c.markedSize(box, box)
+}
+
+func (c *Checkbox) Draw(renderer *sdl.Renderer) {
+	box := c.lineHeight()
+	mark := c.drawMarked(renderer, box, box)
+	c.drawFrame(renderer, mark, c.State())
+	if c.Checked {
+		inset := math.Max(2, math.Floor(mark.W/4))
+		fillRect(renderer, mark.Grow(-inset), c.style().Accent)
+	}
+}
+
+// Switch that slides between off and on, works like a checkbox
+type Toggle struct {
+	Checkbox
+}
+
+func NewToggle(text string, on bool, onChanged func(on bool)) *Toggle {
+	return &Toggle{Checkbox: *NewCheckbox(text, on, onChanged)}
+}
+
+func (t *Toggle) Init() {
+	t.initWidget(t)
+}
+
+func (t *Toggle) Name() string {
+	return "Toggle"
+}
+
+func (t *Toggle) Size() (int, int) {
+	h := t.lineHeight()
+	return t.markedSize(h*2, h)
+}
+
+func (t *Toggle) Draw(renderer *sdl.Renderer) {
+	h := t.lineHeight()
+	track := t.drawMarked(renderer, h*2, h)
+	t.drawFrame(renderer, track, t.State())
+
+	knob := Rect{track.X, track.Y, track.H, track.H}
+	if t.Checked {
+		fillRect(renderer, track.Grow(-2), t.style().Accent)
+		knob.X = track.Right() - knob.W
+	}
+	knob = knob.Grow(-2)
+	stateStyle := t.stateStyle(t.State())
+	fillRect(renderer, knob, stateStyle.TextColor)
+	outlineRect(renderer, knob, stateStyle.Border)
+}
+
+// Keeps track of which of its radio buttons is selected
+type RadioGroup struct {
+	Selected  int // Index of the selected button, -1 for none
+	OnChanged func(index int)
+	Buttons   []*RadioButton
+}
+
+func NewRadioGroup(selected int, onChanged func(index int)) *RadioGroup {
+	return &RadioGroup{
+		Selected:  selected,
+		OnChanged: onChanged,
+	}
+}
+
+// Creates a radio button in this group, the index is the order they're created in
+func (g *RadioGroup) NewButton(text string) *RadioButton {
+	button := &RadioButton{
+		Widget: Widget{Text: text, Layer: UILAYER},
+		Group:  g,
+		Index:  len(g.Buttons),
+	}
+	g.Buttons = append(g.Buttons, button)
+	return button
+}
+
+func (g *RadioGroup) Select(index int) {
+	if index == g.Selected {
+		return
+	}
+	g.Selected = index
+	if g.OnChanged != nil {
+		g.OnChanged(index)
+	}
+}
+
+// One option in a RadioGroup, created with RadioGroup.NewButton
+type RadioButton struct {
+	Widget
+	Group *RadioGroup
+	Index int
+}
+
+func (r *RadioButton) Init() {
+	r.initWidget(r)
+}
+
+func (r *RadioButton) Name() string {
+	return "RadioButton"
+}
+
+func (r *RadioButton) Selected() bool {
+	return r.Group != nil && r.Group.Selected == r.Index
+}
+
+func (r *RadioButton) click(x, y int) {
+	if r.Group != nil {
+		r.Group.Select(r.Index)
+	}
+}
+
+func (r *RadioButton) Size() (int, int) {
+	size := r.lineHeight()
+	return r.markedSize(size, size)
+}
+
+func (r *RadioButton) Draw(renderer *sdl.Renderer) {
+	size := r.lineHeight()
+	mark := r.drawMarked(renderer, size, size)
+
+	stateStyle := r.stateStyle(r.State())
+	center := mark.Center()
+	radius := mark.W / 2
+	if stateStyle.Texture != "" {
+		r.drawFrame(renderer, mark, r.State())
+	} else {
+		fillCircle(renderer, center, radius, stateStyle.Fill)
+		outlineCircle(renderer, center, radius, stateStyle.Border)
+	}
+	if r.Selected() {
+		fillCircle(renderer, center, math.Max(1, radius/2), r.style().Accent)
+	}
+}
+
+// Horizontal slider picking a value between Min and Max by dragging the handle
+type Slider struct {
+	Widget
+	Min, Max float64
+	Value    float64
+	Step     float64 // Values snap to multiples of this from Min, 0 for no snapping
+
+	ShowValue   bool   // Draws the value after the slider
+	ValueFormat string // Format for the value, %.0f if empty
+
+	OnChanged func(value float64)
+
+	dragging bool
+}
+
+func NewSlider(min, max, value float64, onChanged func(value float64)) *Slider {
+	return &Slider{
+		Widget:    Widget{Layer: UILAYER},
+		Min:       min,
+		Max:       max,
+		Value:     value,
+		OnChanged: onChanged,
+	}
+}
+
+func (s *Slider) Init() {
+	s.initWidget(s)
+}
+
+func (s *Slider) Name() string {
+	return "Slider"
+}
+
+func (s *Slider) SetValue(value float64) {
+	if s.Step > 0 {
+		value = s.Min + math.Floor((value-s.Min)/s.Step+0.5)*s.Step
+	}
+	value = math.Max(s.Min, math.Min(s.Max, value))
+	if value == s.Value {
+		return
+	}
+	s.Value = value
+	if s.OnChanged != nil {
+		s.OnChanged(value)
+	}
+}
+
+// Value between 0 and 1
+func (s *Slider) Fraction() float64 {
+	if s.Max <= s.Min {
+		return 0
+	}
+	return (s.Value - s.Min) / (s.Max - s.Min)
+}
+
+func (s *Slider) formatValue(value float64) string {
+	format := s.ValueFormat
+	if format == "" {
+		format = "%.0f"
+	}
+	return fmt.Sprintf(format, value)
+}
+
+// Space taken by the value text, sized for Max so it doesn't jump around
+func (s *Slider) valueWidth() int {
+	if !s.ShowValue {
+		return 0
+	}
+	tw, _ := s.textSize(s.formatValue(s.Max))
+	return tw + s.style().Padding.Left
+}
+
+func (s *Slider) handleWidth() float64 {
+	return math.Max(6, math.Floor(float64(s.lineHeight())/2))
+}
+
+func (s *Slider) trackRect() Rect {
+	content := s.contentRect()
+	content.W = math.Max(0, content.W-float64(s.valueWidth()))
+	return content
+}
+
+func (s *Slider) setFromX(x int) {
+	track := s.trackRect()
+	handle := s.handleWidth()
+	usable := track.W - handle
+	if usable <= 0 {
+		return
+	}
+	fraction := (float64(x) - track.X - handle/2) / usable
+	s.SetValue(s.Min + math.Max(0, math.Min(1, fraction))*(s.Max-s.Min))
+}
+
+// Left and right move it by Step, or a twentieth of the range without one
+func (s *Slider) navigate(dir int) bool {
+	step := s.Step
+	if step <= 0 {
+		step = (s.Max - s.Min) / 20
+	}
+	switch dir {
+	case NAVLEFT:
+		s.SetValue(s.Value - step)
+	case NAVRIGHT:
+		s.SetValue(s.Value + step)
+	default:
+		return false
+	}
+	return true
+}
+
+func (s *Slider) press(x, y int) {
+	s.dragging = true
+	s.setFromX(x)
+	s.engine().UI.CaptureMouse(s)
+}
+
+func (s *Slider) CapturedMouseMove(x, y int) {
+	if s.dragging {
+		s.setFromX(x)
+	}
+}
+
+func (s *Slider) CapturedMouseButton(x, y, button int, up bool) {
+	if up && button == sdl.BUTTON_LEFT {
+		s.dragging = false
+		s.engine().UI.ReleaseMouse(s)
+		s.IsHover = s.Rect().Contains(float64(x), float64(y))
+		s.release()
+	}
+}
+
+func (s *Slider) Size() (int, int) {
+	style := s.style()
+	return 150 + s.valueWidth() + style.Padding.Horizontal(), s.lineHeight() + style.Padding.Vertical()
+}
+
+func (s *Slider) Draw(renderer *sdl.Renderer) {
+	state := s.State()
+	stateStyle := s.stateStyle(state)
+	track := s.trackRect()
+	handle := s.handleWidth()
+
+	bar := Rect{track.X, track.Y + math.Floor(track.H/2) - 2, track.W, 4}
+	fillRect(renderer, bar, stateStyle.Border)
+	handleX := math.Floor(track.X + (track.W-handle)*s.Fraction())
+	fillRect(renderer, Rect{bar.X, bar.Y, handleX - bar.X, bar.H}, s.style().Accent)
+	s.drawFrame(renderer, Rect{handleX, track.Y, handle, track.H}, state)
+
+	if s.ShowValue {
+		content := s.contentRect()
+		s.drawText(renderer, s.formatValue(s.Value), Rect{track.Right(), content.Y, content.Right() - track.Right(), content.H}, ALIGNRIGHT, stateStyle.TextColor)
+	}
+}
+
+// Bar filled by Value out of Max, shows Text on top (with {0} replaced by the percentage)
+type ProgressBar struct {
+	Widget
+	Value, Max float64
+}
+
+func NewProgressBar(value, max float64) *ProgressBar {
+	return &ProgressBar{
+		Widget: Widget{Layer: UILAYER},
+		Value:  value,
+		Max:    max,
+	}
+}
+
+func (p *ProgressBar) Init() {
+	p.initWidget(p)
+}
+
+func (p *ProgressBar) Name() string {
+	return "ProgressBar"
+}
+
+// Value between 0 and 1
+func (p *ProgressBar) Fraction() float64 {
+	if p.Max <= 0 {
+		return 0
+	}
+	return math.Max(0, math.Min(1, p.Value/p.Max))
+}
+
+func (p *ProgressBar) CanFocus() bool {
+	return false
+}
+
+func (p *ProgressBar) Size() (int, int) {
+	w, h := p.Widget.Size()
+	return int(math.Max(float64(w), 150)), h
+}
+
+func (p *ProgressBar) Draw(renderer *sdl.Renderer) {
+	state := WIDGETIDLE
+	if p.Disabled {
+		state = WIDGETDISABLED
+	}
+	rect := p.Rect()
+	p.drawFrame(renderer, rect, state)
+
+	fill := rect.Grow(-2)
+	fill.W = math.Floor(fill.W * p.Fraction())
+	fillRect(renderer, fill, p.style().Accent)
+
+	text := formatLocalized(p.Text, -1, []interface{}{int(p.Fraction()*100 + 0.5)})
+	p.drawText(renderer, text, rect, ALIGNCENTER, p.stateStyle(state).TextColor)
+}
+
+// Text on several lines, wrapped at the widget width, for longer messages like the ones in dialogs
+// It has no background, the preferred size wraps at MaxWidth
+type TextBlock struct {
+	Widget
+	MaxWidth int // 0 only breaks at newlines
+	Align    int // ALIGNLEFT, ALIGNCENTER or ALIGNRIGHT
+}
+
+func NewTextBlock(text string, maxWidth int) *TextBlock {
+	return &TextBlock{
+		Widget:   Widget{Text: text, Layer: UILAYER},
+		MaxWidth: maxWidth,
+	}
+}
+
+func (t *TextBlock) Init() {
+	t.initWidget(t)
+}
+
+func (t *TextBlock) Name() string {
+	return "TextBlock"
+}
+
+func (t *TextBlock) CanFocus() bool {
+	return false
+}
+
+func (t *TextBlock) lines(width int) []TextLine {
+	font := t.font()
+	if font == nil {
+		return nil
+	}
+	return wrapLines(font.Measure, t.Text, width)
+}
+
+func (t *TextBlock) Size() (int, int) {
+	widest := 0
+	lines := t.lines(t.MaxWidth)
+	if font := t.font(); font != nil {
+		for _, line := range lines {
+			widest = int(math.Max(float64(widest), float64(font.Measure(line.Text))))
+		}
+	}
+	return widest, len(lines) * t.lineHeight()
+}
+
+func (t *TextBlock) Draw(renderer *sdl.Renderer) {
+	state := WIDGETIDLE
+	if t.Disabled {
+		state = WIDGETDISABLED
+	}
+	rect := t.Rect()
+	lineHeight := float64(t.lineHeight())
+	for k, line := range t.lines(int(rect.W)) {
+		lineRect := Rect{rect.X, rect.Y + float64(k)*lineHeight, rect.W, lineHeight}
+		t.drawText(renderer, line.Text, lineRect, t.Align, t.stateStyle(state).TextColor)
+	}
+}
+
+// Small triangle pointing down, for dropdowns
+func drawArrow(renderer *sdl.Renderer, rect Rect, color sdl.Color) {
+	center := rect.Center()
+	size := math.Max(3, math.Floor(math.Min(rect.W, rect.H)/4))
+	setDrawColor(renderer, color)
+	FillPolygon(renderer, []box2dlite.Vec2{
+		{center.X - size, center.Y - size/2},
+		{center.X + size, center.Y - size/2},
+		{center.X, center.Y + size/2},
+	})
+}